@@ -0,0 +1,32 @@
+package routes
+
+import (
+	"log/slog"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hftamayo/gotodo/api/v1/admin"
+	"github.com/hftamayo/gotodo/api/v1/apikey"
+	"github.com/hftamayo/gotodo/pkg/config"
+	"github.com/hftamayo/gotodo/pkg/middleware"
+)
+
+// SetupAdminRoutes registers the admin endpoints, those handler can't serve are left out
+func SetupAdminRoutes(r *gin.Engine, handler *admin.Handler, apiKeyHandler *apikey.Handler, adminConfig *config.AdminConfig) {
+	if adminConfig.Token == "" {
+		slog.Info("ADMIN_TOKEN is not set, admin endpoints are disabled")
+		return
+	}
+
+	adminGroup := r.Group("/tasks/admin", middleware.AdminAuth(adminConfig.Token))
+	{
+		if handler.HasCacheStats() {
+			adminGroup.GET("/cache", handler.CacheStats)
+		}
+		if handler.HasErrorLog() {
+			adminGroup.GET("/errors", handler.Errors)
+		}
+		adminGroup.POST("/apikeys", apiKeyHandler.Create)
+		adminGroup.GET("/apikeys", apiKeyHandler.List)
+		adminGroup.DELETE("/apikeys/:id", apiKeyHandler.Revoke)
+	}
+}
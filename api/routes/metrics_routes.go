@@ -0,0 +1,28 @@
+package routes
+
+import (
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hftamayo/gotodo/pkg/config"
+	"github.com/hftamayo/gotodo/pkg/metrics"
+	"github.com/hftamayo/gotodo/pkg/middleware"
+)
+
+// SetupMetricsServer serves the Prometheus endpoint on its own listener,
+// scrapers don't go through the CORS, identity and rate limiter middleware
+// of the API. The endpoint always needs the bearer token.
+func SetupMetricsServer(appMetrics *metrics.Metrics, metricsConfig *config.MetricsConfig) *http.Server {
+	r := gin.New()
+	r.Use(middleware.Recovery(slog.Default()))
+	r.GET(metricsConfig.Path, middleware.BearerAuth(metricsConfig.Token), appMetrics.Handler())
+
+	return &http.Server{
+		Addr:         metricsConfig.Addr,
+		Handler:      r,
+		ReadTimeout:  15 * time.Second,
+		WriteTimeout: 15 * time.Second,
+	}
+}
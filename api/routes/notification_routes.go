@@ -0,0 +1,13 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/hftamayo/gotodo/api/v1/notification"
+)
+
+func SetupNotificationRoutes(r *gin.Engine, handler *notification.Handler) {
+	const preferencesPath = "/tasks/notifications/preferences"
+
+	r.GET(preferencesPath+"/:userId", handler.GetPreference)
+	r.PUT(preferencesPath+"/:userId", handler.UpdatePreference)
+}
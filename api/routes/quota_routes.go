@@ -0,0 +1,10 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/hftamayo/gotodo/api/v1/apikey"
+)
+
+func SetupQuotaRoutes(r *gin.Engine, handler *apikey.Handler) {
+	r.GET("/tasks/quota", handler.Quota)
+}
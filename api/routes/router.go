@@ -1,25 +1,175 @@
 package routes
 
 import (
+	"context"
+	"fmt"
+	"log/slog"
+
 	"github.com/gin-gonic/gin"
+	"github.com/hftamayo/gotodo/api/v1/admin"
+	"github.com/hftamayo/gotodo/api/v1/apikey"
 	"github.com/hftamayo/gotodo/api/v1/health"
+	"github.com/hftamayo/gotodo/api/v1/notification"
 	"github.com/hftamayo/gotodo/api/v1/task"
+	"github.com/hftamayo/gotodo/api/v1/webhook"
+	"github.com/hftamayo/gotodo/pkg/cache"
 	"github.com/hftamayo/gotodo/pkg/config"
+	"github.com/hftamayo/gotodo/pkg/events"
+	"github.com/hftamayo/gotodo/pkg/metrics"
+	"github.com/hftamayo/gotodo/pkg/middleware"
+	"github.com/hftamayo/gotodo/pkg/outbox"
+	"github.com/hftamayo/gotodo/pkg/stream"
+	"github.com/hftamayo/gotodo/pkg/utils"
 	"gorm.io/gorm"
 )
 
-func SetupRouter(r *gin.Engine, db *gorm.DB, cache config.CacheInterface, errorLogger config.ErrorLogger) {
-	
+// SetupRouter wires services, middleware and handlers, background workers
+// started here run until ctx is cancelled. redisClient may be nil or point to
+// a Redis that is down, rateLimiter then counts requests in memory. appMetrics is
+// nil when metrics are disabled, they are served by SetupMetricsServer.
+func SetupRouter(ctx context.Context, r *gin.Engine, db *gorm.DB, appCache cache.Cache, errorLogger config.ErrorLogger, redisClient utils.RedisClientInterface, rateLimiter *utils.RateLimiter, appMetrics *metrics.Metrics) {
+	// Metrics come first so the requests rejected by the rate limiter are counted
+	if appMetrics != nil {
+		observeMetrics(r, appMetrics, db, appCache, errorLogger, rateLimiter)
+	}
+
+	// Clients are identified by their API key before being rate limited,
+	// rejected keys are counted against the client IP
+	apiKeyService := apikey.NewAPIKeyService(apikey.NewAPIKeyRepositoryImpl(db), appCache, rateLimiter)
+	r.Use(middleware.IdentityMiddleware(apiKeyService, rateLimiter))
+	r.Use(middleware.RateLimiter(rateLimiter))
+
+	outboxConfig := config.DefaultOutboxConfig()
+	eventBus := events.NewBus()
+
+	// With the outbox, task events are written in the same transaction as the
+	// change and published by the relay, otherwise the service publishes them
 	taskRepo := task.NewTaskRepositoryImpl(db)
+	if outboxConfig.Enabled {
+		taskRepo = task.NewTaskRepositoryWithOutbox(db)
+	}
 
 	// Create task service with custom configuration
 	taskServiceConfig := task.DefaultTaskServiceConfig()
 	taskServiceConfig.ErrorLogger = errorLogger
-	taskService := task.NewTaskServiceWithConfig(taskRepo, cache, taskServiceConfig)
+	if !outboxConfig.Enabled {
+		taskServiceConfig.EventPublisher = eventBus
+	}
+	taskServiceConfig.PrefetchLimiter = rateLimiter
+	if appMetrics != nil {
+		taskServiceConfig.CacheRecorder = appMetrics
+	}
+	taskService := task.NewTaskServiceWithConfig(taskRepo, appCache, taskServiceConfig)
+
+	// Warm the first pages now and whenever the cache comes back from its fallback
+	go taskService.Warm(ctx)
+	if recoverable, ok := appCache.(interface{ OnRecover(fn func()) }); ok {
+		recoverable.OnRecover(func() { taskService.Warm(ctx) })
+	}
+
+	// Task reminders
+	notificationConfig := config.DefaultNotificationConfig()
+	notificationRepo := notification.NewNotificationRepositoryImpl(db)
+	notificationService := notification.NewNotificationService(notificationRepo,
+		notification.NewNotifiers(notificationConfig), notificationConfig, errorLogger)
+	go notificationService.StartScheduler(ctx)
+
+	// Outbound webhooks for task lifecycle events
+	webhookRepo := webhook.NewWebhookRepositoryImpl(db)
+	webhookDispatcher := webhook.NewDispatcher(webhookRepo, config.DefaultWebhookConfig(), errorLogger)
+	if appMetrics != nil {
+		if err := appMetrics.ObserveWebhooks(webhookDispatcher); err != nil {
+			slog.Warn("Webhook metrics are disabled", "error", err)
+		}
+	}
+	if outboxConfig.Enabled {
+		eventBus.Subscribe(task.CacheInvalidationSubscriber(taskService))
+		eventBus.Subscribe(webhookDispatcher.Deliver)
+	} else {
+		eventBus.Subscribe(webhookDispatcher.HandleEvent)
+	}
+	go webhookDispatcher.Start(ctx)
+	webhookService := webhook.NewWebhookService(webhookRepo, webhookDispatcher)
+
+	// Real-time task change stream, fanned out across instances through Redis
+	streamHub := stream.NewHub()
+	go func() {
+		// Open streams would otherwise hold the server shutdown until its deadline
+		<-ctx.Done()
+		streamHub.Close()
+	}()
+	streamBroker := stream.NewBroker(redisClient, streamHub)
+	eventBus.Subscribe(func(ctx context.Context, event events.Event) error {
+		// Live clients are told to refetch, a missed push is not worth a retry
+		streamBroker.Publish(ctx, event)
+		return nil
+	})
+	go streamBroker.Start(ctx)
+
+	if outboxConfig.Enabled {
+		if redisClient != nil && outboxConfig.RedisStream != "" {
+			streamWriter := stream.NewStreamWriter(redisClient, outboxConfig.RedisStream, outboxConfig.RedisStreamMaxLen)
+			eventBus.Subscribe(func(ctx context.Context, event events.Event) error {
+				// Best effort like the live stream, a Redis outage must not
+				// hold back webhooks or replay the other subscribers
+				if err := streamWriter.Publish(ctx, event); err != nil {
+					errorLogger.LogError(ctx, "outbox-relay", "stream-publish",
+						fmt.Sprintf("Failed to append event %s to the Redis stream: %v", event.ID, err),
+						map[string]interface{}{"event_id": event.ID, "task_id": event.TaskID, "error": err.Error()})
+				}
+				return nil
+			})
+		}
+		go outbox.NewRelay(db, eventBus, outboxConfig, errorLogger).Start(ctx)
+	}
 
 	taskHandler := task.NewHandler(taskService)
+	streamHandler := task.NewStreamHandler(streamHub)
+	notificationHandler := notification.NewHandler(notificationService)
+	webhookHandler := webhook.NewHandler(webhookService)
 	healthHandler := health.NewHealthHandler(db)
+	apiKeyHandler := apikey.NewHandler(apiKeyService, rateLimiter)
 
-	SetupTaskRoutes(r, taskHandler)
+	SetupTaskRoutes(r, taskHandler, streamHandler)
+	SetupNotificationRoutes(r, notificationHandler)
+	SetupWebhookRoutes(r, webhookHandler)
 	SetupHealthCheckRoutes(r, healthHandler)
+	SetupQuotaRoutes(r, apiKeyHandler)
+
+	// Admin endpoints need a token, cache stats need a cache that keeps them
+	// and the error log a logger that can read its errors back
+	var cacheStats admin.CacheStatsProvider
+	if instrumented, ok := appCache.(*cache.InstrumentedCache); ok {
+		cacheStats = instrumented
+	}
+	var errorLog config.ErrorLogReader
+	if reader, ok := errorLogger.(config.ErrorLogReader); ok {
+		errorLog = reader
+	}
+	adminHandler := admin.NewHandler(cacheStats, errorLog)
+	SetupAdminRoutes(r, adminHandler, apiKeyHandler, config.DefaultAdminConfig())
+}
+
+// observeMetrics counts the requests and collects the stats of the database,
+// cache, rate limiter and error logger, those that can't be observed are
+// left out of the metrics
+func observeMetrics(r *gin.Engine, appMetrics *metrics.Metrics, db *gorm.DB, appCache cache.Cache, errorLogger config.ErrorLogger, rateLimiter *utils.RateLimiter) {
+	r.Use(appMetrics.Middleware())
+
+	if err := appMetrics.ObserveDB(db, "gotodo"); err != nil {
+		slog.Warn("Database metrics are disabled", "error", err)
+	}
+	if stats, ok := appCache.(metrics.CacheStatsProvider); ok {
+		if err := appMetrics.ObserveCache(stats); err != nil {
+			slog.Warn("Cache metrics are disabled", "error", err)
+		}
+	}
+	if err := appMetrics.ObserveRateLimiter(rateLimiter); err != nil {
+		slog.Warn("Rate limiter metrics are disabled", "error", err)
+	}
+	if stats, ok := errorLogger.(metrics.ErrorLogStatsProvider); ok {
+		if err := appMetrics.ObserveErrorLogger(stats); err != nil {
+			slog.Warn("Error log metrics are disabled", "error", err)
+		}
+	}
 }
@@ -9,12 +9,17 @@ const (
     basePath    = "/tasks/task"
 )
 
-func SetupTaskRoutes(r *gin.Engine, handler *task.Handler) {
+func SetupTaskRoutes(r *gin.Engine, handler *task.Handler, streamHandler *task.StreamHandler) {
     taskGroup := r.Group(basePath)
     {
         taskGroup.GET("/list", handler.List)
         taskGroup.GET("/list/page", handler.List)
+        taskGroup.GET("/stream", streamHandler.SSE)
+        taskGroup.GET("/ws", streamHandler.WebSocket)
+        taskGroup.GET("/export", handler.Export)
+        taskGroup.POST("/import", handler.Import)
         taskGroup.GET("/:id", handler.ListById)
+        taskGroup.GET("/:id/cache", handler.CacheInfo)
         taskGroup.POST("", handler.Create)
         taskGroup.PATCH("/:id", handler.Update)
         taskGroup.PATCH("/:id/done", handler.Done)
@@ -0,0 +1,18 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/hftamayo/gotodo/api/v1/webhook"
+)
+
+func SetupWebhookRoutes(r *gin.Engine, handler *webhook.Handler) {
+	webhookGroup := r.Group("/tasks/webhooks")
+	{
+		webhookGroup.GET("", handler.List)
+		webhookGroup.POST("", handler.Create)
+		webhookGroup.GET("/:id", handler.ListById)
+		webhookGroup.DELETE("/:id", handler.Delete)
+		webhookGroup.GET("/:id/deliveries", handler.Deliveries)
+		webhookGroup.POST("/deliveries/:deliveryId/redeliver", handler.Redeliver)
+	}
+}
@@ -0,0 +1,123 @@
+package admin
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hftamayo/gotodo/pkg/cache"
+	"github.com/hftamayo/gotodo/pkg/config"
+)
+
+const (
+	defaultTopKeys = 20
+	maxTopKeys     = 1000
+
+	defaultErrorsLimit = 50
+	maxErrorsLimit     = 500
+)
+
+// CacheStatsProvider is a cache that keeps operation stats
+type CacheStatsProvider interface {
+	Stats(topN int) cache.Stats
+}
+
+type Handler struct {
+	cache    CacheStatsProvider
+	errorLog config.ErrorLogReader
+}
+
+type CacheStatsResponse struct {
+	Timestamp string `json:"timestamp"`
+	cache.Stats
+}
+
+type ErrorsQuery struct {
+	Severity  string    `form:"severity" binding:"omitempty,oneof=warning error critical"`
+	Service   string    `form:"service"`
+	Operation string    `form:"operation"`
+	Since     time.Time `form:"since" time_format:"2006-01-02T15:04:05Z07:00"`
+	Until     time.Time `form:"until" time_format:"2006-01-02T15:04:05Z07:00"`
+	Cursor    string    `form:"cursor"`
+	Limit     int       `form:"limit" binding:"omitempty,gt=0"`
+}
+
+// NewHandler creates the admin handler, cache or errorLog is nil when the
+// application doesn't support the matching endpoint
+func NewHandler(cache CacheStatsProvider, errorLog config.ErrorLogReader) *Handler {
+	return &Handler{cache: cache, errorLog: errorLog}
+}
+
+// HasCacheStats reports whether CacheStats can be served
+func (h *Handler) HasCacheStats() bool {
+	return h.cache != nil
+}
+
+// HasErrorLog reports whether Errors can be served
+func (h *Handler) HasErrorLog() bool {
+	return h.errorLog != nil
+}
+
+// CacheStats returns the per-operation counters of the cache and its most
+// read keys, ?top= sets how many keys are listed
+func (h *Handler) CacheStats(c *gin.Context) {
+	top := defaultTopKeys
+	if value := c.Query("top"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "top must be a non-negative integer",
+			})
+			return
+		}
+		top = min(parsed, maxTopKeys)
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusOK, CacheStatsResponse{
+		Timestamp: time.Now().UTC().Format(time.RFC3339),
+		Stats:     h.cache.Stats(top),
+	})
+}
+
+// Errors pages through the logged errors, newest first, filtered by
+// ?severity=, ?service=, ?operation= and the ?since= and ?until= RFC 3339 times
+func (h *Handler) Errors(c *gin.Context) {
+	var query ErrorsQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	if query.Limit == 0 {
+		query.Limit = defaultErrorsLimit
+	}
+
+	page, err := h.errorLog.QueryErrors(c.Request.Context(), config.ErrorLogQuery{
+		Severity:  config.ErrorSeverity(query.Severity),
+		Service:   query.Service,
+		Operation: query.Operation,
+		Since:     query.Since,
+		Until:     query.Until,
+		Cursor:    query.Cursor,
+		Limit:     min(query.Limit, maxErrorsLimit),
+	})
+	switch {
+	case errors.Is(err, config.ErrInvalidErrorLogQuery):
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	case err != nil:
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"error": "Failed to read the error log",
+		})
+		return
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusOK, page)
+}
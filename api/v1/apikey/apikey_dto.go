@@ -0,0 +1,86 @@
+package apikey
+
+import (
+	"time"
+
+	"github.com/hftamayo/gotodo/api/v1/models"
+	"github.com/hftamayo/gotodo/pkg/utils"
+)
+
+type CreateAPIKeyRequest struct {
+	Owner uint   `json:"owner" binding:"required"`
+	Name  string `json:"name" binding:"required"`
+	Tier  string `json:"tier"`
+}
+
+type APIKeyResponse struct {
+	ID        uint      `json:"id"`
+	Owner     uint      `json:"owner"`
+	Name      string    `json:"name"`
+	Prefix    string    `json:"prefix"`
+	Tier      string    `json:"tier"`
+	Key       string    `json:"key,omitempty"` // only returned when the key is created
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+type OperationLimit struct {
+	Limit  int64  `json:"limit"`
+	Window string `json:"window"`
+}
+
+type QuotaResponse struct {
+	Identity   string                                 `json:"identity"`
+	Tier       string                                 `json:"tier,omitempty"`
+	Limits     map[utils.OperationType]OperationLimit `json:"limits"`
+	DailyQuota *utils.QuotaResult                     `json:"dailyQuota,omitempty"`
+}
+
+type APIKeyOperationResponse struct {
+	Code          int         `json:"code"`
+	ResultMessage string      `json:"resultMessage"`
+	Data          interface{} `json:"data,omitempty"`
+	Timestamp     int64       `json:"timestamp"`
+}
+
+type ErrorResponse struct {
+	Code          int    `json:"code"`
+	ResultMessage string `json:"resultMessage"`
+	Error         string `json:"error,omitempty"`
+}
+
+func ToAPIKeyResponse(key *models.APIKey) *APIKeyResponse {
+	return &APIKeyResponse{
+		ID:        key.ID,
+		Owner:     key.Owner,
+		Name:      key.Name,
+		Prefix:    key.Prefix,
+		Tier:      key.Tier,
+		CreatedAt: key.CreatedAt,
+	}
+}
+
+func APIKeysToResponse(keys []*models.APIKey) []*APIKeyResponse {
+	responses := make([]*APIKeyResponse, len(keys))
+	for i, key := range keys {
+		responses[i] = ToAPIKeyResponse(key)
+	}
+	return responses
+}
+
+// NewAPIKeyOperationResponse creates a new APIKeyOperationResponse with the given status
+func NewAPIKeyOperationResponse(code int, data interface{}) APIKeyOperationResponse {
+	return APIKeyOperationResponse{
+		Code:          code,
+		ResultMessage: utils.OperationSuccess,
+		Data:          data,
+		Timestamp:     time.Now().Unix(),
+	}
+}
+
+func NewErrorResponse(code int, resultMessage string, err string) *ErrorResponse {
+	return &ErrorResponse{
+		Code:          code,
+		ResultMessage: resultMessage,
+		Error:         err,
+	}
+}
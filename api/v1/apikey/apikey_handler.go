@@ -0,0 +1,156 @@
+package apikey
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hftamayo/gotodo/pkg/middleware"
+	"github.com/hftamayo/gotodo/pkg/utils"
+)
+
+var (
+	ErrInvalidID      = errors.New("invalid ID parameter")
+	ErrInvalidOwner   = errors.New("invalid owner parameter")
+	ErrInvalidRequest = errors.New("invalid request body")
+	ErrAPIKeyNotFound = errors.New("API key not found")
+)
+
+type Handler struct {
+	service APIKeyServiceInterface
+	limits  QuotaReporter
+}
+
+func NewHandler(service APIKeyServiceInterface, limits QuotaReporter) *Handler {
+	if service == nil {
+		panic("API key service is required")
+	}
+	return &Handler{service: service, limits: limits}
+}
+
+func (h *Handler) Create(c *gin.Context) {
+	var request CreateAPIKeyRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, NewErrorResponse(
+			http.StatusBadRequest,
+			utils.OperationFailed,
+			ErrInvalidRequest.Error(),
+		))
+		return
+	}
+
+	key, raw, err := h.service.CreateKey(request.Owner, request.Name, request.Tier)
+	if err != nil {
+		statusCode := http.StatusInternalServerError
+		if errors.Is(err, ErrInvalidTier) || errors.Is(err, ErrInvalidName) {
+			statusCode = http.StatusBadRequest
+		}
+
+		c.JSON(statusCode, NewErrorResponse(
+			statusCode,
+			utils.OperationFailed,
+			err.Error(),
+		))
+		return
+	}
+
+	// The key is only disclosed once, only its hash is stored
+	response := ToAPIKeyResponse(key)
+	response.Key = raw
+	c.JSON(http.StatusCreated, NewAPIKeyOperationResponse(http.StatusCreated, response))
+}
+
+func (h *Handler) List(c *gin.Context) {
+	owner, err := strconv.ParseUint(c.Query("owner"), 10, 64)
+	if err != nil || owner == 0 {
+		c.JSON(http.StatusBadRequest, NewErrorResponse(
+			http.StatusBadRequest,
+			utils.OperationFailed,
+			ErrInvalidOwner.Error(),
+		))
+		return
+	}
+
+	keys, err := h.service.ListKeys(uint(owner))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, NewErrorResponse(
+			http.StatusInternalServerError,
+			utils.OperationFailed,
+			err.Error(),
+		))
+		return
+	}
+
+	c.JSON(http.StatusOK, NewAPIKeyOperationResponse(http.StatusOK, APIKeysToResponse(keys)))
+}
+
+func (h *Handler) Revoke(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil || id == 0 {
+		c.JSON(http.StatusBadRequest, NewErrorResponse(
+			http.StatusBadRequest,
+			utils.OperationFailed,
+			ErrInvalidID.Error(),
+		))
+		return
+	}
+
+	if err := h.service.RevokeKey(uint(id)); err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			c.JSON(http.StatusNotFound, NewErrorResponse(
+				http.StatusNotFound,
+				utils.OperationFailed,
+				ErrAPIKeyNotFound.Error(),
+			))
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, NewErrorResponse(
+			http.StatusInternalServerError,
+			utils.OperationFailed,
+			err.Error(),
+		))
+		return
+	}
+
+	c.JSON(http.StatusOK, NewAPIKeyOperationResponse(http.StatusOK, nil))
+}
+
+// Quota returns the limits of the calling client and its daily quota usage
+func (h *Handler) Quota(c *gin.Context) {
+	identity := middleware.GetIdentity(c)
+
+	usage, err := h.limits.QuotaUsage(identity.ID, identity.Tier)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, NewErrorResponse(
+			http.StatusInternalServerError,
+			utils.OperationFailed,
+			err.Error(),
+		))
+		return
+	}
+
+	policy := h.limits.Policy()
+	limits := make(map[utils.OperationType]OperationLimit)
+	for _, op := range []utils.OperationType{utils.OperationRead, utils.OperationWrite, utils.OperationPrefetch} {
+		config, _, err := policy.Resolve(op, "", identity.Tier)
+		if err != nil {
+			continue
+		}
+		limits[op] = OperationLimit{
+			Limit:  config.MaxRequests,
+			Window: (time.Duration(config.Window) * time.Second).String(),
+		}
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusOK, NewAPIKeyOperationResponse(http.StatusOK, QuotaResponse{
+		Identity:   identity.Kind,
+		Tier:       identity.Tier,
+		Limits:     limits,
+		DailyQuota: usage,
+	}))
+}
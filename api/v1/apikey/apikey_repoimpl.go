@@ -0,0 +1,72 @@
+package apikey
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/hftamayo/gotodo/api/v1/models"
+	"gorm.io/gorm"
+)
+
+type APIKeyRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewAPIKeyRepositoryImpl(db *gorm.DB) APIKeyRepository {
+	if db == nil {
+		return nil
+	}
+	return &APIKeyRepositoryImpl{db: db}
+}
+
+func (r *APIKeyRepositoryImpl) Create(key *models.APIKey) (*models.APIKey, error) {
+	if key == nil {
+		return nil, errors.New("API key cannot be nil")
+	}
+
+	if err := r.db.Create(key).Error; err != nil {
+		return nil, fmt.Errorf("failed to create API key: %w", err)
+	}
+	return key, nil
+}
+
+func (r *APIKeyRepositoryImpl) ListByOwner(owner uint) ([]*models.APIKey, error) {
+	var keys []*models.APIKey
+	if err := r.db.Where("owner = ?", owner).Order("id asc").Find(&keys).Error; err != nil {
+		return nil, fmt.Errorf("failed to list API keys: %w", err)
+	}
+	return keys, nil
+}
+
+func (r *APIKeyRepositoryImpl) Get(id uint) (*models.APIKey, error) {
+	var key models.APIKey
+	if err := r.db.First(&key, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get API key: %w", err)
+	}
+	return &key, nil
+}
+
+func (r *APIKeyRepositoryImpl) FindByHash(hash string) (*models.APIKey, error) {
+	var key models.APIKey
+	if err := r.db.Where("key_hash = ?", hash).First(&key).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to find API key: %w", err)
+	}
+	return &key, nil
+}
+
+func (r *APIKeyRepositoryImpl) Delete(id uint) error {
+	result := r.db.Delete(&models.APIKey{}, id)
+	if result.Error != nil {
+		return fmt.Errorf("failed to delete API key: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf(errAPIKeyNotFoundFmt, id)
+	}
+	return nil
+}
@@ -0,0 +1,16 @@
+package apikey
+
+import (
+	"github.com/hftamayo/gotodo/api/v1/models"
+)
+
+type APIKeyRepository interface {
+	Create(key *models.APIKey) (*models.APIKey, error)
+	ListByOwner(owner uint) ([]*models.APIKey, error)
+	Get(id uint) (*models.APIKey, error)
+	FindByHash(hash string) (*models.APIKey, error)
+	Delete(id uint) error
+}
+
+// Ensure APIKeyRepositoryImpl implements APIKeyRepository at compile time
+var _ APIKeyRepository = (*APIKeyRepositoryImpl)(nil)
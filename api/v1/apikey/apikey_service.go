@@ -0,0 +1,148 @@
+package apikey
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/hftamayo/gotodo/api/v1/models"
+	"github.com/hftamayo/gotodo/pkg/cache"
+	"github.com/hftamayo/gotodo/pkg/cache/keys"
+	"github.com/hftamayo/gotodo/pkg/middleware"
+	"github.com/hftamayo/gotodo/pkg/utils"
+)
+
+var (
+	ErrInvalidTier = errors.New("unknown tier")
+	ErrInvalidName = errors.New("API key name must be between 1 and 50 characters")
+)
+
+type APIKeyService struct {
+	repo   APIKeyRepository
+	cache  cache.Cache
+	keys   *keys.Generator
+	limits QuotaReporter
+}
+
+var _ APIKeyServiceInterface = (*APIKeyService)(nil)
+
+// NewAPIKeyService creates an API key service, resolved keys are cached in
+// c and the tiers are the roles of the rate limit policy of limits
+func NewAPIKeyService(repo APIKeyRepository, c cache.Cache, limits QuotaReporter) *APIKeyService {
+	return &APIKeyService{
+		repo:   repo,
+		cache:  c,
+		keys:   keys.NewVersionedGenerator("apikeys", 1),
+		limits: limits,
+	}
+}
+
+// CreateKey generates a key for owner on tier, only its hash is stored
+func (s *APIKeyService) CreateKey(owner uint, name string, tier string) (*models.APIKey, string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || len(name) > 50 {
+		return nil, "", ErrInvalidName
+	}
+	if tier == "" {
+		tier = utils.TierFree
+	}
+	if _, ok := s.limits.Policy().Roles[tier]; !ok {
+		return nil, "", fmt.Errorf("%w: %s", ErrInvalidTier, tier)
+	}
+
+	raw, err := generateKey()
+	if err != nil {
+		return nil, "", err
+	}
+
+	key, err := s.repo.Create(&models.APIKey{
+		Owner:   owner,
+		Name:    name,
+		Prefix:  raw[:displayPrefixLength],
+		KeyHash: hashKey(raw),
+		Tier:    tier,
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	return key, raw, nil
+}
+
+func (s *APIKeyService) ListKeys(owner uint) ([]*models.APIKey, error) {
+	return s.repo.ListByOwner(owner)
+}
+
+// RevokeKey deletes a key, requests using it are rejected right away
+func (s *APIKeyService) RevokeKey(id uint) error {
+	key, err := s.repo.Get(id)
+	if err != nil {
+		return err
+	}
+	if key == nil {
+		return fmt.Errorf(errAPIKeyNotFoundFmt, id)
+	}
+
+	if err := s.repo.Delete(id); err != nil {
+		return err
+	}
+	if err := s.cache.Delete(s.keys.Build(key.KeyHash)); err != nil {
+		slog.Warn("Failed to evict revoked API key, it stays usable until its cache entry expires", "key_id", id, "ttl", resolvedKeyTTL.String(), "error", err)
+	}
+	return nil
+}
+
+// ResolveAPIKey returns the identity of the client owning key
+func (s *APIKeyService) ResolveAPIKey(raw string) (*middleware.Identity, error) {
+	if !strings.HasPrefix(raw, keyPrefix) {
+		return nil, middleware.ErrUnknownAPIKey
+	}
+
+	hash := hashKey(raw)
+	cacheKey := s.keys.Build(hash)
+	var identity middleware.Identity
+	if err := s.cache.Get(cacheKey, &identity); err == nil {
+		return &identity, nil
+	}
+
+	key, err := s.repo.FindByHash(hash)
+	if err != nil {
+		return nil, err
+	}
+	if key == nil {
+		return nil, middleware.ErrUnknownAPIKey
+	}
+
+	identity = middleware.Identity{
+		Kind:  middleware.IdentityAPIKey,
+		ID:    fmt.Sprintf("key:%d", key.ID),
+		Owner: key.Owner,
+		KeyID: key.ID,
+		Tier:  key.Tier,
+	}
+	if key.Owner != 0 {
+		// Every key of a user shares the limits of the user
+		identity.ID = fmt.Sprintf("user:%d", key.Owner)
+	}
+	if err := s.cache.Set(cacheKey, identity, resolvedKeyTTL); err != nil {
+		slog.Warn("Failed to cache API key", "key_id", key.ID, "error", err)
+	}
+	return &identity, nil
+}
+
+// generateKey returns a random API key
+func generateKey() (string, error) {
+	buf := make([]byte, 24)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate API key: %w", err)
+	}
+	return keyPrefix + hex.EncodeToString(buf), nil
+}
+
+func hashKey(raw string) string {
+	sum := sha256.Sum256([]byte(raw))
+	return hex.EncodeToString(sum[:])
+}
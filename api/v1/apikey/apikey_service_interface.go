@@ -0,0 +1,24 @@
+package apikey
+
+import (
+	"github.com/hftamayo/gotodo/api/v1/models"
+	"github.com/hftamayo/gotodo/pkg/middleware"
+	"github.com/hftamayo/gotodo/pkg/utils"
+)
+
+// APIKeyServiceInterface defines the contract for API key operations
+type APIKeyServiceInterface interface {
+	middleware.IdentityResolver
+
+	// CreateKey returns the stored key and the key itself, which can't be recovered later
+	CreateKey(owner uint, name string, tier string) (*models.APIKey, string, error)
+	ListKeys(owner uint) ([]*models.APIKey, error)
+	RevokeKey(id uint) error
+}
+
+// QuotaReporter reports the limits and the daily quota usage of a client,
+// utils.RateLimiter implements it
+type QuotaReporter interface {
+	Policy() *utils.RateLimitPolicy
+	QuotaUsage(clientID, role string) (*utils.QuotaResult, error)
+}
@@ -0,0 +1,16 @@
+package apikey
+
+import "time"
+
+const (
+	// keyPrefix starts every API key, it makes leaked keys easy to spot
+	keyPrefix = "gtd_"
+	// displayPrefixLength is how much of a key is kept to recognize it
+	displayPrefixLength = 12
+
+	// resolvedKeyTTL is how long a resolved key is cached, revoking a key
+	// evicts it right away
+	resolvedKeyTTL = time.Minute
+
+	errAPIKeyNotFoundFmt = "API key with id %d not found"
+)
@@ -1,5 +0,0 @@
-package errorlog
-
-type ErrorLogRepository interface {
-	LogError(operation string, err error) error
-}
@@ -0,0 +1,16 @@
+package models
+
+import (
+	"gorm.io/gorm"
+)
+
+// APIKey identifies the client of a request for rate limiting and quotas,
+// only the SHA-256 of the key is stored
+type APIKey struct {
+	gorm.Model
+	Owner   uint   `gorm:"index" json:"owner"`
+	Name    string `gorm:"type:varchar(50)" json:"name"`
+	Prefix  string `gorm:"type:varchar(16)" json:"prefix"` // start of the key, to recognize it
+	KeyHash string `gorm:"type:varchar(64);uniqueIndex" json:"-"`
+	Tier    string `gorm:"type:varchar(16)" json:"tier"`
+}
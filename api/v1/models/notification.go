@@ -0,0 +1,29 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// NotificationPreference stores how and when a user wants to be reminded
+// about tasks approaching their due date
+type NotificationPreference struct {
+	gorm.Model
+	UserID     uint   `gorm:"uniqueIndex" json:"userId"`
+	Channel    string `gorm:"type:varchar(20);default:'log'" json:"channel"`
+	Email      string `gorm:"type:varchar(100)" json:"email"`
+	WebhookURL string `gorm:"type:varchar(255)" json:"webhookUrl"`
+	LeadTime   int    `gorm:"default:60" json:"leadTime"` // minutes before the due date
+	Enabled    bool   `gorm:"default:true" json:"enabled"`
+}
+
+// TaskReminder records a reminder that has been dispatched, the unique index
+// guarantees a reminder is sent only once per task, due date and channel
+type TaskReminder struct {
+	ID      uint      `gorm:"primaryKey" json:"id"`
+	TaskID  uint      `gorm:"uniqueIndex:idx_task_reminder" json:"taskId"`
+	DueDate time.Time `gorm:"uniqueIndex:idx_task_reminder" json:"dueDate"`
+	Channel string    `gorm:"type:varchar(20);uniqueIndex:idx_task_reminder" json:"channel"`
+	SentAt  time.Time `json:"sentAt"`
+}
@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+// OutboxEvent is a domain event written in the same transaction as the task
+// change that produced it, the relay publishes rows in ID order. NextAttemptAt
+// holds a failed event back until its backoff elapses, and a relay claiming
+// the event until it is done, after MaxAttempts the event is dead-lettered.
+type OutboxEvent struct {
+	ID             uint       `gorm:"primaryKey" json:"id"`
+	EventID        string     `gorm:"type:varchar(64);uniqueIndex" json:"eventId"`
+	EventType      string     `gorm:"type:varchar(32)" json:"eventType"`
+	AggregateID    uint       `gorm:"index" json:"aggregateId"`
+	Owner          uint       `json:"owner"`
+	Payload        string     `gorm:"type:text" json:"payload"`
+	OccurredAt     time.Time  `json:"occurredAt"`
+	PublishedAt    *time.Time `gorm:"index" json:"publishedAt,omitempty"`
+	Attempts       int        `gorm:"default:0" json:"attempts"`
+	LastError      string     `gorm:"type:text" json:"lastError,omitempty"`
+	NextAttemptAt  *time.Time `gorm:"index" json:"nextAttemptAt,omitempty"`
+	DeadLetteredAt *time.Time `gorm:"index" json:"deadLetteredAt,omitempty"`
+}
@@ -1,6 +1,10 @@
 package models
 
-import "gorm.io/gorm"
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
 
 // the default gorm struct is this:
 // type Model struct {
@@ -16,5 +20,6 @@ type Task struct {
 	Description  string `gorm:"type:text" json:"description"`
 	Done  bool   `gorm:"default:false" json:"done"`
 	Owner  uint   `json:"owner"` 
+	DueDate *time.Time `gorm:"index" json:"dueDate,omitempty"`
     User    User   `gorm:"foreignKey:Owner" json:"user"` 
 }
@@ -0,0 +1,33 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// WebhookSubscription is an owner's registration for task lifecycle events
+type WebhookSubscription struct {
+	gorm.Model
+	Owner  uint   `gorm:"index" json:"owner"`
+	URL    string `gorm:"type:varchar(255)" json:"url"`
+	Secret string `gorm:"type:varchar(128)" json:"-"`
+	Events string `gorm:"type:varchar(255)" json:"events"` // comma separated event types
+	Active bool   `gorm:"default:true" json:"active"`
+}
+
+// WebhookDelivery tracks the delivery of one event to one subscription,
+// deliveries that exhaust their retries stay in the table as dead letters
+type WebhookDelivery struct {
+	gorm.Model
+	SubscriptionID uint       `gorm:"uniqueIndex:idx_delivery_event" json:"subscriptionId"`
+	EventID        string     `gorm:"type:varchar(64);uniqueIndex:idx_delivery_event" json:"eventId"`
+	EventType      string     `gorm:"type:varchar(32)" json:"eventType"`
+	Payload        string     `gorm:"type:text" json:"payload"`
+	Status         string     `gorm:"type:varchar(16);index" json:"status"`
+	Attempts       int        `gorm:"default:0" json:"attempts"`
+	ResponseCode   int        `json:"responseCode"`
+	LastError      string     `gorm:"type:text" json:"lastError"`
+	NextAttemptAt  time.Time  `gorm:"index" json:"nextAttemptAt"`
+	DeliveredAt    *time.Time `json:"deliveredAt,omitempty"`
+}
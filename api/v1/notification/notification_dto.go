@@ -0,0 +1,91 @@
+package notification
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/hftamayo/gotodo/api/v1/models"
+	"github.com/hftamayo/gotodo/pkg/utils"
+)
+
+type PreferenceRequest struct {
+	Channel    string `json:"channel" binding:"required,oneof=email webhook log"`
+	Email      string `json:"email" binding:"omitempty,email"`
+	WebhookURL string `json:"webhookUrl" binding:"omitempty,url"`
+	LeadTime   int    `json:"leadTime" binding:"omitempty,gt=0"`
+	Enabled    *bool  `json:"enabled"`
+}
+
+type PreferenceResponse struct {
+	UserID     uint   `json:"userId"`
+	Channel    string `json:"channel"`
+	Email      string `json:"email,omitempty"`
+	WebhookURL string `json:"webhookUrl,omitempty"`
+	LeadTime   int    `json:"leadTime"`
+	Enabled    bool   `json:"enabled"`
+}
+
+// ReminderPayload is the body posted by the webhook notifier
+type ReminderPayload struct {
+	Event       string    `json:"event"`
+	TaskID      uint      `json:"taskId"`
+	Title       string    `json:"title"`
+	Description string    `json:"description"`
+	Owner       uint      `json:"owner"`
+	DueDate     time.Time `json:"dueDate"`
+	SentAt      time.Time `json:"sentAt"`
+}
+
+type NotificationOperationResponse struct {
+	Code          int         `json:"code"`
+	ResultMessage string      `json:"resultMessage"`
+	Data          interface{} `json:"data,omitempty"`
+	Timestamp     int64       `json:"timestamp"`
+}
+
+type ErrorResponse struct {
+	Code          int    `json:"code"`
+	ResultMessage string `json:"resultMessage"`
+	Error         string `json:"error,omitempty"`
+}
+
+func ToPreferenceResponse(pref *models.NotificationPreference) *PreferenceResponse {
+	return &PreferenceResponse{
+		UserID:     pref.UserID,
+		Channel:    pref.Channel,
+		Email:      pref.Email,
+		WebhookURL: pref.WebhookURL,
+		LeadTime:   pref.LeadTime,
+		Enabled:    pref.Enabled,
+	}
+}
+
+func ToReminderPayload(reminder Reminder) ReminderPayload {
+	return ReminderPayload{
+		Event:       "task.reminder",
+		TaskID:      reminder.Task.ID,
+		Title:       reminder.Task.Title,
+		Description: reminder.Task.Description,
+		Owner:       reminder.Task.Owner,
+		DueDate:     reminder.DueDate,
+		SentAt:      time.Now().UTC(),
+	}
+}
+
+// NewNotificationOperationResponse creates a new NotificationOperationResponse with success status
+func NewNotificationOperationResponse(data interface{}) NotificationOperationResponse {
+	return NotificationOperationResponse{
+		Code:          http.StatusOK,
+		ResultMessage: utils.OperationSuccess,
+		Data:          data,
+		Timestamp:     time.Now().Unix(),
+	}
+}
+
+func NewErrorResponse(code int, resultMessage string, err string) *ErrorResponse {
+	return &ErrorResponse{
+		Code:          code,
+		ResultMessage: resultMessage,
+		Error:         err,
+	}
+}
@@ -0,0 +1,101 @@
+package notification
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hftamayo/gotodo/api/v1/models"
+	"github.com/hftamayo/gotodo/pkg/utils"
+)
+
+var (
+	ErrInvalidUserID  = errors.New("invalid user ID parameter")
+	ErrInvalidRequest = errors.New("invalid request body")
+)
+
+type Handler struct {
+	service NotificationServiceInterface
+}
+
+func NewHandler(service NotificationServiceInterface) *Handler {
+	if service == nil {
+		panic("notification service is required")
+	}
+	return &Handler{service: service}
+}
+
+func (h *Handler) GetPreference(c *gin.Context) {
+	userID, err := strconv.ParseUint(c.Param("userId"), 10, 64)
+	if err != nil || userID == 0 {
+		c.JSON(http.StatusBadRequest, NewErrorResponse(
+			http.StatusBadRequest,
+			utils.OperationFailed,
+			ErrInvalidUserID.Error(),
+		))
+		return
+	}
+
+	pref, err := h.service.GetPreference(uint(userID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, NewErrorResponse(
+			http.StatusInternalServerError,
+			utils.OperationFailed,
+			err.Error(),
+		))
+		return
+	}
+
+	c.JSON(http.StatusOK, NewNotificationOperationResponse(ToPreferenceResponse(pref)))
+}
+
+func (h *Handler) UpdatePreference(c *gin.Context) {
+	userID, err := strconv.ParseUint(c.Param("userId"), 10, 64)
+	if err != nil || userID == 0 {
+		c.JSON(http.StatusBadRequest, NewErrorResponse(
+			http.StatusBadRequest,
+			utils.OperationFailed,
+			ErrInvalidUserID.Error(),
+		))
+		return
+	}
+
+	var request PreferenceRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, NewErrorResponse(
+			http.StatusBadRequest,
+			utils.OperationFailed,
+			ErrInvalidRequest.Error(),
+		))
+		return
+	}
+
+	enabled := true
+	if request.Enabled != nil {
+		enabled = *request.Enabled
+	}
+
+	pref, err := h.service.UpdatePreference(uint(userID), &models.NotificationPreference{
+		Channel:    request.Channel,
+		Email:      request.Email,
+		WebhookURL: request.WebhookURL,
+		LeadTime:   request.LeadTime,
+		Enabled:    enabled,
+	})
+	if err != nil {
+		statusCode := http.StatusInternalServerError
+		if errors.Is(err, ErrChannelUnavailable) || errors.Is(err, ErrWebhookURLRequired) || errors.Is(err, ErrInvalidWebhookURL) || errors.Is(err, ErrBlockedWebhookURL) {
+			statusCode = http.StatusBadRequest
+		}
+
+		c.JSON(statusCode, NewErrorResponse(
+			statusCode,
+			utils.OperationFailed,
+			err.Error(),
+		))
+		return
+	}
+
+	c.JSON(http.StatusOK, NewNotificationOperationResponse(ToPreferenceResponse(pref)))
+}
@@ -0,0 +1,30 @@
+package notification
+
+import (
+	"context"
+	"log"
+)
+
+// LogNotifier writes reminders to the application log, it is the default
+// channel and doubles as a no-op sink when no other channel is configured
+type LogNotifier struct {
+	logger *log.Logger
+}
+
+// NewLogNotifier creates a log notifier, a nil logger uses the standard logger
+func NewLogNotifier(logger *log.Logger) *LogNotifier {
+	if logger == nil {
+		logger = log.Default()
+	}
+	return &LogNotifier{logger: logger}
+}
+
+func (n *LogNotifier) Channel() string {
+	return ChannelLog
+}
+
+func (n *LogNotifier) Notify(ctx context.Context, reminder Reminder) error {
+	n.logger.Printf("Reminder: task %d %q owned by user %d is due at %s",
+		reminder.Task.ID, reminder.Task.Title, reminder.Task.Owner, reminder.DueDate.UTC().Format("2006-01-02 15:04 MST"))
+	return nil
+}
@@ -0,0 +1,43 @@
+package notification
+
+import (
+	"context"
+	"time"
+
+	"github.com/hftamayo/gotodo/api/v1/models"
+	"github.com/hftamayo/gotodo/pkg/config"
+)
+
+const (
+	ChannelEmail   = "email"
+	ChannelWebhook = "webhook"
+	ChannelLog     = "log"
+)
+
+// Reminder is the message handed to a Notifier when a task is about to be due
+type Reminder struct {
+	Task      *models.Task
+	DueDate   time.Time
+	Recipient string // email address or webhook URL depending on the channel
+}
+
+// Notifier defines the contract for reminder delivery channels
+type Notifier interface {
+	Channel() string
+	Notify(ctx context.Context, reminder Reminder) error
+}
+
+// NewNotifiers builds the notifiers available for the given configuration,
+// the email notifier is only registered when an SMTP host is configured
+func NewNotifiers(notificationConfig *config.NotificationConfig) map[string]Notifier {
+	notifiers := map[string]Notifier{
+		ChannelLog:     NewLogNotifier(nil),
+		ChannelWebhook: NewWebhookNotifier(notificationConfig.WebhookTimeout),
+	}
+
+	if notificationConfig.SMTP.Host != "" {
+		notifiers[ChannelEmail] = NewSMTPNotifier(notificationConfig.SMTP)
+	}
+
+	return notifiers
+}
@@ -0,0 +1,97 @@
+package notification
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/hftamayo/gotodo/api/v1/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type NotificationRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewNotificationRepositoryImpl(db *gorm.DB) NotificationRepository {
+	if db == nil {
+		return nil
+	}
+	return &NotificationRepositoryImpl{db: db}
+}
+
+// FindTasksDueBetween returns the pending tasks whose due date falls in [from, to)
+func (r *NotificationRepositoryImpl) FindTasksDueBetween(from time.Time, to time.Time) ([]*models.Task, error) {
+	var tasks []*models.Task
+	err := r.db.Model(&models.Task{}).
+		Where("done = ? AND due_date >= ? AND due_date < ?", false, from, to).
+		Order("due_date asc").
+		Find(&tasks).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to find due tasks: %w", err)
+	}
+	return tasks, nil
+}
+
+func (r *NotificationRepositoryImpl) GetPreference(userID uint) (*models.NotificationPreference, error) {
+	var pref models.NotificationPreference
+	if err := r.db.Where("user_id = ?", userID).First(&pref).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get notification preference: %w", err)
+	}
+	return &pref, nil
+}
+
+func (r *NotificationRepositoryImpl) SavePreference(pref *models.NotificationPreference) (*models.NotificationPreference, error) {
+	if pref == nil {
+		return nil, errors.New("preference cannot be nil")
+	}
+
+	var existing models.NotificationPreference
+	err := r.db.Where("user_id = ?", pref.UserID).First(&existing).Error
+	switch {
+	case err == nil:
+		pref.ID = existing.ID
+		pref.CreatedAt = existing.CreatedAt
+	case !errors.Is(err, gorm.ErrRecordNotFound):
+		return nil, fmt.Errorf("failed to load notification preference: %w", err)
+	}
+
+	// Save writes zero values too, so disabling reminders is persisted
+	if err := r.db.Save(pref).Error; err != nil {
+		return nil, fmt.Errorf("failed to save notification preference: %w", err)
+	}
+	return pref, nil
+}
+
+func (r *NotificationRepositoryImpl) GetUserEmail(userID uint) (string, error) {
+	var user models.User
+	if err := r.db.Select("id, email").First(&user, userID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return "", nil
+		}
+		return "", fmt.Errorf("failed to get user email: %w", err)
+	}
+	return user.Email, nil
+}
+
+// ClaimReminder records the reminder before it is sent, it returns false when
+// the reminder was already claimed by a previous scan or another instance
+func (r *NotificationRepositoryImpl) ClaimReminder(reminder *models.TaskReminder) (bool, error) {
+	result := r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(reminder)
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to claim reminder: %w", result.Error)
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// ReleaseReminder removes a claim so a failed delivery is retried on the next scan
+func (r *NotificationRepositoryImpl) ReleaseReminder(reminder *models.TaskReminder) error {
+	if err := r.db.Delete(&models.TaskReminder{}, reminder.ID).Error; err != nil {
+		return fmt.Errorf("failed to release reminder: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,19 @@
+package notification
+
+import (
+	"time"
+
+	"github.com/hftamayo/gotodo/api/v1/models"
+)
+
+type NotificationRepository interface {
+	FindTasksDueBetween(from time.Time, to time.Time) ([]*models.Task, error)
+	GetPreference(userID uint) (*models.NotificationPreference, error)
+	SavePreference(pref *models.NotificationPreference) (*models.NotificationPreference, error)
+	GetUserEmail(userID uint) (string, error)
+	ClaimReminder(reminder *models.TaskReminder) (bool, error)
+	ReleaseReminder(reminder *models.TaskReminder) error
+}
+
+// Ensure NotificationRepositoryImpl implements NotificationRepository at compile time
+var _ NotificationRepository = (*NotificationRepositoryImpl)(nil)
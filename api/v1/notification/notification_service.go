@@ -0,0 +1,243 @@
+package notification
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/hftamayo/gotodo/api/v1/models"
+	"github.com/hftamayo/gotodo/pkg/config"
+	"github.com/hftamayo/gotodo/pkg/safehttp"
+)
+
+var (
+	ErrChannelUnavailable = errors.New("notification channel is not available")
+	ErrWebhookURLRequired = errors.New("webhook channel requires a webhook URL")
+	ErrInvalidWebhookURL  = errors.New("webhook URL must be an absolute http or https URL")
+	ErrBlockedWebhookURL  = errors.New("webhook URL must not point to a loopback, private or link-local address")
+)
+
+type NotificationService struct {
+	repo      NotificationRepository
+	notifiers map[string]Notifier
+	errorLog  config.ErrorLogger
+	config    *config.NotificationConfig
+}
+
+var _ NotificationServiceInterface = (*NotificationService)(nil)
+
+// NewNotificationService creates a reminder service with the given notifiers
+func NewNotificationService(repo NotificationRepository, notifiers map[string]Notifier, notificationConfig *config.NotificationConfig, errorLog config.ErrorLogger) *NotificationService {
+	if notificationConfig == nil {
+		notificationConfig = config.DefaultNotificationConfig()
+	}
+
+	if errorLog == nil {
+		errorLog = config.NewErrorLoggerWithDefaults()
+	}
+
+	if notifiers == nil {
+		notifiers = NewNotifiers(notificationConfig)
+	}
+
+	return &NotificationService{
+		repo:      repo,
+		notifiers: notifiers,
+		errorLog:  errorLog,
+		config:    notificationConfig,
+	}
+}
+
+// GetPreference returns the user's preference, or the defaults if none is stored
+func (s *NotificationService) GetPreference(userID uint) (*models.NotificationPreference, error) {
+	pref, err := s.repo.GetPreference(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if pref == nil {
+		pref = &models.NotificationPreference{
+			UserID:   userID,
+			Channel:  ChannelLog,
+			LeadTime: int(s.config.DefaultLeadTime / time.Minute),
+			Enabled:  true,
+		}
+	}
+	return pref, nil
+}
+
+func (s *NotificationService) UpdatePreference(userID uint, pref *models.NotificationPreference) (*models.NotificationPreference, error) {
+	if pref == nil {
+		return nil, fmt.Errorf("invalid preference data")
+	}
+
+	pref.UserID = userID
+	if pref.LeadTime <= 0 {
+		pref.LeadTime = int(s.config.DefaultLeadTime / time.Minute)
+	}
+
+	if _, ok := s.notifiers[pref.Channel]; !ok {
+		return nil, fmt.Errorf("%w: %s", ErrChannelUnavailable, pref.Channel)
+	}
+	if pref.Channel == ChannelWebhook && pref.WebhookURL == "" {
+		return nil, ErrWebhookURLRequired
+	}
+	if pref.WebhookURL != "" {
+		if err := safehttp.ValidateURL(context.Background(), pref.WebhookURL); err != nil {
+			if errors.Is(err, safehttp.ErrBlockedAddress) {
+				return nil, ErrBlockedWebhookURL
+			}
+			return nil, ErrInvalidWebhookURL
+		}
+	}
+
+	return s.repo.SavePreference(pref)
+}
+
+// StartScheduler scans for due tasks every ScanInterval until ctx is cancelled
+func (s *NotificationService) StartScheduler(ctx context.Context) {
+	if !s.config.Enabled {
+		return
+	}
+
+	interval := s.config.ScanInterval
+	if interval <= 0 {
+		interval = config.DefaultReminderScanInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := s.DispatchDueReminders(ctx); err != nil && ctx.Err() == nil {
+			slog.ErrorContext(ctx, "Reminder scan failed", "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// DispatchDueReminders sends a reminder for every pending task that has entered
+// its owner's lead time window and returns the number of reminders sent.
+// LookAhead bounds the scan, so it is also the longest lead time honored.
+func (s *NotificationService) DispatchDueReminders(ctx context.Context) (int, error) {
+	now := time.Now()
+	tasks, err := s.repo.FindTasksDueBetween(now, now.Add(s.config.LookAhead))
+	if err != nil {
+		s.logError(ctx, "scan", fmt.Sprintf("Failed to find due tasks: %v", err), map[string]interface{}{"error": err.Error()})
+		return 0, err
+	}
+
+	sent := 0
+	prefs := make(map[uint]*models.NotificationPreference)
+	for _, task := range tasks {
+		if err := ctx.Err(); err != nil {
+			return sent, err
+		}
+
+		pref, ok := prefs[task.Owner]
+		if !ok {
+			pref, err = s.GetPreference(task.Owner)
+			if err != nil {
+				s.logError(ctx, "scan", fmt.Sprintf("Failed to get preference for user %d: %v", task.Owner, err),
+					map[string]interface{}{"user_id": task.Owner, "error": err.Error()})
+				continue
+			}
+			prefs[task.Owner] = pref
+		}
+
+		if !pref.Enabled {
+			continue
+		}
+
+		leadTime := time.Duration(pref.LeadTime) * time.Minute
+		if task.DueDate.Add(-leadTime).After(now) {
+			continue
+		}
+
+		delivered, err := s.sendReminder(ctx, task, pref)
+		if err != nil {
+			s.logError(ctx, "send-reminder", fmt.Sprintf("Failed to send reminder for task %d: %v", task.ID, err),
+				map[string]interface{}{"task_id": task.ID, "channel": pref.Channel, "error": err.Error()})
+			continue
+		}
+		if delivered {
+			sent++
+		}
+	}
+
+	return sent, nil
+}
+
+// sendReminder claims the reminder and delivers it, a reminder that was
+// already claimed is skipped and a failed delivery releases its claim
+func (s *NotificationService) sendReminder(ctx context.Context, task *models.Task, pref *models.NotificationPreference) (bool, error) {
+	notifier := s.notifierFor(pref.Channel)
+
+	recipient, err := s.resolveRecipient(notifier.Channel(), pref)
+	if err != nil {
+		return false, err
+	}
+
+	claim := &models.TaskReminder{
+		TaskID:  task.ID,
+		DueDate: *task.DueDate,
+		Channel: notifier.Channel(),
+		SentAt:  time.Now(),
+	}
+
+	claimed, err := s.repo.ClaimReminder(claim)
+	if err != nil || !claimed {
+		return false, err
+	}
+
+	reminder := Reminder{
+		Task:      task,
+		DueDate:   *task.DueDate,
+		Recipient: recipient,
+	}
+
+	if err := notifier.Notify(ctx, reminder); err != nil {
+		if releaseErr := s.repo.ReleaseReminder(claim); releaseErr != nil {
+			return false, fmt.Errorf("%v (and %v)", err, releaseErr)
+		}
+		return false, err
+	}
+
+	return true, nil
+}
+
+// notifierFor returns the notifier of a channel, falling back to the log
+// notifier when the channel is not configured on this instance
+func (s *NotificationService) notifierFor(channel string) Notifier {
+	if notifier, ok := s.notifiers[channel]; ok {
+		return notifier
+	}
+	if notifier, ok := s.notifiers[ChannelLog]; ok {
+		return notifier
+	}
+	return NewLogNotifier(nil)
+}
+
+func (s *NotificationService) resolveRecipient(channel string, pref *models.NotificationPreference) (string, error) {
+	switch channel {
+	case ChannelEmail:
+		if pref.Email != "" {
+			return pref.Email, nil
+		}
+		return s.repo.GetUserEmail(pref.UserID)
+	case ChannelWebhook:
+		return pref.WebhookURL, nil
+	default:
+		return "", nil
+	}
+}
+
+func (s *NotificationService) logError(ctx context.Context, operation, errorMsg string, metadata map[string]interface{}) {
+	s.errorLog.LogError(ctx, "notification-service", operation, errorMsg, metadata)
+}
@@ -0,0 +1,17 @@
+package notification
+
+import (
+	"context"
+
+	"github.com/hftamayo/gotodo/api/v1/models"
+)
+
+// NotificationServiceInterface defines the contract for reminder operations
+type NotificationServiceInterface interface {
+	GetPreference(userID uint) (*models.NotificationPreference, error)
+	UpdatePreference(userID uint, pref *models.NotificationPreference) (*models.NotificationPreference, error)
+
+	// Scheduler operations
+	DispatchDueReminders(ctx context.Context) (int, error)
+	StartScheduler(ctx context.Context)
+}
@@ -0,0 +1,69 @@
+package notification
+
+import (
+	"context"
+	"fmt"
+	"mime"
+	"net/smtp"
+	"strings"
+	"time"
+
+	"github.com/hftamayo/gotodo/pkg/config"
+)
+
+// SMTPNotifier delivers reminders by email
+type SMTPNotifier struct {
+	config config.SMTPConfig
+}
+
+// NewSMTPNotifier creates a new email notifier
+func NewSMTPNotifier(smtpConfig config.SMTPConfig) *SMTPNotifier {
+	return &SMTPNotifier{config: smtpConfig}
+}
+
+func (n *SMTPNotifier) Channel() string {
+	return ChannelEmail
+}
+
+func (n *SMTPNotifier) Notify(ctx context.Context, reminder Reminder) error {
+	if reminder.Recipient == "" {
+		return fmt.Errorf("no email address for user %d", reminder.Task.Owner)
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	var auth smtp.Auth
+	if n.config.Username != "" {
+		auth = smtp.PlainAuth("", n.config.Username, n.config.Password, n.config.Host)
+	}
+
+	addr := n.config.Host + ":" + n.config.Port
+	if err := smtp.SendMail(addr, auth, n.config.From, []string{reminder.Recipient}, n.buildMessage(reminder)); err != nil {
+		return fmt.Errorf("failed to send reminder email: %w", err)
+	}
+	return nil
+}
+
+// buildMessage renders a plain text email for the reminder
+func (n *SMTPNotifier) buildMessage(reminder Reminder) []byte {
+	var msg strings.Builder
+	fmt.Fprintf(&msg, "From: %s\r\n", n.config.From)
+	fmt.Fprintf(&msg, "To: %s\r\n", reminder.Recipient)
+	fmt.Fprintf(&msg, "Subject: %s\r\n", encodeHeader("Reminder: "+reminder.Task.Title))
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	msg.WriteString("Content-Type: text/plain; charset=\"utf-8\"\r\n")
+	msg.WriteString("\r\n")
+	fmt.Fprintf(&msg, "Your task \"%s\" is due on %s.\r\n", reminder.Task.Title, reminder.DueDate.UTC().Format(time.RFC1123))
+	if reminder.Task.Description != "" {
+		fmt.Fprintf(&msg, "\r\n%s\r\n", reminder.Task.Description)
+	}
+	return []byte(msg.String())
+}
+
+// encodeHeader makes value safe for a header line: line breaks, which would
+// start new headers, are replaced and non ASCII text is RFC 2047 encoded
+func encodeHeader(value string) string {
+	value = strings.NewReplacer("\r\n", " ", "\r", " ", "\n", " ").Replace(value)
+	return mime.QEncoding.Encode("utf-8", value)
+}
@@ -0,0 +1,55 @@
+package notification
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/hftamayo/gotodo/pkg/safehttp"
+)
+
+// WebhookNotifier delivers reminders as a JSON POST to a user supplied URL
+type WebhookNotifier struct {
+	client *http.Client
+}
+
+// NewWebhookNotifier creates a new webhook notifier, the URLs are user
+// supplied so it only connects to public addresses
+func NewWebhookNotifier(timeout time.Duration) *WebhookNotifier {
+	return &WebhookNotifier{client: safehttp.NewClient(timeout)}
+}
+
+func (n *WebhookNotifier) Channel() string {
+	return ChannelWebhook
+}
+
+func (n *WebhookNotifier) Notify(ctx context.Context, reminder Reminder) error {
+	if reminder.Recipient == "" {
+		return fmt.Errorf("no webhook URL for user %d", reminder.Task.Owner)
+	}
+
+	body, err := json.Marshal(ToReminderPayload(reminder))
+	if err != nil {
+		return fmt.Errorf("failed to encode reminder payload: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, reminder.Recipient, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to build webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to deliver reminder webhook: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("reminder webhook returned status %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -4,6 +4,7 @@ const (
 	// HTTP header constants
 	headerLastModified = "Last-Modified"
 	headerCacheControl = "Cache-Control"
+	headerXCache       = "X-Cache"
 	
 	// ETag formatting
 	eTagCharacterFmt = "W/\"%x\""
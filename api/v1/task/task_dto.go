@@ -21,11 +21,13 @@ type CreateTaskRequest struct {
     Title       string `json:"title" binding:"required"`
     Description string `json:"description"`
     Owner       uint   `json:"owner" binding:"required"`
+    DueDate     *time.Time `json:"dueDate"`
 }
 
 type UpdateTaskRequest struct {
     Title       string `json:"title" binding:"required"`
     Description string `json:"description" binding:"required"`
+    DueDate     *time.Time `json:"dueDate"`
 }
 
 type CursorPaginationQuery struct {
@@ -40,6 +42,28 @@ type PagePaginationQuery struct {
     Order string `form:"order" binding:"omitempty,oneof=asc desc"`
 }
 
+type ExportQuery struct {
+    Format string `form:"format" binding:"omitempty,oneof=csv json ics"`
+    Owner  uint   `form:"owner" binding:"required,gt=0"`
+    Done   *bool  `form:"done"`
+}
+
+type ImportQuery struct {
+    Format string `form:"format" binding:"omitempty,oneof=csv json ics"`
+    Owner  uint   `form:"owner" binding:"required,gt=0"`
+    DryRun bool   `form:"dryRun"`
+}
+
+type ImportResponse struct {
+    Format   string            `json:"format"`
+    DryRun   bool              `json:"dryRun"`
+    Total    int               `json:"total"`
+    Valid    int               `json:"valid"`
+    Invalid  int               `json:"invalid"`
+    Imported int               `json:"imported"`
+    Rows     []ImportRowResult `json:"rows"`
+}
+
 type PaginationMeta struct {
     NextCursor  string `json:"nextCursor"`
     PrevCursor  string `json:"prevCursor,omitempty"`
@@ -67,6 +91,7 @@ type TaskResponse struct {
     Description string    `json:"description"`
     Done        bool     `json:"done"`
     Owner       uint     `json:"owner"`
+    DueDate     *time.Time `json:"dueDate,omitempty"`
     CreatedAt   time.Time `json:"createdAt" binding:"required"`
     UpdatedAt   time.Time `json:"updatedAt" binding:"required"`    
 }
@@ -99,11 +124,24 @@ func ToTaskResponse(task *models.Task) *TaskResponse {
         Description: task.Description,
         Done:        task.Done,
         Owner:       task.Owner,
+        DueDate:     task.DueDate,
         CreatedAt:   task.CreatedAt,
         UpdatedAt:   task.UpdatedAt,
     }
 }
 
+func ToImportResponse(result *ImportResult, format string, dryRun bool) ImportResponse {
+    return ImportResponse{
+        Format:   format,
+        DryRun:   dryRun,
+        Total:    len(result.Rows),
+        Valid:    result.Valid,
+        Invalid:  result.Invalid,
+        Imported: result.Imported,
+        Rows:     result.Rows,
+    }
+}
+
 func TasksToResponse(tasks []*models.Task) []*TaskResponse {
     taskResponses := make([]*TaskResponse, len(tasks))
     for i, task := range tasks {
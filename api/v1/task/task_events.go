@@ -0,0 +1,22 @@
+package task
+
+import (
+	"context"
+	"errors"
+
+	"github.com/hftamayo/gotodo/pkg/events"
+)
+
+// CacheInvalidationSubscriber drops the cached entries affected by a task
+// event, it lets the outbox relay repair the cache when the service crashed
+// between the database write and its own invalidation
+func CacheInvalidationSubscriber(service TaskServiceInterface) events.Subscriber {
+	return func(ctx context.Context, event events.Event) error {
+		return errors.Join(
+			service.InvalidateTaskCache(int(event.TaskID)),
+			service.InvalidateListCache(),
+			service.InvalidatePageCache(),
+			service.InvalidateUserCache(event.Owner),
+		)
+	}
+}
@@ -21,6 +21,10 @@ var (
     ErrInvalidRequest = errors.New("invalid request body")
     ErrInvalidPaginationParams = errors.New("invalid pagination parameters")
     ErrInvalidCursor = errors.New("invalid cursor")
+    ErrInvalidOwner = errors.New("invalid owner parameter")
+    ErrInvalidTransferQuery = errors.New("invalid query parameters, owner is required and format must be csv, json or ics")
+    ErrEmptyImport = errors.New("import contains no tasks")
+    ErrImportTooLarge = errors.New("import exceeds the maximum size")
 )
 
 type Handler struct {
@@ -56,7 +60,8 @@ func (h *Handler) List(c *gin.Context) {
 		query.Order = DefaultOrder
 	}
 
-	tasks, totalCount, err := h.service.ListByPage(query.Page, query.Limit, query.Order)
+	tasks, totalCount, cacheStatus, err := h.service.ListByPage(c.Request.Context(), query.Page, query.Limit, query.Order)
+	c.Header(headerXCache, string(cacheStatus))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, NewErrorResponse(
 			http.StatusInternalServerError,
@@ -86,7 +91,8 @@ func (h *Handler) ListById(c *gin.Context) {
 		return
 	}
 	
-	task, err := h.service.ListById(id)
+	task, cacheStatus, err := h.service.ListById(c.Request.Context(), id)
+	c.Header(headerXCache, string(cacheStatus))
 	if err != nil {
 		if strings.Contains(err.Error(), "not found") {
 			c.JSON(http.StatusNotFound, NewErrorResponse(
@@ -196,9 +202,10 @@ func (h *Handler) Create(c *gin.Context) {
 		Description: createRequest.Description,
 		Done:        false,
 		Owner:       createRequest.Owner,
+		DueDate:     createRequest.DueDate,
 	}
 	
-	createdTask, err := h.service.Create(task)
+	createdTask, err := h.service.Create(c.Request.Context(), task)
 	if err != nil {
 		statusCode := http.StatusInternalServerError
 		errorMsg := "Failed to create task"
@@ -253,9 +260,10 @@ func (h *Handler) Update(c *gin.Context) {
 		Model:       gorm.Model{ID: uint(id)},
 		Title:       updateRequest.Title,
 		Description: updateRequest.Description,
+		DueDate:     updateRequest.DueDate,
 	}
 	
-	updatedTask, err := h.service.Update(id, task)
+	updatedTask, err := h.service.Update(c.Request.Context(), id, task)
 	if err != nil {
 		if strings.Contains(err.Error(), "not found") {
 			c.JSON(http.StatusNotFound, NewErrorResponse(
@@ -296,7 +304,7 @@ func (h *Handler) Done(c *gin.Context) {
 		return
 	}
 	
-	updatedTask, err := h.service.MarkAsDone(id)
+	updatedTask, err := h.service.MarkAsDone(c.Request.Context(), id)
 	if err != nil {
 		if strings.Contains(err.Error(), "not found") {
 			c.JSON(http.StatusNotFound, NewErrorResponse(
@@ -337,7 +345,7 @@ func (h *Handler) Delete(c *gin.Context) {
 		return
 	}
 	
-	if err := h.service.Delete(id); err != nil {
+	if err := h.service.Delete(c.Request.Context(), id); err != nil {
 		if strings.Contains(err.Error(), "not found") {
 			c.JSON(http.StatusNotFound, NewErrorResponse(
 				http.StatusNotFound,
@@ -365,6 +373,40 @@ func (h *Handler) Delete(c *gin.Context) {
 	c.JSON(http.StatusOK, response)
 }
 
+// CacheInfo shows the cache keys holding or depending on a task
+func (h *Handler) CacheInfo(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, NewErrorResponse(
+			http.StatusBadRequest,
+			utils.OperationFailed,
+			ErrInvalidID.Error(),
+		))
+		return
+	}
+
+	info, err := h.service.InspectCache(c.Request.Context(), id)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			c.JSON(http.StatusNotFound, NewErrorResponse(
+				http.StatusNotFound,
+				utils.OperationFailed,
+				ErrTaskNotFound.Error(),
+			))
+		} else {
+			c.JSON(http.StatusInternalServerError, NewErrorResponse(
+				http.StatusInternalServerError,
+				utils.OperationFailed,
+				err.Error(),
+			))
+		}
+		return
+	}
+
+	c.Header(headerCacheControl, "no-store")
+	c.JSON(http.StatusOK, NewTaskOperationResponse(info))
+}
+
 func setEtagHeader(c *gin.Context, etag string) {
     if etag != "" {
         c.Header("ETag", etag)
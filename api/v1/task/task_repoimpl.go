@@ -6,12 +6,14 @@ import (
 	"time"
 
 	"github.com/hftamayo/gotodo/api/v1/models"
+	"github.com/hftamayo/gotodo/pkg/events"
 	"github.com/hftamayo/gotodo/pkg/utils"
 	"gorm.io/gorm"
 )
 
 type TaskRepositoryImpl struct {
-	db *gorm.DB
+	db     *gorm.DB
+	outbox bool
 }
 
 func NewTaskRepositoryImpl(db *gorm.DB) TaskRepository {
@@ -21,6 +23,15 @@ func NewTaskRepositoryImpl(db *gorm.DB) TaskRepository {
     return &TaskRepositoryImpl{db: db}
 }
 
+// NewTaskRepositoryWithOutbox creates a repository that records a domain event
+// in the outbox table within the same transaction as every task change
+func NewTaskRepositoryWithOutbox(db *gorm.DB) TaskRepository {
+    if db == nil {
+        return nil
+    }
+    return &TaskRepositoryImpl{db: db, outbox: true}
+}
+
 func (r *TaskRepositoryImpl) GetTotalCount() (int64, error) {
     var count int64
     result := r.db.Model(&models.Task{}).Count(&count)
@@ -137,10 +148,16 @@ func (r *TaskRepositoryImpl) Create(task *models.Task) (*models.Task, error) {
         return nil, errors.New("task cannot be nil")
     }
 	
-	if result := r.db.Create(task); result.Error != nil {
-		return nil, result.Error
-	}
-	return task, nil
+    err := r.db.Transaction(func(tx *gorm.DB) error {
+        if err := tx.Create(task).Error; err != nil {
+            return err
+        }
+        return r.recordEvent(tx, events.TaskCreated, task)
+    })
+    if err != nil {
+        return nil, err
+    }
+    return task, nil
 }
 
 func (r *TaskRepositoryImpl) Update(id int, task *models.Task) (*models.Task, error) {
@@ -170,6 +187,10 @@ func (r *TaskRepositoryImpl) Update(id int, task *models.Task) (*models.Task, er
         return nil, fmt.Errorf("failed to fetch updated task: %w", err)
     }
 
+    if err := r.recordEvent(tx, events.TaskUpdated, &updatedTask); err != nil {
+        tx.Rollback()
+        return nil, err
+    }
 
     if err := tx.Commit().Error; err != nil {
         return nil, fmt.Errorf("failed to commit transaction: %w", err)
@@ -180,21 +201,28 @@ func (r *TaskRepositoryImpl) Update(id int, task *models.Task) (*models.Task, er
 
 func (r *TaskRepositoryImpl) MarkAsDone(id int) (*models.Task, error) {
     var task models.Task
-    result := r.db.Model(&models.Task{}).Where("id = ?", id).Updates(map[string]interface{}{
-        "done":       true,
+    err := r.db.Transaction(func(tx *gorm.DB) error {
+        result := tx.Model(&models.Task{}).Where("id = ?", id).Updates(map[string]interface{}{
+            "done":       true,
+        })
+
+        if result.Error != nil {
+            return fmt.Errorf("failed to mark task as done: %w", result.Error)
+        }
+        if result.RowsAffected == 0 {
+            return fmt.Errorf(utils.ErrTaskNotFoundFmt, id)
+        }
+
+        if err := tx.First(&task, id).Error; err != nil {
+            return fmt.Errorf("failed to fetch updated task: %w", err)
+        }
+
+        return r.recordEvent(tx, events.TaskDone, &task)
     })
-    
-    if result.Error != nil {
-        return nil, fmt.Errorf("failed to mark task as done: %w", result.Error)
-    }
-    if result.RowsAffected == 0 {
-        return nil, fmt.Errorf(utils.ErrTaskNotFoundFmt, id)
-    }
-    
-    if err := r.db.First(&task, id).Error; err != nil {
-        return nil, fmt.Errorf("failed to fetch updated task: %w", err)
+    if err != nil {
+        return nil, err
     }
-    
+
     return &task, nil
 }
 
@@ -203,17 +231,30 @@ func (r *TaskRepositoryImpl) Delete(id int) error {
         return fmt.Errorf("invalid task id: %d", id)
     }
 
-    result := r.db.Delete(&models.Task{}, id)
+    return r.db.Transaction(func(tx *gorm.DB) error {
+        // The deleted task is loaded first so the event carries its last state
+        var task models.Task
+        if r.outbox {
+            if err := tx.First(&task, id).Error; err != nil {
+                if errors.Is(err, gorm.ErrRecordNotFound) {
+                    return fmt.Errorf(utils.ErrTaskNotFoundFmt, id)
+                }
+                return fmt.Errorf("failed to load task: %w", err)
+            }
+        }
 
-    if result.Error != nil {
-        return fmt.Errorf("failed to delete task: %w", result.Error)
-    }
+        result := tx.Delete(&models.Task{}, id)
 
-    if result.RowsAffected == 0 {
-        return fmt.Errorf(utils.ErrTaskNotFoundFmt, id)
-    }
+        if result.Error != nil {
+            return fmt.Errorf("failed to delete task: %w", result.Error)
+        }
 
-    return nil
+        if result.RowsAffected == 0 {
+            return fmt.Errorf(utils.ErrTaskNotFoundFmt, id)
+        }
+
+        return r.recordEvent(tx, events.TaskDeleted, &task)
+    })
 }
 
 func (r *TaskRepositoryImpl) ListByPage(page int, limit int, order string) ([]*models.Task, int64, error) {
@@ -245,7 +286,7 @@ func (r *TaskRepositoryImpl) ListByPage(page int, limit int, order string) ([]*m
     var tasks []*models.Task
     query := r.db.Model(&models.Task{}).
         Order(fmt.Sprintf("created_at %s, id %s", order, order)).
-        Select("id, title, description, done, owner, created_at, updated_at").
+        Select("id, title, description, done, owner, due_date, created_at, updated_at").
         Offset(offset).
         Limit(limit)
 
@@ -255,3 +296,108 @@ func (r *TaskRepositoryImpl) ListByPage(page int, limit int, order string) ([]*m
 
     return tasks, totalCount, nil
 }
+
+// StreamByFilter walks the tasks matching filter in ID order, handing them to
+// fn one batch at a time so exports never hold the whole set in memory
+func (r *TaskRepositoryImpl) StreamByFilter(filter TaskFilter, batchSize int, fn func(tasks []*models.Task) error) error {
+    if batchSize <= 0 {
+        batchSize = utils.MaxLimit
+    }
+
+    query := r.db.Model(&models.Task{}).Where("owner = ?", filter.Owner)
+    if filter.Done != nil {
+        query = query.Where("done = ?", *filter.Done)
+    }
+
+    var batch []*models.Task
+    result := query.FindInBatches(&batch, batchSize, func(tx *gorm.DB, _ int) error {
+        return fn(batch)
+    })
+    if result.Error != nil {
+        return fmt.Errorf("failed to stream tasks: %w", result.Error)
+    }
+    return nil
+}
+
+// FindRecentOwners returns the owners with tasks changed since the given
+// time, most recently active first
+func (r *TaskRepositoryImpl) FindRecentOwners(since time.Time, limit int) ([]uint, error) {
+    var owners []uint
+    if err := r.db.Model(&models.Task{}).
+        Where("updated_at >= ?", since).
+        Group("owner").
+        Order("MAX(updated_at) DESC").
+        Limit(limit).
+        Pluck("owner", &owners).Error; err != nil {
+        return nil, fmt.Errorf("failed to find recent owners: %w", err)
+    }
+    return owners, nil
+}
+
+// FindExistingTitles returns which of the given titles are already taken
+func (r *TaskRepositoryImpl) FindExistingTitles(titles []string) (map[string]bool, error) {
+    existing := make(map[string]bool)
+    for start := 0; start < len(titles); start += utils.MaxLimit {
+        end := start + utils.MaxLimit
+        if end > len(titles) {
+            end = len(titles)
+        }
+
+        var found []string
+        if err := r.db.Model(&models.Task{}).
+            Where("title IN ?", titles[start:end]).
+            Pluck("title", &found).Error; err != nil {
+            return nil, fmt.Errorf("failed to check existing titles: %w", err)
+        }
+        for _, title := range found {
+            existing[title] = true
+        }
+    }
+    return existing, nil
+}
+
+// CreateBatch inserts every task in a single transaction, either all of them
+// are stored or none is
+func (r *TaskRepositoryImpl) CreateBatch(tasks []*models.Task) error {
+    if len(tasks) == 0 {
+        return nil
+    }
+
+    return r.db.Transaction(func(tx *gorm.DB) error {
+        if err := tx.CreateInBatches(tasks, utils.MaxLimit).Error; err != nil {
+            return fmt.Errorf("failed to import tasks: %w", err)
+        }
+        for _, task := range tasks {
+            if err := r.recordEvent(tx, events.TaskCreated, task); err != nil {
+                return err
+            }
+        }
+        return nil
+    })
+}
+
+// recordEvent writes a domain event to the outbox within tx, it is a no-op when
+// the repository was created without an outbox
+func (r *TaskRepositoryImpl) recordEvent(tx *gorm.DB, eventType events.EventType, task *models.Task) error {
+    if !r.outbox {
+        return nil
+    }
+
+    event, err := events.NewEvent(eventType, task.ID, task.Owner, ToTaskResponse(task))
+    if err != nil {
+        return fmt.Errorf("failed to build %s event: %w", eventType, err)
+    }
+
+    outboxEvent := &models.OutboxEvent{
+        EventID:     event.ID,
+        EventType:   string(event.Type),
+        AggregateID: event.TaskID,
+        Owner:       event.Owner,
+        Payload:     string(event.Data),
+        OccurredAt:  event.OccurredAt,
+    }
+    if err := tx.Create(outboxEvent).Error; err != nil {
+        return fmt.Errorf("failed to record %s event: %w", eventType, err)
+    }
+    return nil
+}
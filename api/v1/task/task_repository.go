@@ -1,6 +1,8 @@
 package task
 
 import (
+	"time"
+
 	"github.com/hftamayo/gotodo/api/v1/models"
 )
 
@@ -14,6 +16,10 @@ type TaskRepository interface {
 	Delete(id int) error
 	GetTotalCount() (int64, error)
 	ListByPage(page int, limit int, order string) ([]*models.Task, int64, error)
+	StreamByFilter(filter TaskFilter, batchSize int, fn func(tasks []*models.Task) error) error
+	FindExistingTitles(titles []string) (map[string]bool, error)
+	CreateBatch(tasks []*models.Task) error
+	FindRecentOwners(since time.Time, limit int) ([]uint, error)
 }
 
 // Ensure TaskRepositoryImpl implements TaskRepository at compile time
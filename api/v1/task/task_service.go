@@ -2,32 +2,54 @@ package task
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
 	"math"
+	"time"
+	"unicode/utf8"
 
 	"github.com/hftamayo/gotodo/api/v1/models"
+	taskcache "github.com/hftamayo/gotodo/internal/task/cache"
+	"github.com/hftamayo/gotodo/pkg/cache"
 	"github.com/hftamayo/gotodo/pkg/config"
+	"github.com/hftamayo/gotodo/pkg/events"
+	"github.com/hftamayo/gotodo/pkg/utils"
 )
 
 type TaskService struct {
 	repo       TaskRepository
-	cache      CacheInterface
+	cache      cache.Cache
+	loader     *cache.Loader
+	generations *cache.Generations
+	prefetchSlots chan struct{}
 	errorLog   config.ErrorLogger
+	events     events.Publisher
 	config     *TaskServiceConfig
 }
 
 var _ TaskServiceInterface = (*TaskService)(nil)
 
-var cachedData struct {
-    Tasks      []*models.Task        `json:"tasks"`
-    Pagination PaginationMeta  `json:"pagination"`
+const (
+    exportBatchSize = 500
+    maxTitleLength  = 100
+
+    // prefetchClientID is the rate limiter identity of background prefetches
+    prefetchClientID = "task-service:prefetch"
+)
+
+// ErrImportRejected is returned when an import has invalid rows, nothing is stored
+var ErrImportRejected = errors.New("import rejected, fix the invalid rows and retry")
+
+// cursorPage is the cached result of List
+type cursorPage struct {
+    Tasks      []*models.Task `json:"tasks"`
+    Pagination PaginationMeta `json:"pagination"`
     TotalCount int64          `json:"totalCount"`
 }
 
 // NewTaskService creates a new task service with default configuration
 // This is the legacy constructor for backward compatibility
-func NewTaskService(repo TaskRepository, cache CacheInterface) TaskServiceInterface {
+func NewTaskService(repo TaskRepository, cache cache.Cache) TaskServiceInterface {
 	serviceConfig := DefaultTaskServiceConfig()
 	serviceConfig.ErrorLogger = config.NewErrorLoggerWithDefaults()
 	
@@ -35,7 +57,7 @@ func NewTaskService(repo TaskRepository, cache CacheInterface) TaskServiceInterf
 }
 
 // NewTaskServiceWithConfig creates a task service with custom configuration
-func NewTaskServiceWithConfig(repo TaskRepository, cache CacheInterface, serviceConfig *TaskServiceConfig) TaskServiceInterface {
+func NewTaskServiceWithConfig(repo TaskRepository, taskCache cache.Cache, serviceConfig *TaskServiceConfig) TaskServiceInterface {
 	if serviceConfig == nil {
 		serviceConfig = DefaultTaskServiceConfig()
 	}
@@ -43,25 +65,43 @@ func NewTaskServiceWithConfig(repo TaskRepository, cache CacheInterface, service
 	if serviceConfig.ErrorLogger == nil {
 		serviceConfig.ErrorLogger = config.NewErrorLoggerWithDefaults()
 	}
+	if serviceConfig.AsyncLogging {
+		switch serviceConfig.ErrorLogger.(type) {
+		case *config.AsyncErrorLogger, *config.MemoryErrorLogger:
+			// Reporting already doesn't block
+		default:
+			serviceConfig.ErrorLogger = config.NewAsyncErrorLogger(serviceConfig.ErrorLogger, nil)
+		}
+	}
+	if serviceConfig.CacheKeys == nil {
+		serviceConfig.CacheKeys = taskcache.NewTaskKeyGenerator()
+	}
 	
-	return &TaskService{
+	service := &TaskService{
 		repo:     repo,
-		cache:    cache,
+		cache:    taskCache,
 		errorLog: serviceConfig.ErrorLogger,
+		events:   serviceConfig.EventPublisher,
 		config:   serviceConfig,
 	}
+	service.loader = cache.NewLoader(taskCache, service.logLoaderError)
+	service.generations = cache.NewGenerations(taskCache)
+	if serviceConfig.PrefetchBudget > 0 {
+		service.prefetchSlots = make(chan struct{}, serviceConfig.PrefetchBudget)
+	}
+	return service
 }
 
 // NewTaskServiceWithErrorLogger creates a task service with a custom error logger
 // This is kept for backward compatibility
-func NewTaskServiceWithErrorLogger(repo TaskRepository, cache CacheInterface, errorLog config.ErrorLogger) TaskServiceInterface {
+func NewTaskServiceWithErrorLogger(repo TaskRepository, cache cache.Cache, errorLog config.ErrorLogger) TaskServiceInterface {
 	serviceConfig := DefaultTaskServiceConfig()
 	serviceConfig.ErrorLogger = errorLog
 	
 	return NewTaskServiceWithConfig(repo, cache, serviceConfig)
 }
 
-func (s *TaskService) List(cursor string, limit int, order string) ([]*models.Task, string, string, int64, error) {
+func (s *TaskService) List(ctx context.Context, cursor string, limit int, order string) ([]*models.Task, string, string, int64, error) {
     // Use the validation helper
     query := validatePaginationQuery(CursorPaginationQuery{
         Cursor: cursor,
@@ -69,13 +109,37 @@ func (s *TaskService) List(cursor string, limit int, order string) ([]*models.Ta
         Order:  order,
     })
 
+    result, err := s.listCursor(ctx, query)
+    if err != nil {
+        return nil, "", "", 0, err
+    }
+
+    // Warm the page the client is most likely to ask for next
+    if result.Pagination.HasMore && result.Pagination.NextCursor != "" {
+        next := query
+        next.Cursor = result.Pagination.NextCursor
+        s.prefetch(ctx, s.cursorCacheKey(ctx, next), func(ctx context.Context) error {
+            _, err := s.listCursor(ctx, next)
+            return err
+        })
+    }
+
+    return result.Tasks, result.Pagination.NextCursor, result.Pagination.PrevCursor, result.TotalCount, nil
+}
+
+func (s *TaskService) cursorCacheKey(ctx context.Context, query CursorPaginationQuery) string {
+    return s.listCacheKey(ctx, "list", s.config.CacheKeys.ForCursorList(0, query.Cursor, query.Limit, query.Order),
+        s.config.CacheKeys.ListGenerations(0)...)
+}
+
+// listCursor serves a validated cursor query from the cache or the repository
+func (s *TaskService) listCursor(ctx context.Context, query CursorPaginationQuery) (cursorPage, error) {
     // Try to get from cache first if enabled
-    if s.config.EnableCache {
-        cacheKey := fmt.Sprintf(s.config.CacheKeys.TaskCursorKey, 
-            query.Cursor, query.Limit, query.Order)
-        if err := s.cache.Get(cacheKey, &cachedData); err == nil {
-            return cachedData.Tasks, cachedData.Pagination.NextCursor, 
-                cachedData.Pagination.PrevCursor, cachedData.TotalCount, nil
+    cacheKey := s.cursorCacheKey(ctx, query)
+    if cacheKey != "" {
+        var cached cursorPage
+        if err := s.cache.Get(cacheKey, &cached); err == nil {
+            return cached, nil
         }
     }
 
@@ -83,65 +147,61 @@ func (s *TaskService) List(cursor string, limit int, order string) ([]*models.Ta
     tasks, nextCursor, prevCursor, err := s.repo.List(query.Limit+1, 
         query.Cursor, query.Order)
     if err != nil {
-        s.logError("list", fmt.Sprintf("Failed to list tasks: %v", err), map[string]interface{}{"error": err.Error()})
-        return nil, "", "", 0, fmt.Errorf("failed to list tasks: %w", err)
+        s.logError(ctx, "list", fmt.Sprintf("Failed to list tasks: %v", err), map[string]interface{}{"error": err.Error()})
+        return cursorPage{}, fmt.Errorf("failed to list tasks: %w", err)
     }
 
     // Get total count
     totalCount, err := s.repo.GetTotalCount()
     if err != nil {
-        s.logError("list", fmt.Sprintf("Failed to get total count: %v", err), map[string]interface{}{"error": err.Error()})
-        return nil, "", "", 0, fmt.Errorf("failed to get total count: %w", err)
+        s.logError(ctx, "list", fmt.Sprintf("Failed to get total count: %v", err), map[string]interface{}{"error": err.Error()})
+        return cursorPage{}, fmt.Errorf("failed to get total count: %w", err)
     }
 
     // Handle hasMore and slice tasks
-    hasMore := len(tasks) > limit
+    hasMore := len(tasks) > query.Limit
     if hasMore {
-        tasks = tasks[:limit] // Remove the extra record
+        tasks = tasks[:query.Limit] // Remove the extra record
     }
 
     // Calculate pagination metadata
-    totalPages := int(math.Ceil(float64(totalCount) / float64(limit)))
+    totalPages := int(math.Ceil(float64(totalCount) / float64(query.Limit)))
     currentPage := 1
-    if cursor != "" {
+    if query.Cursor != "" {
         // For cursor-based pagination, we don't need to calculate current page
         // as it's not relevant to the user
         currentPage = 0
     }
 
-    cacheData := struct {
-        Tasks      []*models.Task  `json:"tasks"`
-        Pagination PaginationMeta  `json:"pagination"`
-        TotalCount int64          `json:"totalCount"`
-    }{
+    result := cursorPage{
         Tasks: tasks,
         Pagination: PaginationMeta{
             NextCursor:  nextCursor,
             PrevCursor:  prevCursor,
             HasMore:     hasMore,
-            Limit:       limit,
+            Limit:       query.Limit,
             TotalCount:  totalCount,
             CurrentPage: currentPage,
             TotalPages:  totalPages,
-            Order:       order,
+            Order:       query.Order,
         },
         TotalCount: totalCount,
     }
     
     // Cache the result if enabled
-    if s.config.EnableCache {
-        cacheKey := fmt.Sprintf(s.config.CacheKeys.TaskCursorKey, 
-            query.Cursor, query.Limit, query.Order)
-        if cacheBytes, err := json.Marshal(cacheData); err == nil {
-            s.cache.Set(cacheKey, string(cacheBytes), s.config.CacheTTL)
+    if cacheKey != "" {
+        if err := s.cache.Set(cacheKey, result, s.config.CacheTTL); err != nil {
+            s.logError(ctx, "list", 
+                fmt.Sprintf("Failed to cache tasks: %v", err), 
+                map[string]interface{}{"error": err.Error()})
         }
     }
 
-    return tasks, nextCursor, prevCursor, totalCount, nil
+    return result, nil
 }
 
 // validateTaskExistence checks if a task exists and hasn't been modified
-func (s *TaskService) validateTaskExistence(id int, cachedTask *models.Task) (*models.Task, error) {
+func (s *TaskService) validateTaskExistence(ctx context.Context, id int, cachedTask *models.Task) (*models.Task, error) {
     existingTask, err := s.repo.ListById(id)
     if err != nil {
         return nil, fmt.Errorf("failed to verify task existence: %w", err)
@@ -150,8 +210,8 @@ func (s *TaskService) validateTaskExistence(id int, cachedTask *models.Task) (*m
     // If task doesn't exist anymore, invalidate cache and return error
     if existingTask == nil {
         if s.config.EnableCache {
-            if err := s.cache.InvalidateByTags(fmt.Sprintf(s.config.CacheKeys.TaskReference, id)); err != nil {
-                s.logError("validate-task-existence", 
+            if err := s.cache.InvalidateByTags(s.config.CacheKeys.ForTaskTag(id)); err != nil {
+                s.logError(ctx, "validate-task-existence", 
                     fmt.Sprintf("Failed to invalidate cache for task %d: %v", id, err), 
                     map[string]interface{}{"task_id": id, "error": err.Error()})
             }
@@ -166,8 +226,8 @@ func (s *TaskService) validateTaskExistence(id int, cachedTask *models.Task) (*m
 
     // If task has been modified, invalidate cache and continue
     if s.config.EnableCache {
-        if err := s.cache.InvalidateByTags(fmt.Sprintf(s.config.CacheKeys.TaskReference, id)); err != nil {
-            s.logError("validate-task-existence", 
+        if err := s.cache.InvalidateByTags(s.config.CacheKeys.ForTaskTag(id)); err != nil {
+            s.logError(ctx, "validate-task-existence", 
                 fmt.Sprintf("Failed to invalidate cache for task %d: %v", id, err), 
                 map[string]interface{}{"task_id": id, "error": err.Error()})
         }
@@ -176,51 +236,64 @@ func (s *TaskService) validateTaskExistence(id int, cachedTask *models.Task) (*m
     return existingTask, nil
 }
 
-// ListById retrieves a task by its ID
-func (s *TaskService) ListById(id int) (*models.Task, error) {
+// ListById retrieves a task by its ID, status tells whether it came from the cache
+func (s *TaskService) ListById(ctx context.Context, id int) (*models.Task, cache.LoadStatus, error) {
+    task, status, err := s.listById(ctx, id)
+    s.recordCacheLoad("task", status)
+    return task, status, err
+}
+
+func (s *TaskService) listById(ctx context.Context, id int) (*models.Task, cache.LoadStatus, error) {
     var task *models.Task
 
     // Try to get from cache first if enabled
+    status := cache.StatusBypass
     if s.config.EnableCache {
-        cacheKey := fmt.Sprintf(s.config.CacheKeys.TaskKey, id)
+        status = cache.StatusMiss
+        cacheKey := s.config.CacheKeys.ForTask(id)
         if err := s.cache.Get(cacheKey, &task); err == nil {
-            return s.validateTaskExistence(id, task)
+            validated, err := s.validateTaskExistence(ctx, id, task)
+            if validated != task {
+                // The cached copy was outdated, the task was read again
+                return validated, cache.StatusMiss, err
+            }
+            return validated, cache.StatusHit, err
         }
     }
 
     // Get fresh data from repository
     task, err := s.repo.ListById(id)
     if err != nil {
-        s.logError("list-by-id", fmt.Sprintf("Failed to get task by id: %v", err), map[string]interface{}{"task_id": id, "error": err.Error()})
-        return nil, fmt.Errorf("failed to get task by id: %w", err)
+        s.logError(ctx, "list-by-id", fmt.Sprintf("Failed to get task by id: %v", err), map[string]interface{}{"task_id": id, "error": err.Error()})
+        return nil, status, fmt.Errorf("failed to get task by id: %w", err)
     }
 
     if task == nil {
-        return nil, fmt.Errorf(s.config.ValidationConfig.ErrTaskNotFoundFmt, id)
+        return nil, status, fmt.Errorf(s.config.ValidationConfig.ErrTaskNotFoundFmt, id)
     }
 
     // Cache the result with tags if enabled
     if s.config.EnableCache {
-        cacheKey := fmt.Sprintf(s.config.CacheKeys.TaskKey, id)
+        cacheKey := s.config.CacheKeys.ForTask(id)
         if err := s.cache.SetWithTags(cacheKey, task, s.config.CacheTTL, 
-            fmt.Sprintf(s.config.CacheKeys.TaskReference, id)); err != nil {
-            s.logError("list-by-id", 
+            s.config.CacheKeys.ForTaskTag(id)); err != nil {
+            s.logError(ctx, "list-by-id", 
                 fmt.Sprintf("Failed to cache task %d: %v", id, err), 
                 map[string]interface{}{"task_id": id, "error": err.Error()})
         }
     }
 
-    return task, nil
+    return task, status, nil
 }
 
-func (s *TaskService) Create(task *models.Task) (*models.Task, error) {
+func (s *TaskService) Create(ctx context.Context, task *models.Task) (*models.Task, error) {
     if task == nil {
         return nil, fmt.Errorf("invalid task data")
     }
 
     existingTask, err := s.repo.SearchByTitle(task.Title)
     if err != nil {
-        s.logError("create", fmt.Sprintf("Failed to check for duplicate title: %v", err), map[string]interface{}{"error": err.Error()})
+        s.logError(ctx, "create", fmt.Sprintf("Failed to check for duplicate title: %v", err), map[string]interface{}{"error": err.Error()})
         return nil, fmt.Errorf("failed to check for duplicate title: %w", err)
     }
 
@@ -230,23 +303,18 @@ func (s *TaskService) Create(task *models.Task) (*models.Task, error) {
 
     createdTask, err := s.repo.Create(task)
     if err != nil {
-        s.logError("create", fmt.Sprintf("Failed to create task: %v", err), map[string]interface{}{"error": err.Error()})
+        s.logError(ctx, "create", fmt.Sprintf("Failed to create task: %v", err), map[string]interface{}{"error": err.Error()})
         return nil, fmt.Errorf("failed to create task: %w", err)
     }
 
-    // Invalidate all task-related caches if enabled
-    if s.config.EnableCache {
-        if err := s.cache.InvalidateByTags(s.config.CacheKeys.TaskListRef); err != nil {
-            s.logError("create", 
-                fmt.Sprintf("Failed to invalidate cache for task list: %v", err), 
-                map[string]interface{}{"error": err.Error()})
-        }
-    }
+    s.invalidateWrite(ctx, "create", createdTask.Owner)
+
+    s.publishEvent(ctx, events.TaskCreated, createdTask)
 
     return createdTask, nil
 }
 
-func (s *TaskService) Update(id int, task *models.Task) (*models.Task, error) {
+func (s *TaskService) Update(ctx context.Context, id int, task *models.Task) (*models.Task, error) {
     if task == nil {
         return nil, fmt.Errorf("invalid task data")
     }
@@ -257,7 +325,7 @@ func (s *TaskService) Update(id int, task *models.Task) (*models.Task, error) {
 
     existingTask, err := s.repo.ListById(id)
     if err != nil {
-        s.logError("update", fmt.Sprintf("Failed to verify task existence: %v", err), map[string]interface{}{"task_id": id, "error": err.Error()})
+        s.logError(ctx, "update", fmt.Sprintf("Failed to verify task existence: %v", err), map[string]interface{}{"task_id": id, "error": err.Error()})
         return nil, fmt.Errorf("failed to verify task existence: %w", err)
     }
 
@@ -271,26 +339,24 @@ func (s *TaskService) Update(id int, task *models.Task) (*models.Task, error) {
 
     updatedTask, err := s.repo.Update(id, task)
     if err != nil {
-        s.logError("update", fmt.Sprintf("Failed to update task: %v", err), map[string]interface{}{"task_id": id, "error": err.Error()})
+        s.logError(ctx, "update", fmt.Sprintf("Failed to update task: %v", err), map[string]interface{}{"task_id": id, "error": err.Error()})
         return nil, fmt.Errorf("failed to update task: %w", err)
     }
 
-    // Invalidate all task-related caches if enabled
-    if s.config.EnableCache {
-        if err := s.cache.InvalidateByTags(s.config.CacheKeys.TaskListRef, fmt.Sprintf(s.config.CacheKeys.TaskReference, id)); err != nil {
-            s.logError("update", 
-                fmt.Sprintf("Failed to invalidate cache for task %d update: %v", id, err), 
-                map[string]interface{}{"task_id": id, "error": err.Error()})
-        }
+    s.invalidateWrite(ctx, "update", existingTask.Owner, id)
+    if updatedTask != nil && updatedTask.Owner != existingTask.Owner {
+        s.bumpGenerations(ctx, "update", s.config.CacheKeys.UserGeneration(updatedTask.Owner))
     }
 
+    s.publishEvent(ctx, events.TaskUpdated, updatedTask)
+
     return updatedTask, nil
 }
 
-func (s *TaskService) MarkAsDone(id int) (*models.Task, error) {
+func (s *TaskService) MarkAsDone(ctx context.Context, id int) (*models.Task, error) {
     existingTask, err := s.repo.ListById(id)
     if err != nil {
-        s.logError("mark-as-done", fmt.Sprintf("Failed to get task: %v", err), map[string]interface{}{"task_id": id, "error": err.Error()})
+        s.logError(ctx, "mark-as-done", fmt.Sprintf("Failed to get task: %v", err), map[string]interface{}{"task_id": id, "error": err.Error()})
         return nil, fmt.Errorf("failed to get task: %w", err)
     }
 
@@ -300,96 +366,220 @@ func (s *TaskService) MarkAsDone(id int) (*models.Task, error) {
 
     updatedTask, err := s.repo.MarkAsDone(id)
     if err != nil {
-        s.logError("mark-as-done", fmt.Sprintf("Failed to mark task as done: %v", err), map[string]interface{}{"task_id": id, "error": err.Error()})
+        s.logError(ctx, "mark-as-done", fmt.Sprintf("Failed to mark task as done: %v", err), map[string]interface{}{"task_id": id, "error": err.Error()})
         return nil, fmt.Errorf("failed to mark task as done: %w", err)
     }
 
-    // Invalidate all task-related caches if enabled
-    if s.config.EnableCache {
-        if err := s.cache.InvalidateByTags(s.config.CacheKeys.TaskListRef, fmt.Sprintf(s.config.CacheKeys.TaskReference, id)); err != nil {
-            s.logError("mark-as-done", 
-                fmt.Sprintf("Failed to invalidate cache for task %d mark as done: %v", id, err), 
-                map[string]interface{}{"task_id": id, "error": err.Error()})
-        }
-    }
+    s.invalidateWrite(ctx, "mark-as-done", existingTask.Owner, id)
+
+    s.publishEvent(ctx, events.TaskDone, updatedTask)
 
     return updatedTask, nil
 }
 
-func (s *TaskService) Delete(id int) error {
+func (s *TaskService) Delete(ctx context.Context, id int) error {
+    // Keep a snapshot of the task so the delete event and the cache
+    // invalidation know its owner
+    var deletedTask *models.Task
+    if s.events != nil || s.config.EnableCache {
+        task, err := s.repo.ListById(id)
+        if err != nil {
+            s.logError(ctx, "delete", fmt.Sprintf("Failed to load task before delete: %v", err), map[string]interface{}{"task_id": id, "error": err.Error()})
+        }
+        deletedTask = task
+    }
+    var owner uint
+    if deletedTask != nil {
+        owner = deletedTask.Owner
+    }
+
     if err := s.repo.Delete(id); err != nil {
-        s.logError("delete", fmt.Sprintf("Failed to delete task: %v", err), map[string]interface{}{"task_id": id, "error": err.Error()})
+        s.logError(ctx, "delete", fmt.Sprintf("Failed to delete task: %v", err), map[string]interface{}{"task_id": id, "error": err.Error()})
         return fmt.Errorf("failed to delete task: %w", err)
     }
 
-    // Invalidate all task-related caches if enabled
-    if s.config.EnableCache {
-        if err := s.cache.InvalidateByTags(s.config.CacheKeys.TaskListRef, fmt.Sprintf(s.config.CacheKeys.TaskReference, id)); err != nil {
-            s.logError("delete", 
-                fmt.Sprintf("Failed to invalidate cache for task %d delete: %v", id, err), 
-                map[string]interface{}{"task_id": id, "error": err.Error()})
-        }
+    s.invalidateWrite(ctx, "delete", owner, id)
+
+    if deletedTask == nil {
+        deletedTask = &models.Task{}
+        deletedTask.ID = uint(id)
     }
+    s.publishEvent(ctx, events.TaskDeleted, deletedTask)
 
     return nil
 }
 
-// ListByPage retrieves a paginated list of tasks
-func (s *TaskService) ListByPage(page, limit int, order string) ([]*models.Task, int64, error) {
-    var cachedData struct {
-        Tasks      []*models.Task `json:"tasks"`
-        TotalCount int64         `json:"totalCount"`
+// taskPage is the cached result of ListByPage
+type taskPage struct {
+    Tasks      []*models.Task `json:"tasks"`
+    TotalCount int64          `json:"totalCount"`
+}
+
+// ListByPage retrieves a paginated list of tasks. Concurrent misses for a page
+// share a single query and an expired page is served stale while it refreshes.
+// Pages are keyed by the list generations, so a write never leaves them stale.
+func (s *TaskService) ListByPage(ctx context.Context, page, limit int, order string) ([]*models.Task, int64, cache.LoadStatus, error) {
+    result, status, err := s.loadPage(ctx, page, limit, order)
+    s.recordCacheLoad("page", status)
+    if err != nil {
+        s.logError(ctx, "list-by-page", fmt.Sprintf("Failed to list tasks by page: %v", err), map[string]interface{}{"error": err.Error()})
+        return nil, 0, status, fmt.Errorf("failed to list tasks by page: %w", err)
     }
-    
-    // Try to get from cache first if enabled
-    if s.config.EnableCache {
-        cacheKey := fmt.Sprintf(s.config.CacheKeys.TaskPageKey, page, limit, order)
-        if err := s.cache.Get(cacheKey, &cachedData); err == nil {
-            // If we have cached data, verify it's still valid
-            totalCount, err := s.repo.GetTotalCount()
-            if err != nil {
-                s.logError("list-by-page", fmt.Sprintf("Failed to get total count: %v", err), map[string]interface{}{"error": err.Error()})
-                return nil, 0, fmt.Errorf("failed to get total count: %w", err)
-            }
 
-            // If total count matches, return cached data
-            if totalCount == cachedData.TotalCount {
-                return cachedData.Tasks, cachedData.TotalCount, nil
-            }
+    // Warm the page the client is most likely to ask for next
+    if int64(page*limit) < result.TotalCount {
+        s.prefetch(ctx, s.pageCacheKey(ctx, page+1, limit, order), func(ctx context.Context) error {
+            _, _, err := s.loadPage(ctx, page+1, limit, order)
+            return err
+        })
+    }
 
-            // If total count doesn't match, invalidate cache and continue
-            if err := s.cache.InvalidateByTags("tasks:list"); err != nil {
-                s.logError("list-by-page", 
-                    fmt.Sprintf("Failed to invalidate cache for tasks list: %v", err), 
-                    map[string]interface{}{"error": err.Error()})
-            }
+    return result.Tasks, result.TotalCount, status, nil
+}
+
+// recordCacheLoad counts the cache status of a read requested by a client,
+// prefetches and warming are left out
+func (s *TaskService) recordCacheLoad(resource string, status cache.LoadStatus) {
+    if s.config.CacheRecorder != nil {
+        s.config.CacheRecorder.RecordCacheLoad(resource, status)
+    }
+}
+
+func (s *TaskService) pageCacheKey(ctx context.Context, page, limit int, order string) string {
+    return s.listCacheKey(ctx, "list-by-page", s.config.CacheKeys.ForPageList(0, page, limit, order),
+        append(s.config.CacheKeys.ListGenerations(0), s.config.CacheKeys.PageGeneration())...)
+}
+
+// loadPage serves a page through the cache loader, or straight from the
+// repository when caching is disabled
+func (s *TaskService) loadPage(ctx context.Context, page, limit int, order string) (taskPage, cache.LoadStatus, error) {
+    load := func() (interface{}, error) {
+        tasks, totalCount, err := s.repo.ListByPage(page, limit, order)
+        if err != nil {
+            return nil, err
         }
+        return taskPage{Tasks: tasks, TotalCount: totalCount}, nil
     }
 
-    // Get fresh data from repository
-    tasks, totalCount, err := s.repo.ListByPage(page, limit, order)
+    cacheKey := s.pageCacheKey(ctx, page, limit, order)
+    if cacheKey == "" {
+        result, err := load()
+        if err != nil {
+            return taskPage{}, cache.StatusBypass, err
+        }
+        return result.(taskPage), cache.StatusBypass, nil
+    }
+
+    var result taskPage
+    status, err := s.loader.Load(cacheKey, &result, cache.LoadOptions{
+        TTL:      s.config.CacheTTL,
+        StaleTTL: s.config.CacheStaleTTL,
+        Beta:     s.config.CacheEarlyExpirationBeta,
+    }, load)
+    return result, status, err
+}
+
+// Export hands the tasks matching filter to fn in batches
+func (s *TaskService) Export(ctx context.Context, filter TaskFilter, fn func(tasks []*models.Task) error) error {
+    if err := s.repo.StreamByFilter(filter, exportBatchSize, fn); err != nil {
+        s.logError(ctx, "export", fmt.Sprintf("Failed to export tasks: %v", err), map[string]interface{}{"owner": filter.Owner, "error": err.Error()})
+        return err
+    }
+    return nil
+}
+
+// Import validates every record and creates the tasks in one transaction.
+// Nothing is stored when a row is invalid or dryRun is set, the result
+// always carries the outcome of each row.
+func (s *TaskService) Import(ctx context.Context, owner uint, records []ImportRecord, dryRun bool) (*ImportResult, error) {
+    result := &ImportResult{Rows: make([]ImportRowResult, len(records))}
+
+    titles := make([]string, 0, len(records))
+    for _, record := range records {
+        if record.Title != "" {
+            titles = append(titles, record.Title)
+        }
+    }
+
+    existing, err := s.repo.FindExistingTitles(titles)
     if err != nil {
-        s.logError("list-by-page", fmt.Sprintf("Failed to list tasks by page: %v", err), map[string]interface{}{"error": err.Error()})
-        return nil, 0, fmt.Errorf("failed to list tasks by page: %w", err)
+        s.logError(ctx, "import", fmt.Sprintf("Failed to check for duplicate titles: %v", err), map[string]interface{}{"owner": owner, "error": err.Error()})
+        return nil, fmt.Errorf("failed to check for duplicate titles: %w", err)
     }
 
-    // Cache the results with tags if enabled
-    if s.config.EnableCache {
-        cacheKey := fmt.Sprintf(s.config.CacheKeys.TaskPageKey, page, limit, order)
-        if err := s.cache.SetWithTags(cacheKey, struct {
-            Tasks      []*models.Task `json:"tasks"`
-            TotalCount int64         `json:"totalCount"`
-        }{
-            Tasks:      tasks,
-            TotalCount: totalCount,
-        }, s.config.CacheTTL, "tasks:list", fmt.Sprintf("tasks:page:%d", page)); err != nil {
-            s.logError("list-by-page", 
-                fmt.Sprintf("Failed to cache tasks: %v", err), 
-                map[string]interface{}{"error": err.Error()})
+    seen := make(map[string]int, len(records))
+    tasks := make([]*models.Task, 0, len(records))
+    for i, record := range records {
+        row := ImportRowResult{Row: record.Row, Title: record.Title, Error: record.Err}
+        if row.Error == "" {
+            row.Error = validateImportRecord(record, existing, seen)
+        }
+        result.Rows[i] = row
+
+        if row.Error != "" {
+            result.Invalid++
+            continue
         }
+        seen[record.Title] = record.Row
+        result.Valid++
+        tasks = append(tasks, &models.Task{
+            Title:       record.Title,
+            Description: record.Description,
+            Done:        record.Done,
+            Owner:       owner,
+            DueDate:     record.DueDate,
+        })
+    }
+
+    if result.Invalid > 0 {
+        if dryRun {
+            return result, nil
+        }
+        return result, ErrImportRejected
+    }
+    if dryRun {
+        return result, nil
     }
 
-    return tasks, totalCount, nil
+    if err := s.repo.CreateBatch(tasks); err != nil {
+        s.logError(ctx, "import", fmt.Sprintf("Failed to import tasks: %v", err), map[string]interface{}{"owner": owner, "count": len(tasks), "error": err.Error()})
+        return nil, fmt.Errorf("failed to import tasks: %w", err)
+    }
+
+    // Every record was valid, so the created tasks line up with the rows
+    for i, task := range tasks {
+        result.Rows[i].TaskID = task.ID
+    }
+    result.Imported = len(tasks)
+
+    s.invalidateWrite(ctx, "import", owner)
+
+    for _, task := range tasks {
+        s.publishEvent(ctx, events.TaskCreated, task)
+    }
+
+    return result, nil
+}
+
+func validateImportRecord(record ImportRecord, existing map[string]bool, seen map[string]int) string {
+    switch {
+    case record.Title == "":
+        return "title is required"
+    case utf8.RuneCountInString(record.Title) > maxTitleLength:
+        return fmt.Sprintf("title exceeds %d characters", maxTitleLength)
+    case existing[record.Title]:
+        return fmt.Sprintf("task with title %s already exists", record.Title)
+    }
+    if row, ok := seen[record.Title]; ok {
+        return fmt.Sprintf("duplicate title, already used at row %d", row)
+    }
+    return ""
+}
+
+// logLoaderError reports cache writes and background refreshes that failed
+// without reaching the caller
+func (s *TaskService) logLoaderError(key string, err error) {
+    s.logError(context.Background(), "cache-load", fmt.Sprintf("Failed to refresh cache key %s: %v", key, err), map[string]interface{}{"key": key, "error": err.Error()})
 }
 
 // Cache invalidation methods moved from handler
@@ -398,9 +588,9 @@ func (s *TaskService) InvalidateTaskCache(id int) error {
         return nil
     }
     
-    cacheKey := fmt.Sprintf(s.config.CacheKeys.TaskKey, id)
+    cacheKey := s.config.CacheKeys.ForTask(id)
     if err := s.cache.Delete(cacheKey); err != nil {
-        s.logError("invalidate-task-cache", 
+        s.logError(context.Background(), "invalidate-task-cache", 
             fmt.Sprintf("Failed to delete task cache for id %d: %v", id, err), 
             map[string]interface{}{"task_id": id, "error": err.Error()})
         return err
@@ -408,47 +598,209 @@ func (s *TaskService) InvalidateTaskCache(id int) error {
     return nil
 }
 
+// InspectCache reports the cache keys holding or depending on a task and
+// their current state
+func (s *TaskService) InspectCache(ctx context.Context, id int) (*TaskCacheInfo, error) {
+    task, err := s.repo.ListById(id)
+    if err != nil {
+        return nil, fmt.Errorf("failed to get task by id: %w", err)
+    }
+    if task == nil {
+        return nil, fmt.Errorf(s.config.ValidationConfig.ErrTaskNotFoundFmt, id)
+    }
+
+    keys := s.config.CacheKeys.ForTaskKeys(id, task.Owner)
+    info := &TaskCacheInfo{
+        TaskID:      id,
+        Enabled:     s.config.EnableCache,
+        Keys:        keys,
+        Generations: make(map[string]string, len(keys.Generations)),
+    }
+    if !s.config.EnableCache {
+        return info, nil
+    }
+
+    info.Cached = s.cache.Exists(keys.Entry)
+    for _, namespace := range keys.Generations {
+        generation, err := s.generations.Current(namespace)
+        if err != nil {
+            return nil, fmt.Errorf("failed to read cache generation %s: %w", namespace, err)
+        }
+        info.Generations[namespace] = generation
+    }
+    return info, nil
+}
+
+// InvalidateListCache starts a new generation of every list
 func (s *TaskService) InvalidateListCache() error {
+    return s.bumpGenerations(context.Background(), "invalidate-list-cache", s.config.CacheKeys.ListGeneration())
+}
+
+// InvalidatePageCache starts a new generation of the page lists
+func (s *TaskService) InvalidatePageCache() error {
+    return s.bumpGenerations(context.Background(), "invalidate-page-cache", s.config.CacheKeys.PageGeneration())
+}
+
+// InvalidateUserCache starts a new generation of the lists of owner
+func (s *TaskService) InvalidateUserCache(owner uint) error {
+    if owner == 0 {
+        return nil
+    }
+    return s.bumpGenerations(context.Background(), "invalidate-user-cache", s.config.CacheKeys.UserGeneration(owner))
+}
+
+func (s *TaskService) bumpGenerations(ctx context.Context, operation string, namespaces ...string) error {
     if !s.config.EnableCache {
         return nil
     }
-    
-    if err := s.cache.InvalidateByTags(s.config.CacheKeys.TaskListRef); err != nil {
-        s.logError("invalidate-list-cache", 
-            fmt.Sprintf("Failed to invalidate list cache: %v", err), 
-            map[string]interface{}{"error": err.Error()})
+
+    if err := s.generations.Bump(namespaces...); err != nil {
+        s.logError(ctx, operation, 
+            fmt.Sprintf("Failed to invalidate cache generations %v: %v", namespaces, err), 
+            map[string]interface{}{"namespaces": namespaces, "error": err.Error()})
         return err
     }
     return nil
 }
 
-func (s *TaskService) InvalidatePageCache() error {
+// invalidateWrite drops the caches affected by a write: the written tasks and,
+// through their generations, every list and the lists of the owner
+func (s *TaskService) invalidateWrite(ctx context.Context, operation string, owner uint, ids ...int) {
     if !s.config.EnableCache {
-        return nil
+        return
     }
-    
-    if err := s.cache.Delete(s.config.CacheKeys.TaskPageCache); err != nil {
-        s.logError("invalidate-page-cache", 
-            fmt.Sprintf("Failed to invalidate page cache: %v", err), 
+
+    s.bumpGenerations(ctx, operation, s.config.CacheKeys.ListGenerations(owner)...)
+
+    if len(ids) == 0 {
+        return
+    }
+    tags := make([]string, len(ids))
+    for i, id := range ids {
+        tags[i] = s.config.CacheKeys.ForTaskTag(id)
+    }
+    if err := s.cache.InvalidateByTags(tags...); err != nil {
+        s.logError(ctx, operation, 
+            fmt.Sprintf("Failed to invalidate cache for tasks %v: %v", ids, err), 
+            map[string]interface{}{"task_ids": ids, "error": err.Error()})
+    }
+}
+
+// listCacheKey versions key with the generations of namespaces, it returns ""
+// when caching is disabled or the generations can't be read
+func (s *TaskService) listCacheKey(ctx context.Context, operation, key string, namespaces ...string) string {
+    if !s.config.EnableCache {
+        return ""
+    }
+
+    versioned, err := s.generations.Versioned(key, namespaces...)
+    if err != nil {
+        s.logError(ctx, operation, 
+            fmt.Sprintf("Failed to read cache generations: %v", err), 
             map[string]interface{}{"error": err.Error()})
+        return ""
+    }
+    return versioned
+}
+
+// prefetch runs warm in the background when key isn't cached yet. Prefetching
+// is best effort: it is skipped when PrefetchBudget prefetches are already
+// running or the limiter's prefetch quota is spent.
+func (s *TaskService) prefetch(ctx context.Context, key string, warm func(ctx context.Context) error) {
+    if !s.config.PrefetchEnabled || key == "" {
+        return
+    }
+
+    select {
+    case s.prefetchSlots <- struct{}{}:
+    default:
+        return
+    }
+
+    // The prefetch outlives the request, it keeps its ID but not its deadline
+    ctx = context.WithoutCancel(ctx)
+    go func() {
+        defer func() { <-s.prefetchSlots }()
+
+        if s.cache.Exists(key) {
+            return
+        }
+        if s.config.PrefetchLimiter != nil {
+            allowed, _, _, err := s.config.PrefetchLimiter.Allow(prefetchClientID, utils.OperationPrefetch)
+            if err != nil {
+                s.logError(ctx, "prefetch", fmt.Sprintf("Failed to check prefetch quota: %v", err), map[string]interface{}{"error": err.Error()})
+                return
+            }
+            if !allowed {
+                return
+            }
+        }
+        if err := warm(ctx); err != nil {
+            s.logError(ctx, "prefetch", fmt.Sprintf("Failed to prefetch %s: %v", key, err), map[string]interface{}{"key": key, "error": err.Error()})
+        }
+    }()
+}
+
+// Warm loads the first pages of the task lists into the cache. Lists are
+// shared by every owner, so the recently active owners only decide whether
+// there is anyone to warm them for; an idle instance skips the queries.
+func (s *TaskService) Warm(ctx context.Context) error {
+    if !s.config.EnableCache || s.config.WarmPages <= 0 {
+        return nil
+    }
+
+    owners, err := s.repo.FindRecentOwners(time.Now().Add(-s.config.WarmActiveWindow), 1)
+    if err != nil {
+        s.logError(ctx, "warm", fmt.Sprintf("Failed to find recently active owners: %v", err), map[string]interface{}{"error": err.Error()})
+        return err
+    }
+    if len(owners) == 0 {
+        return nil
+    }
+
+    if _, err := s.listCursor(ctx, validatePaginationQuery(CursorPaginationQuery{})); err != nil {
         return err
     }
+    for page := 1; page <= s.config.WarmPages; page++ {
+        if ctx.Err() != nil {
+            return ctx.Err()
+        }
+        result, _, err := s.loadPage(ctx, page, utils.DefaultLimit, utils.DefaultOrder)
+        if err != nil {
+            s.logError(ctx, "warm", fmt.Sprintf("Failed to warm page %d: %v", page, err), map[string]interface{}{"page": page, "error": err.Error()})
+            return err
+        }
+        if int64(page*utils.DefaultLimit) >= result.TotalCount {
+            break
+        }
+    }
     return nil
 }
 
 // logError is a helper method that handles error logging based on configuration
-func (s *TaskService) logError(operation, errorMsg string, metadata map[string]interface{}) {
+func (s *TaskService) logError(ctx context.Context, operation, errorMsg string, metadata map[string]interface{}) {
     if !s.config.EnableLogging {
         return
     }
     
-    if s.config.AsyncLogging {
-        // For async logging, we don't wait for the result
-        go func() {
-            s.errorLog.LogError(context.Background(), "task-service", operation, errorMsg, metadata)
-        }()
-    } else {
-        // For sync logging, we wait for the result
-        s.errorLog.LogError(context.Background(), "task-service", operation, errorMsg, metadata)
-    }
-}
\ No newline at end of file
+    // With AsyncLogging the logger queues the error, see NewTaskServiceWithConfig
+    s.errorLog.LogError(ctx, "task-service", operation, errorMsg, metadata)
+}
+
+// publishEvent emits a task lifecycle event if a publisher is configured
+func (s *TaskService) publishEvent(ctx context.Context, eventType events.EventType, task *models.Task) {
+    if s.events == nil || task == nil {
+        return
+    }
+
+    event, err := events.NewEvent(eventType, task.ID, task.Owner, ToTaskResponse(task))
+    if err != nil {
+        s.logError(ctx, "publish-event", fmt.Sprintf("Failed to build %s event: %v", eventType, err), map[string]interface{}{"task_id": task.ID, "error": err.Error()})
+        return
+    }
+
+    // Subscribers may outlive the request, they keep its values but not its deadline
+    if err := s.events.Publish(context.WithoutCancel(ctx), event); err != nil {
+        s.logError(ctx, "publish-event", fmt.Sprintf("Failed to publish %s event: %v", eventType, err), map[string]interface{}{"task_id": task.ID, "error": err.Error()})
+    }
+}
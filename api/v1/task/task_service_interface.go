@@ -1,36 +1,94 @@
 package task
 
 import (
+	"context"
 	"time"
 
 	"github.com/hftamayo/gotodo/api/v1/models"
+	taskcache "github.com/hftamayo/gotodo/internal/task/cache"
+	"github.com/hftamayo/gotodo/pkg/cache"
 	"github.com/hftamayo/gotodo/pkg/config"
+	"github.com/hftamayo/gotodo/pkg/events"
+	"github.com/hftamayo/gotodo/pkg/utils"
 )
 
-// CacheInterface defines the contract for cache operations
-type CacheInterface interface {
-	Get(key string, dest interface{}) error
-	Set(key string, value interface{}, ttl time.Duration) error
-	SetWithTags(key string, value interface{}, ttl time.Duration, tags ...string) error
-	Delete(key string) error
-	InvalidateByTags(tags ...string) error
-}
-
 // TaskServiceInterface defines the contract for task operations
 type TaskServiceInterface interface {
 	// Core CRUD operations
-	List(cursor string, limit int, order string) ([]*models.Task, string, string, int64, error)
-	ListById(id int) (*models.Task, error)
-	Create(task *models.Task) (*models.Task, error)
-	Update(id int, task *models.Task) (*models.Task, error)
-	Delete(id int) error
-	MarkAsDone(id int) (*models.Task, error)
-	ListByPage(page int, limit int, order string) ([]*models.Task, int64, error)
+	// ctx carries the request ID and client reported with the errors
+	List(ctx context.Context, cursor string, limit int, order string) ([]*models.Task, string, string, int64, error)
+	ListById(ctx context.Context, id int) (*models.Task, cache.LoadStatus, error)
+	Create(ctx context.Context, task *models.Task) (*models.Task, error)
+	Update(ctx context.Context, id int, task *models.Task) (*models.Task, error)
+	Delete(ctx context.Context, id int) error
+	MarkAsDone(ctx context.Context, id int) (*models.Task, error)
+	ListByPage(ctx context.Context, page int, limit int, order string) ([]*models.Task, int64, cache.LoadStatus, error)
+	
+	// Bulk transfer
+	Export(ctx context.Context, filter TaskFilter, fn func(tasks []*models.Task) error) error
+	Import(ctx context.Context, owner uint, records []ImportRecord, dryRun bool) (*ImportResult, error)
 	
 	// Cache operations (moved from handler)
 	InvalidateTaskCache(id int) error
 	InvalidateListCache() error
 	InvalidatePageCache() error
+	InvalidateUserCache(owner uint) error
+	Warm(ctx context.Context) error
+	InspectCache(ctx context.Context, id int) (*TaskCacheInfo, error)
+}
+
+// TaskCacheInfo describes the cache keys of a task, Generations maps each
+// namespace its lists depend on to the current generation
+type TaskCacheInfo struct {
+	TaskID      int                `json:"taskId"`
+	Enabled     bool               `json:"enabled"`
+	Cached      bool               `json:"cached"`
+	Keys        taskcache.TaskKeys `json:"keys"`
+	Generations map[string]string  `json:"generations"`
+}
+
+// PrefetchLimiter grants background prefetches, utils.RateLimiter implements
+// it with its prefetch quota
+type PrefetchLimiter interface {
+	Allow(clientID string, op utils.OperationType) (bool, int64, time.Time, error)
+}
+
+// CacheRecorder counts how the reads of the service were served, the
+// metrics package implements it
+type CacheRecorder interface {
+	RecordCacheLoad(resource string, status cache.LoadStatus)
+}
+
+// TaskFilter narrows the tasks of an owner, a nil Done matches both states
+type TaskFilter struct {
+	Owner uint
+	Done  *bool
+}
+
+// ImportRecord is one parsed row of an import, Err holds its parse error
+type ImportRecord struct {
+	Row         int
+	Title       string
+	Description string
+	Done        bool
+	DueDate     *time.Time
+	Err         string
+}
+
+// ImportResult reports the outcome of every row of an import
+type ImportResult struct {
+	Rows     []ImportRowResult
+	Valid    int
+	Invalid  int
+	Imported int
+}
+
+// ImportRowResult is the validation outcome of a single import row
+type ImportRowResult struct {
+	Row    int    `json:"row"`
+	Title  string `json:"title"`
+	TaskID uint   `json:"taskId,omitempty"`
+	Error  string `json:"error,omitempty"`
 }
 
 // TaskServiceConfig holds configuration for the task service
@@ -38,27 +96,33 @@ type TaskServiceConfig struct {
 	// Cache configuration
 	EnableCache     bool
 	CacheTTL        time.Duration // in time.Duration
-	CacheKeys       CacheKeyConfig
+	CacheStaleTTL   time.Duration // stale pages are served this long past CacheTTL while one refresh runs
+	CacheEarlyExpirationBeta float64 // above zero, pages are refreshed early at random before CacheTTL (XFetch)
+	CacheKeys       *taskcache.TaskKeyGenerator
+	CacheRecorder   CacheRecorder // nil leaves the cache statuses of the reads uncounted
+	
+	// Prefetch of the next page after a list request
+	PrefetchEnabled bool
+	PrefetchBudget  int             // prefetches running at once
+	PrefetchLimiter PrefetchLimiter // nil leaves PrefetchBudget as the only bound
+	
+	// Startup warming, the first WarmPages pages are loaded when an owner was
+	// active within WarmActiveWindow
+	WarmPages        int
+	WarmActiveWindow time.Duration
 	
 	// Logging configuration
 	EnableLogging   bool
-	AsyncLogging    bool
+	AsyncLogging    bool // errors are queued to a bounded pool of workers, see config.AsyncErrorLogger
 	ErrorLogger     config.ErrorLogger
 	
+	// Lifecycle events (task.created, task.updated, ...), nil disables them
+	EventPublisher  events.Publisher
+	
 	// Validation configuration
 	ValidationConfig ValidationConfig
 }
 
-// CacheKeyConfig holds all cache key patterns
-type CacheKeyConfig struct {
-	TaskKey          string // "task_%d"
-	TaskPageKey      string // "tasks_page_%d_%d_%s"
-	TaskCursorKey    string // "tasks_cursor_%s_limit_%d_order_%s"
-	TaskListRef      string // "tasks:list"
-	TaskReference    string // "task:%d"
-	TaskPageCache    string // "task_page_*"
-}
-
 // ValidationConfig holds validation-related configuration
 type ValidationConfig struct {
 	ErrTaskNotFoundFmt string // "task with id %d not found"
@@ -70,14 +134,12 @@ func DefaultTaskServiceConfig() *TaskServiceConfig {
 	return &TaskServiceConfig{
 		EnableCache:   true,
 		CacheTTL:      5 * time.Minute, // 5 minutes
-		CacheKeys: CacheKeyConfig{
-			TaskKey:       "task_%d",
-			TaskPageKey:   "tasks_page_%d_%d_%s",
-			TaskCursorKey: "tasks_cursor_%s_limit_%d_order_%s",
-			TaskListRef:   "tasks:list",
-			TaskReference: "task:%d",
-			TaskPageCache: "task_page_*",
-		},
+		CacheStaleTTL: time.Minute,
+		CacheKeys:     taskcache.NewTaskKeyGenerator(),
+		PrefetchEnabled:  true,
+		PrefetchBudget:   4,
+		WarmPages:        2,
+		WarmActiveWindow: 24 * time.Hour,
 		EnableLogging: true,
 		AsyncLogging:  true,
 		ValidationConfig: ValidationConfig{
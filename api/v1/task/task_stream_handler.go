@@ -0,0 +1,201 @@
+package task
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/hftamayo/gotodo/pkg/events"
+	"github.com/hftamayo/gotodo/pkg/stream"
+	"github.com/hftamayo/gotodo/pkg/utils"
+)
+
+const (
+	streamBufferSize  = 32
+	streamHeartbeat   = 25 * time.Second
+	streamWriteWait   = 10 * time.Second
+	streamPongTimeout = 60 * time.Second
+)
+
+// StreamHandler pushes task change events to connected clients
+type StreamHandler struct {
+	hub      *stream.Hub
+	upgrader websocket.Upgrader
+}
+
+func NewStreamHandler(hub *stream.Hub) *StreamHandler {
+	if hub == nil {
+		panic("stream hub is required")
+	}
+	return &StreamHandler{
+		hub: hub,
+		upgrader: websocket.Upgrader{
+			ReadBufferSize:  1024,
+			WriteBufferSize: 1024,
+			// Origins are already enforced by the CORS middleware
+			CheckOrigin: func(r *http.Request) bool { return true },
+		},
+	}
+}
+
+// SSE streams the owner's task events as Server-Sent Events
+func (h *StreamHandler) SSE(c *gin.Context) {
+	owner, ok := parseStreamOwner(c)
+	if !ok {
+		return
+	}
+
+	// Streams outlive the server WriteTimeout, so the deadline is extended per write
+	controller := http.NewResponseController(c.Writer)
+
+	sub := h.hub.Subscribe(owner, streamBufferSize)
+	defer sub.Close()
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header(headerCacheControl, "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+
+	heartbeat := time.NewTicker(streamHeartbeat)
+	defer heartbeat.Stop()
+
+	// An initial comment flushes the headers so clients see the stream as open
+	if !writeSSE(c, controller, ": connected\n\n") {
+		return
+	}
+
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case <-heartbeat.C:
+			if !writeSSE(c, controller, ": heartbeat\n\n") {
+				return
+			}
+		case event, ok := <-sub.C:
+			if !ok {
+				return
+			}
+			data, err := json.Marshal(ToTaskStreamMessage(event))
+			if err != nil {
+				continue
+			}
+			frame := fmt.Sprintf("id: %s\nevent: %s\ndata: %s\n\n", event.ID, event.Type, data)
+			if !writeSSE(c, controller, frame) {
+				return
+			}
+		}
+	}
+}
+
+// WebSocket streams the owner's task events as JSON text messages
+func (h *StreamHandler) WebSocket(c *gin.Context) {
+	owner, ok := parseStreamOwner(c)
+	if !ok {
+		return
+	}
+
+	conn, err := h.upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		// The upgrader has already written the error response
+		return
+	}
+	defer conn.Close()
+
+	sub := h.hub.Subscribe(owner, streamBufferSize)
+	defer sub.Close()
+
+	// The read loop only processes control frames and detects disconnects
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		conn.SetReadLimit(512)
+		conn.SetReadDeadline(time.Now().Add(streamPongTimeout))
+		conn.SetPongHandler(func(string) error {
+			return conn.SetReadDeadline(time.Now().Add(streamPongTimeout))
+		})
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	ping := time.NewTicker(streamHeartbeat)
+	defer ping.Stop()
+
+	for {
+		select {
+		case <-closed:
+			return
+		case <-ping.C:
+			conn.SetWriteDeadline(time.Now().Add(streamWriteWait))
+			if err := conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				return
+			}
+		case event, ok := <-sub.C:
+			if !ok {
+				// The hub closed on shutdown
+				conn.WriteControl(websocket.CloseMessage,
+					websocket.FormatCloseMessage(websocket.CloseGoingAway, "server shutting down"),
+					time.Now().Add(streamWriteWait))
+				return
+			}
+			conn.SetWriteDeadline(time.Now().Add(streamWriteWait))
+			if err := conn.WriteJSON(ToTaskStreamMessage(event)); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// TaskStreamMessage is the payload pushed to stream clients
+type TaskStreamMessage struct {
+	ID        string              `json:"id"`
+	Event     events.EventType    `json:"event"`
+	TaskID    uint                `json:"taskId"`
+	Task      json.RawMessage     `json:"task,omitempty"`
+	Status    TaskOperationStatus `json:"status"`
+	Timestamp int64               `json:"timestamp"`
+}
+
+func ToTaskStreamMessage(event events.Event) TaskStreamMessage {
+	return TaskStreamMessage{
+		ID:     event.ID,
+		Event:  event.Type,
+		TaskID: event.TaskID,
+		Task:   event.Data,
+		Status: TaskOperationStatus{
+			LastUpdated:     event.OccurredAt.Unix(),
+			RefreshRequired: true,
+		},
+		Timestamp: time.Now().Unix(),
+	}
+}
+
+func parseStreamOwner(c *gin.Context) (uint, bool) {
+	owner, err := strconv.ParseUint(c.Query("owner"), 10, 64)
+	if err != nil || owner == 0 {
+		c.JSON(http.StatusBadRequest, NewErrorResponse(
+			http.StatusBadRequest,
+			utils.OperationFailed,
+			ErrInvalidOwner.Error(),
+		))
+		return 0, false
+	}
+	return uint(owner), true
+}
+
+func writeSSE(c *gin.Context, controller *http.ResponseController, frame string) bool {
+	controller.SetWriteDeadline(time.Now().Add(streamWriteWait))
+	if _, err := c.Writer.WriteString(frame); err != nil {
+		return false
+	}
+	c.Writer.Flush()
+	return true
+}
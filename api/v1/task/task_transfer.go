@@ -0,0 +1,485 @@
+package task
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/hftamayo/gotodo/api/v1/models"
+)
+
+// Supported import/export formats
+const (
+	FormatCSV  = "csv"
+	FormatJSON = "json"
+	FormatICS  = "ics"
+)
+
+const (
+	icsDateTimeFmt = "20060102T150405Z"
+	icsFloatingFmt = "20060102T150405"
+	icsDateFmt     = "20060102"
+	icsLineLimit   = 75
+	icsProductID   = "-//gotodo//tasks export//EN"
+	csvDateOnlyFmt = "2006-01-02"
+)
+
+var (
+	ErrUnsupportedFormat  = errors.New("unsupported format, expected csv, json or ics")
+	ErrMissingTitleColumn = errors.New("csv header must include a title column")
+)
+
+var csvExportHeader = []string{"id", "title", "description", "done", "owner", "dueDate", "createdAt", "updatedAt"}
+
+// FormatContentType returns the MIME type of an import/export format
+func FormatContentType(format string) string {
+	switch format {
+	case FormatCSV:
+		return "text/csv; charset=utf-8"
+	case FormatICS:
+		return "text/calendar; charset=utf-8"
+	default:
+		return "application/json; charset=utf-8"
+	}
+}
+
+// FormatFromContentType maps a request Content-Type to an import format
+func FormatFromContentType(contentType string) string {
+	mediaType := strings.ToLower(strings.TrimSpace(strings.Split(contentType, ";")[0]))
+	switch mediaType {
+	case "text/csv", "application/csv":
+		return FormatCSV
+	case "text/calendar":
+		return FormatICS
+	case "application/json":
+		return FormatJSON
+	default:
+		return ""
+	}
+}
+
+// taskEncoder writes tasks incrementally so exports can be streamed
+type taskEncoder interface {
+	Begin() error
+	Encode(task *models.Task) error
+	End() error
+}
+
+func newTaskEncoder(format string, w io.Writer) (taskEncoder, error) {
+	switch format {
+	case FormatCSV:
+		return &csvEncoder{w: csv.NewWriter(w)}, nil
+	case FormatJSON:
+		return &jsonEncoder{w: w}, nil
+	case FormatICS:
+		return &icsEncoder{w: bufio.NewWriter(w), stamp: time.Now().UTC()}, nil
+	default:
+		return nil, ErrUnsupportedFormat
+	}
+}
+
+type csvEncoder struct {
+	w *csv.Writer
+}
+
+func (e *csvEncoder) Begin() error {
+	return e.w.Write(csvExportHeader)
+}
+
+func (e *csvEncoder) Encode(task *models.Task) error {
+	dueDate := ""
+	if task.DueDate != nil {
+		dueDate = task.DueDate.UTC().Format(time.RFC3339)
+	}
+
+	if err := e.w.Write([]string{
+		strconv.FormatUint(uint64(task.ID), 10),
+		task.Title,
+		task.Description,
+		strconv.FormatBool(task.Done),
+		strconv.FormatUint(uint64(task.Owner), 10),
+		dueDate,
+		task.CreatedAt.UTC().Format(time.RFC3339),
+		task.UpdatedAt.UTC().Format(time.RFC3339),
+	}); err != nil {
+		return err
+	}
+	e.w.Flush()
+	return e.w.Error()
+}
+
+func (e *csvEncoder) End() error {
+	e.w.Flush()
+	return e.w.Error()
+}
+
+// jsonEncoder writes a JSON array of TaskResponse, the same shape the import accepts
+type jsonEncoder struct {
+	w     io.Writer
+	count int
+}
+
+func (e *jsonEncoder) Begin() error {
+	_, err := io.WriteString(e.w, "[")
+	return err
+}
+
+func (e *jsonEncoder) Encode(task *models.Task) error {
+	data, err := json.Marshal(ToTaskResponse(task))
+	if err != nil {
+		return err
+	}
+	if e.count > 0 {
+		if _, err := io.WriteString(e.w, ","); err != nil {
+			return err
+		}
+	}
+	e.count++
+	_, err = e.w.Write(data)
+	return err
+}
+
+func (e *jsonEncoder) End() error {
+	_, err := io.WriteString(e.w, "]\n")
+	return err
+}
+
+// icsEncoder writes an RFC 5545 calendar with one VTODO per task
+type icsEncoder struct {
+	w     *bufio.Writer
+	stamp time.Time
+}
+
+func (e *icsEncoder) Begin() error {
+	e.writeLine("BEGIN:VCALENDAR")
+	e.writeLine("VERSION:2.0")
+	e.writeLine("PRODID:" + icsProductID)
+	e.writeLine("CALSCALE:GREGORIAN")
+	return e.w.Flush()
+}
+
+func (e *icsEncoder) Encode(task *models.Task) error {
+	status := "NEEDS-ACTION"
+	if task.Done {
+		status = "COMPLETED"
+	}
+
+	e.writeLine("BEGIN:VTODO")
+	e.writeLine(fmt.Sprintf("UID:task-%d@gotodo", task.ID))
+	e.writeLine("DTSTAMP:" + e.stamp.Format(icsDateTimeFmt))
+	e.writeLine("CREATED:" + task.CreatedAt.UTC().Format(icsDateTimeFmt))
+	e.writeLine("LAST-MODIFIED:" + task.UpdatedAt.UTC().Format(icsDateTimeFmt))
+	e.writeLine("SUMMARY:" + escapeICSText(task.Title))
+	if task.Description != "" {
+		e.writeLine("DESCRIPTION:" + escapeICSText(task.Description))
+	}
+	e.writeLine("STATUS:" + status)
+	if task.DueDate != nil {
+		e.writeLine("DUE:" + task.DueDate.UTC().Format(icsDateTimeFmt))
+	}
+	e.writeLine("END:VTODO")
+	return e.w.Flush()
+}
+
+func (e *icsEncoder) End() error {
+	e.writeLine("END:VCALENDAR")
+	return e.w.Flush()
+}
+
+// writeLine folds content lines longer than 75 octets without splitting a
+// UTF-8 sequence, continuation lines start with a space
+func (e *icsEncoder) writeLine(line string) {
+	limit := icsLineLimit
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(line[cut]) {
+			cut--
+		}
+		e.w.WriteString(line[:cut])
+		e.w.WriteString("\r\n ")
+		line = line[cut:]
+		limit = icsLineLimit - 1
+	}
+	e.w.WriteString(line)
+	e.w.WriteString("\r\n")
+}
+
+func escapeICSText(value string) string {
+	return strings.NewReplacer(
+		`\`, `\\`,
+		";", `\;`,
+		",", `\,`,
+		"\r\n", `\n`,
+		"\n", `\n`,
+		"\r", "",
+	).Replace(value)
+}
+
+func unescapeICSText(value string) string {
+	var b strings.Builder
+	for i := 0; i < len(value); i++ {
+		if value[i] != '\\' || i == len(value)-1 {
+			b.WriteByte(value[i])
+			continue
+		}
+		i++
+		switch value[i] {
+		case 'n', 'N':
+			b.WriteByte('\n')
+		default:
+			b.WriteByte(value[i])
+		}
+	}
+	return b.String()
+}
+
+// decodeTasks parses an import document, a malformed row is returned with
+// its Err set so every problem can be reported at once
+func decodeTasks(format string, r io.Reader) ([]ImportRecord, error) {
+	switch format {
+	case FormatCSV:
+		return decodeCSV(r)
+	case FormatJSON:
+		return decodeJSON(r)
+	case FormatICS:
+		return decodeICS(r)
+	default:
+		return nil, ErrUnsupportedFormat
+	}
+}
+
+func decodeCSV(r io.Reader) ([]ImportRecord, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid csv header: %w", err)
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))] = i
+	}
+	if _, ok := columns["title"]; !ok {
+		return nil, ErrMissingTitleColumn
+	}
+
+	field := func(row []string, name string) string {
+		if i, ok := columns[strings.ToLower(name)]; ok && i < len(row) {
+			return strings.TrimSpace(row[i])
+		}
+		return ""
+	}
+
+	var records []ImportRecord
+	for rowNumber := 2; ; rowNumber++ {
+		row, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid csv at row %d: %w", rowNumber, err)
+		}
+
+		record := ImportRecord{
+			Row:         rowNumber,
+			Title:       field(row, "title"),
+			Description: field(row, "description"),
+		}
+		if done := field(row, "done"); done != "" {
+			if record.Done, err = strconv.ParseBool(done); err != nil {
+				record.Err = fmt.Sprintf("invalid done value %q", done)
+			}
+		}
+		if dueDate := field(row, "dueDate"); dueDate != "" && record.Err == "" {
+			if record.DueDate, err = parseImportDate(dueDate); err != nil {
+				record.Err = err.Error()
+			}
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+// importTaskJSON accepts both hand-written objects and the export format
+type importTaskJSON struct {
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	Done        bool   `json:"done"`
+	DueDate     string `json:"dueDate"`
+}
+
+func decodeJSON(r io.Reader) ([]ImportRecord, error) {
+	var items []json.RawMessage
+	if err := json.NewDecoder(r).Decode(&items); err != nil {
+		return nil, fmt.Errorf("invalid json, expected an array of tasks: %w", err)
+	}
+
+	records := make([]ImportRecord, 0, len(items))
+	for i, item := range items {
+		record := ImportRecord{Row: i + 1}
+
+		var task importTaskJSON
+		if err := json.Unmarshal(item, &task); err != nil {
+			record.Err = fmt.Sprintf("invalid task object: %v", err)
+			records = append(records, record)
+			continue
+		}
+
+		record.Title = strings.TrimSpace(task.Title)
+		record.Description = task.Description
+		record.Done = task.Done
+		if task.DueDate != "" {
+			dueDate, err := parseImportDate(task.DueDate)
+			if err != nil {
+				record.Err = err.Error()
+			}
+			record.DueDate = dueDate
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+// decodeICS reads the VTODO components of a calendar, each one is a row
+func decodeICS(r io.Reader) ([]ImportRecord, error) {
+	lines, err := unfoldICSLines(r)
+	if err != nil {
+		return nil, fmt.Errorf("invalid calendar: %w", err)
+	}
+
+	var records []ImportRecord
+	var current *ImportRecord
+	for _, line := range lines {
+		name, params, value, ok := parseICSProperty(line)
+		if !ok {
+			continue
+		}
+
+		switch {
+		case name == "BEGIN" && strings.EqualFold(value, "VTODO"):
+			current = &ImportRecord{Row: len(records) + 1}
+		case name == "END" && strings.EqualFold(value, "VTODO"):
+			if current != nil {
+				records = append(records, *current)
+				current = nil
+			}
+		case current == nil:
+			continue
+		case name == "SUMMARY":
+			current.Title = strings.TrimSpace(unescapeICSText(value))
+		case name == "DESCRIPTION":
+			current.Description = unescapeICSText(value)
+		case name == "STATUS":
+			current.Done = strings.EqualFold(value, "COMPLETED")
+		case name == "DUE":
+			dueDate, err := parseICSDate(value, params)
+			if err != nil && current.Err == "" {
+				current.Err = err.Error()
+			}
+			current.DueDate = dueDate
+		}
+	}
+
+	if current != nil {
+		return nil, errors.New("invalid calendar: unterminated VTODO")
+	}
+	return records, nil
+}
+
+func unfoldICSLines(r io.Reader) ([]string, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	var lines []string
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if len(line) > 0 && (line[0] == ' ' || line[0] == '\t') && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return lines, scanner.Err()
+}
+
+// parseICSProperty splits "NAME;PARAM=x:value", colons inside quoted
+// parameter values are not separators
+func parseICSProperty(line string) (string, map[string]string, string, bool) {
+	inQuotes := false
+	sep := -1
+	for i, r := range line {
+		if r == '"' {
+			inQuotes = !inQuotes
+		}
+		if r == ':' && !inQuotes {
+			sep = i
+			break
+		}
+	}
+	if sep < 1 {
+		return "", nil, "", false
+	}
+
+	parts := strings.Split(line[:sep], ";")
+	params := make(map[string]string, len(parts)-1)
+	for _, param := range parts[1:] {
+		if key, value, ok := strings.Cut(param, "="); ok {
+			params[strings.ToUpper(key)] = strings.Trim(value, `"`)
+		}
+	}
+	return strings.ToUpper(parts[0]), params, line[sep+1:], true
+}
+
+func parseICSDate(value string, params map[string]string) (*time.Time, error) {
+	location := time.UTC
+	if tzid := params["TZID"]; tzid != "" {
+		loc, err := time.LoadLocation(tzid)
+		if err != nil {
+			return nil, fmt.Errorf("unknown time zone %q", tzid)
+		}
+		location = loc
+	}
+
+	var (
+		parsed time.Time
+		err    error
+	)
+	switch {
+	case params["VALUE"] == "DATE" || len(value) == len(icsDateFmt):
+		parsed, err = time.ParseInLocation(icsDateFmt, value, location)
+	case strings.HasSuffix(value, "Z"):
+		parsed, err = time.Parse(icsDateTimeFmt, value)
+	default:
+		parsed, err = time.ParseInLocation(icsFloatingFmt, value, location)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid due date %q", value)
+	}
+
+	parsed = parsed.UTC()
+	return &parsed, nil
+}
+
+// parseImportDate accepts RFC 3339 timestamps and plain dates
+func parseImportDate(value string) (*time.Time, error) {
+	for _, layout := range []string{time.RFC3339, csvDateOnlyFmt} {
+		if parsed, err := time.Parse(layout, value); err == nil {
+			parsed = parsed.UTC()
+			return &parsed, nil
+		}
+	}
+	return nil, fmt.Errorf("invalid due date %q, expected RFC 3339 or YYYY-MM-DD", value)
+}
@@ -0,0 +1,181 @@
+package task
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hftamayo/gotodo/api/v1/models"
+	"github.com/hftamayo/gotodo/pkg/utils"
+)
+
+const (
+	maxImportBytes  = 5 << 20
+	maxImportRows   = 5000
+	exportWriteWait = 15 * time.Second
+)
+
+// Export streams the owner's tasks as CSV, JSON or iCalendar, ?done= narrows
+// them the same way the list filters do
+func (h *Handler) Export(c *gin.Context) {
+	var query ExportQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, NewErrorResponse(
+			http.StatusBadRequest,
+			utils.OperationFailed,
+			ErrInvalidTransferQuery.Error(),
+		))
+		return
+	}
+	if query.Format == "" {
+		query.Format = FormatJSON
+	}
+
+	encoder, err := newTaskEncoder(query.Format, c.Writer)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, NewErrorResponse(
+			http.StatusBadRequest,
+			utils.OperationFailed,
+			err.Error(),
+		))
+		return
+	}
+
+	// Large exports outlive the server WriteTimeout, so the deadline is extended per batch
+	controller := http.NewResponseController(c.Writer)
+
+	// Headers are sent with the first batch, so a failure before any data was
+	// written can still be reported as a regular error response
+	started := false
+	begin := func() error {
+		started = true
+		c.Header("Content-Type", FormatContentType(query.Format))
+		c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="tasks-%d.%s"`, query.Owner, query.Format))
+		c.Header(headerCacheControl, "no-store")
+		c.Status(http.StatusOK)
+		return encoder.Begin()
+	}
+
+	err = h.service.Export(c.Request.Context(), TaskFilter{Owner: query.Owner, Done: query.Done}, func(tasks []*models.Task) error {
+		controller.SetWriteDeadline(time.Now().Add(exportWriteWait))
+		if !started {
+			if err := begin(); err != nil {
+				return err
+			}
+		}
+		for _, task := range tasks {
+			if err := encoder.Encode(task); err != nil {
+				return err
+			}
+		}
+		c.Writer.Flush()
+		return nil
+	})
+
+	if err != nil {
+		if !started {
+			c.JSON(http.StatusInternalServerError, NewErrorResponse(
+				http.StatusInternalServerError,
+				utils.OperationFailed,
+				err.Error(),
+			))
+		}
+		// Otherwise the body is already partially sent, the truncated document tells the client
+		c.Error(err)
+		return
+	}
+
+	if !started {
+		if err := begin(); err != nil {
+			c.Error(err)
+			return
+		}
+	}
+	if err := encoder.End(); err != nil {
+		c.Error(err)
+	}
+}
+
+// Import creates tasks for the owner from a CSV, JSON or iCalendar body. The
+// format comes from ?format= or the Content-Type, ?dryRun=true only validates.
+func (h *Handler) Import(c *gin.Context) {
+	var query ImportQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, NewErrorResponse(
+			http.StatusBadRequest,
+			utils.OperationFailed,
+			ErrInvalidTransferQuery.Error(),
+		))
+		return
+	}
+	if query.Format == "" {
+		query.Format = FormatFromContentType(c.ContentType())
+	}
+
+	body := http.MaxBytesReader(c.Writer, c.Request.Body, maxImportBytes)
+	records, err := decodeTasks(query.Format, body)
+	if err != nil {
+		statusCode := http.StatusBadRequest
+		errorMsg := err.Error()
+
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			statusCode = http.StatusRequestEntityTooLarge
+			errorMsg = ErrImportTooLarge.Error()
+		}
+
+		c.JSON(statusCode, NewErrorResponse(
+			statusCode,
+			utils.OperationFailed,
+			errorMsg,
+		))
+		return
+	}
+
+	if len(records) == 0 {
+		c.JSON(http.StatusBadRequest, NewErrorResponse(
+			http.StatusBadRequest,
+			utils.OperationFailed,
+			ErrEmptyImport.Error(),
+		))
+		return
+	}
+	if len(records) > maxImportRows {
+		c.JSON(http.StatusRequestEntityTooLarge, NewErrorResponse(
+			http.StatusRequestEntityTooLarge,
+			utils.OperationFailed,
+			fmt.Sprintf("%s of %d rows", ErrImportTooLarge.Error(), maxImportRows),
+		))
+		return
+	}
+
+	result, err := h.service.Import(c.Request.Context(), query.Owner, records, query.DryRun)
+	if err != nil && !errors.Is(err, ErrImportRejected) {
+		c.JSON(http.StatusInternalServerError, NewErrorResponse(
+			http.StatusInternalServerError,
+			utils.OperationFailed,
+			err.Error(),
+		))
+		return
+	}
+
+	statusCode := http.StatusCreated
+	resultMessage := utils.OperationSuccess
+	switch {
+	case errors.Is(err, ErrImportRejected):
+		statusCode = http.StatusUnprocessableEntity
+		resultMessage = utils.OperationFailed
+	case query.DryRun:
+		statusCode = http.StatusOK
+	}
+
+	addCacheHeaders(c, !query.DryRun)
+	c.JSON(statusCode, TaskOperationResponse{
+		Code:          statusCode,
+		ResultMessage: resultMessage,
+		Data:          ToImportResponse(result, query.Format, query.DryRun),
+		Timestamp:     time.Now().Unix(),
+	})
+}
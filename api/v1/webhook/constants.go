@@ -0,0 +1,17 @@
+package webhook
+
+const (
+	// Delivery statuses, dead deliveries form the dead-letter store
+	DeliveryPending   = "pending"
+	DeliveryInFlight  = "in_flight"
+	DeliverySucceeded = "succeeded"
+	DeliveryDead      = "dead"
+
+	// Outbound request headers
+	headerEvent     = "X-Webhook-Event"
+	headerDelivery  = "X-Webhook-Delivery"
+	headerTimestamp = "X-Webhook-Timestamp"
+	headerSignature = "X-Webhook-Signature"
+
+	errSubscriptionNotFoundFmt = "webhook subscription with id %d not found"
+)
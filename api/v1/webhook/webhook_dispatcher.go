@@ -0,0 +1,332 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/hftamayo/gotodo/api/v1/models"
+	"github.com/hftamayo/gotodo/pkg/config"
+	"github.com/hftamayo/gotodo/pkg/events"
+	"github.com/hftamayo/gotodo/pkg/safehttp"
+)
+
+// Dispatcher delivers task events to webhook subscriptions asynchronously.
+// Every delivery is persisted before it is attempted, so retries survive
+// restarts and a full delivery queue only delays a delivery until the next
+// poll. Events handed to HandleEvent are not persisted until they are fanned
+// out, those that find the event queue full are dropped and counted. The
+// outbox relay uses Deliver instead, which loses none.
+type Dispatcher struct {
+	repo       WebhookRepository
+	client     *http.Client
+	config     *config.WebhookConfig
+	errorLog   config.ErrorLogger
+	events     chan events.Event
+	deliveries chan uint
+	dropped    atomic.Uint64
+}
+
+// NewDispatcher creates a new webhook dispatcher
+func NewDispatcher(repo WebhookRepository, webhookConfig *config.WebhookConfig, errorLog config.ErrorLogger) *Dispatcher {
+	if webhookConfig == nil {
+		webhookConfig = config.DefaultWebhookConfig()
+	}
+
+	if errorLog == nil {
+		errorLog = config.NewErrorLoggerWithDefaults()
+	}
+
+	return &Dispatcher{
+		repo:       repo,
+		client:     safehttp.NewClient(webhookConfig.RequestTimeout),
+		config:     webhookConfig,
+		errorLog:   errorLog,
+		events:     make(chan events.Event, webhookConfig.QueueSize),
+		deliveries: make(chan uint, webhookConfig.QueueSize),
+	}
+}
+
+// HandleEvent is an events.Subscriber, it only queues the event so the task
+// request that emitted it is never blocked by webhook delivery. An event
+// that finds the queue full is lost, see DroppedEvents.
+func (d *Dispatcher) HandleEvent(ctx context.Context, event events.Event) error {
+	select {
+	case d.events <- event:
+		return nil
+	default:
+		dropped := d.dropped.Add(1)
+		d.logError("handle-event", fmt.Sprintf("Webhook event queue full, dropping %s event %s", event.Type, event.ID),
+			map[string]interface{}{"event_id": event.ID, "task_id": event.TaskID, "dropped": dropped})
+		return fmt.Errorf("webhook event queue full, dropped event %s", event.ID)
+	}
+}
+
+// DroppedEvents returns the number of events HandleEvent dropped because the
+// queue was full, their deliveries were never created
+func (d *Dispatcher) DroppedEvents() uint64 {
+	return d.dropped.Load()
+}
+
+// Deliver is an events.Subscriber that persists the event's deliveries before
+// returning, it is used by the outbox relay so no event is lost on a crash
+func (d *Dispatcher) Deliver(ctx context.Context, event events.Event) error {
+	return d.fanOut(event)
+}
+
+// Enqueue schedules an immediate attempt of a persisted delivery
+func (d *Dispatcher) Enqueue(deliveryID uint) {
+	select {
+	case d.deliveries <- deliveryID:
+	default:
+		// The retry poller picks up pending deliveries that did not fit
+	}
+}
+
+// Start runs the event fan-out, delivery workers and retry poller until ctx is cancelled
+func (d *Dispatcher) Start(ctx context.Context) {
+	var wg sync.WaitGroup
+
+	workers := d.config.Workers
+	if workers < 1 {
+		workers = 1
+	}
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			d.runWorker(ctx)
+		}()
+	}
+
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		d.runFanOut(ctx)
+	}()
+	go func() {
+		defer wg.Done()
+		d.runRetryPoller(ctx)
+	}()
+
+	wg.Wait()
+}
+
+// runFanOut turns queued events into one persisted delivery per matching subscription
+func (d *Dispatcher) runFanOut(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event := <-d.events:
+			if err := d.fanOut(event); err != nil {
+				d.logError("fan-out", err.Error(), map[string]interface{}{"event_id": event.ID, "error": err.Error()})
+			}
+		}
+	}
+}
+
+func (d *Dispatcher) fanOut(event events.Event) error {
+	subs, err := d.repo.FindActiveSubscriptions(event.Owner)
+	if err != nil {
+		return fmt.Errorf("failed to find subscriptions for event %s: %w", event.ID, err)
+	}
+
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to encode event %s: %w", event.ID, err)
+	}
+
+	now := time.Now()
+	var deliveries []*models.WebhookDelivery
+	for _, sub := range subs {
+		if !subscribesTo(sub, event.Type) {
+			continue
+		}
+		deliveries = append(deliveries, &models.WebhookDelivery{
+			SubscriptionID: sub.ID,
+			EventID:        event.ID,
+			EventType:      string(event.Type),
+			Payload:        string(payload),
+			Status:         DeliveryPending,
+			NextAttemptAt:  now,
+		})
+	}
+
+	if err := d.repo.CreateDeliveries(deliveries); err != nil {
+		return fmt.Errorf("failed to store deliveries for event %s: %w", event.ID, err)
+	}
+
+	for _, delivery := range deliveries {
+		if delivery.ID != 0 {
+			d.Enqueue(delivery.ID)
+		}
+	}
+	return nil
+}
+
+func (d *Dispatcher) runWorker(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case id := <-d.deliveries:
+			d.attempt(ctx, id)
+		}
+	}
+}
+
+// runRetryPoller periodically queues pending deliveries whose backoff elapsed
+// and recovers deliveries left in-flight by a crashed worker
+func (d *Dispatcher) runRetryPoller(ctx context.Context) {
+	ticker := time.NewTicker(d.config.RetryPollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		staleBefore := time.Now().Add(-2 * d.config.RequestTimeout)
+		if err := d.repo.ResetStaleDeliveries(staleBefore); err != nil {
+			d.logError("retry-poller", err.Error(), map[string]interface{}{"error": err.Error()})
+		}
+
+		ids, err := d.repo.FindDueDeliveryIDs(time.Now(), d.config.QueueSize)
+		if err != nil {
+			d.logError("retry-poller", err.Error(), map[string]interface{}{"error": err.Error()})
+			continue
+		}
+		for _, id := range ids {
+			d.Enqueue(id)
+		}
+	}
+}
+
+// attempt sends one delivery and records the outcome, scheduling a retry with
+// exponential backoff or moving it to the dead-letter state
+func (d *Dispatcher) attempt(ctx context.Context, id uint) {
+	delivery, err := d.repo.ClaimDelivery(id, time.Now())
+	if err != nil {
+		d.logError("attempt", err.Error(), map[string]interface{}{"delivery_id": id, "error": err.Error()})
+		return
+	}
+	if delivery == nil {
+		return
+	}
+
+	sub, err := d.repo.GetSubscription(delivery.SubscriptionID)
+	if err != nil {
+		// Leave it in-flight, the poller returns it to the queue later
+		d.logError("attempt", err.Error(), map[string]interface{}{"delivery_id": id, "error": err.Error()})
+		return
+	}
+
+	delivery.Attempts++
+	if sub == nil || !sub.Active {
+		delivery.Status = DeliveryDead
+		delivery.LastError = "subscription removed or inactive"
+	} else {
+		statusCode, sendErr := d.send(ctx, sub, delivery)
+		delivery.ResponseCode = statusCode
+		d.recordOutcome(delivery, sendErr)
+	}
+
+	if err := d.repo.UpdateDelivery(delivery); err != nil {
+		d.logError("attempt", err.Error(), map[string]interface{}{"delivery_id": id, "error": err.Error()})
+	}
+}
+
+func (d *Dispatcher) recordOutcome(delivery *models.WebhookDelivery, sendErr error) {
+	now := time.Now()
+	if sendErr == nil {
+		delivery.Status = DeliverySucceeded
+		delivery.LastError = ""
+		delivery.DeliveredAt = &now
+		return
+	}
+
+	delivery.LastError = sendErr.Error()
+	if delivery.Attempts >= d.config.MaxAttempts {
+		delivery.Status = DeliveryDead
+		d.logError("attempt", fmt.Sprintf("Webhook delivery %d moved to dead letters after %d attempts: %v", delivery.ID, delivery.Attempts, sendErr),
+			map[string]interface{}{"delivery_id": delivery.ID, "subscription_id": delivery.SubscriptionID, "error": sendErr.Error()})
+		return
+	}
+
+	delivery.Status = DeliveryPending
+	delivery.NextAttemptAt = now.Add(d.backoff(delivery.Attempts))
+}
+
+// backoff returns InitialBackoff * 2^(attempts-1) capped at MaxBackoff, with
+// up to 20% jitter so retries from an outage don't arrive in lockstep
+func (d *Dispatcher) backoff(attempts int) time.Duration {
+	delay := d.config.InitialBackoff
+	for i := 1; i < attempts && delay < d.config.MaxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > d.config.MaxBackoff {
+		delay = d.config.MaxBackoff
+	}
+
+	if jitter := int64(delay) / 5; jitter > 0 {
+		delay += time.Duration(rand.Int63n(jitter))
+	}
+	return delay
+}
+
+func (d *Dispatcher) send(ctx context.Context, sub *models.WebhookSubscription, delivery *models.WebhookDelivery) (int, error) {
+	body := []byte(delivery.Payload)
+	timestamp := time.Now().Unix()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, sub.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, fmt.Errorf("failed to build webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(headerEvent, delivery.EventType)
+	req.Header.Set(headerDelivery, strconv.FormatUint(uint64(delivery.ID), 10))
+	req.Header.Set(headerTimestamp, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(headerSignature, Sign(sub.Secret, timestamp, body))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("webhook request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("webhook endpoint returned status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+func (d *Dispatcher) logError(operation, errorMsg string, metadata map[string]interface{}) {
+	d.errorLog.LogError(context.Background(), "webhook-dispatcher", operation, errorMsg, metadata)
+}
+
+// subscribesTo reports whether the subscription wants the given event type,
+// an empty event list subscribes to every task event
+func subscribesTo(sub *models.WebhookSubscription, eventType events.EventType) bool {
+	if sub.Events == "" {
+		return true
+	}
+	for _, name := range strings.Split(sub.Events, ",") {
+		if strings.TrimSpace(name) == string(eventType) {
+			return true
+		}
+	}
+	return false
+}
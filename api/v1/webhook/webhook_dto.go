@@ -0,0 +1,151 @@
+package webhook
+
+import (
+	"math"
+	"strings"
+	"time"
+
+	"github.com/hftamayo/gotodo/api/v1/models"
+	"github.com/hftamayo/gotodo/pkg/utils"
+)
+
+type CreateSubscriptionRequest struct {
+	Owner  uint     `json:"owner" binding:"required"`
+	URL    string   `json:"url" binding:"required,url"`
+	Events []string `json:"events"`
+	Secret string   `json:"secret"`
+}
+
+type DeliveryHistoryQuery struct {
+	Status string `form:"status" binding:"omitempty,oneof=pending in_flight succeeded dead"`
+	Page   int    `form:"page" binding:"omitempty,gt=0"`
+	Limit  int    `form:"limit" binding:"omitempty,gt=0"`
+}
+
+type SubscriptionResponse struct {
+	ID        uint      `json:"id"`
+	Owner     uint      `json:"owner"`
+	URL       string    `json:"url"`
+	Events    []string  `json:"events"`
+	Active    bool      `json:"active"`
+	Secret    string    `json:"secret,omitempty"` // only returned when the subscription is created
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+type DeliveryResponse struct {
+	ID             uint       `json:"id"`
+	SubscriptionID uint       `json:"subscriptionId"`
+	EventID        string     `json:"eventId"`
+	EventType      string     `json:"eventType"`
+	Status         string     `json:"status"`
+	Attempts       int        `json:"attempts"`
+	ResponseCode   int        `json:"responseCode,omitempty"`
+	LastError      string     `json:"lastError,omitempty"`
+	NextAttemptAt  time.Time  `json:"nextAttemptAt"`
+	DeliveredAt    *time.Time `json:"deliveredAt,omitempty"`
+	CreatedAt      time.Time  `json:"createdAt"`
+}
+
+type DeliveryListResponse struct {
+	Deliveries []*DeliveryResponse `json:"deliveries"`
+	Pagination PaginationMeta      `json:"pagination"`
+}
+
+type PaginationMeta struct {
+	Limit       int   `json:"limit"`
+	TotalCount  int64 `json:"totalCount"`
+	CurrentPage int   `json:"currentPage"`
+	TotalPages  int   `json:"totalPages"`
+}
+
+type WebhookOperationResponse struct {
+	Code          int         `json:"code"`
+	ResultMessage string      `json:"resultMessage"`
+	Data          interface{} `json:"data,omitempty"`
+	Timestamp     int64       `json:"timestamp"`
+}
+
+type ErrorResponse struct {
+	Code          int    `json:"code"`
+	ResultMessage string `json:"resultMessage"`
+	Error         string `json:"error,omitempty"`
+}
+
+func ToSubscriptionResponse(sub *models.WebhookSubscription) *SubscriptionResponse {
+	eventNames := splitEvents(sub.Events)
+	if eventNames == nil {
+		eventNames = []string{}
+	}
+
+	return &SubscriptionResponse{
+		ID:        sub.ID,
+		Owner:     sub.Owner,
+		URL:       sub.URL,
+		Events:    eventNames,
+		Active:    sub.Active,
+		CreatedAt: sub.CreatedAt,
+	}
+}
+
+func SubscriptionsToResponse(subs []*models.WebhookSubscription) []*SubscriptionResponse {
+	responses := make([]*SubscriptionResponse, len(subs))
+	for i, sub := range subs {
+		responses[i] = ToSubscriptionResponse(sub)
+	}
+	return responses
+}
+
+func ToDeliveryResponse(delivery *models.WebhookDelivery) *DeliveryResponse {
+	return &DeliveryResponse{
+		ID:             delivery.ID,
+		SubscriptionID: delivery.SubscriptionID,
+		EventID:        delivery.EventID,
+		EventType:      delivery.EventType,
+		Status:         delivery.Status,
+		Attempts:       delivery.Attempts,
+		ResponseCode:   delivery.ResponseCode,
+		LastError:      delivery.LastError,
+		NextAttemptAt:  delivery.NextAttemptAt,
+		DeliveredAt:    delivery.DeliveredAt,
+		CreatedAt:      delivery.CreatedAt,
+	}
+}
+
+func buildDeliveryListResponse(deliveries []*models.WebhookDelivery, totalCount int64, page, limit int) DeliveryListResponse {
+	responses := make([]*DeliveryResponse, len(deliveries))
+	for i, delivery := range deliveries {
+		responses[i] = ToDeliveryResponse(delivery)
+	}
+
+	return DeliveryListResponse{
+		Deliveries: responses,
+		Pagination: PaginationMeta{
+			Limit:       limit,
+			TotalCount:  totalCount,
+			CurrentPage: page,
+			TotalPages:  int(math.Ceil(float64(totalCount) / float64(limit))),
+		},
+	}
+}
+
+// NewWebhookOperationResponse creates a new WebhookOperationResponse with the given status
+func NewWebhookOperationResponse(code int, data interface{}) WebhookOperationResponse {
+	return WebhookOperationResponse{
+		Code:          code,
+		ResultMessage: utils.OperationSuccess,
+		Data:          data,
+		Timestamp:     time.Now().Unix(),
+	}
+}
+
+func NewErrorResponse(code int, resultMessage string, err string) *ErrorResponse {
+	return &ErrorResponse{
+		Code:          code,
+		ResultMessage: resultMessage,
+		Error:         err,
+	}
+}
+
+func eventsToList(eventNames []string) string {
+	return strings.Join(eventNames, ",")
+}
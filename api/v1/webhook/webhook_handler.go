@@ -0,0 +1,213 @@
+package webhook
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hftamayo/gotodo/api/v1/models"
+	"github.com/hftamayo/gotodo/pkg/utils"
+)
+
+var (
+	ErrInvalidID            = errors.New("invalid ID parameter")
+	ErrInvalidOwner         = errors.New("invalid owner parameter")
+	ErrInvalidRequest       = errors.New("invalid request body")
+	ErrInvalidQuery         = errors.New("invalid query parameters")
+	ErrSubscriptionNotFound = errors.New("webhook subscription not found")
+)
+
+type Handler struct {
+	service WebhookServiceInterface
+}
+
+func NewHandler(service WebhookServiceInterface) *Handler {
+	if service == nil {
+		panic("webhook service is required")
+	}
+	return &Handler{service: service}
+}
+
+func (h *Handler) Create(c *gin.Context) {
+	var request CreateSubscriptionRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, NewErrorResponse(
+			http.StatusBadRequest,
+			utils.OperationFailed,
+			ErrInvalidRequest.Error(),
+		))
+		return
+	}
+
+	sub, err := h.service.CreateSubscription(&models.WebhookSubscription{
+		Owner:  request.Owner,
+		URL:    request.URL,
+		Events: eventsToList(request.Events),
+		Secret: request.Secret,
+	})
+	if err != nil {
+		statusCode := http.StatusInternalServerError
+		if errors.Is(err, ErrInvalidURL) || errors.Is(err, ErrBlockedURL) || errors.Is(err, ErrInvalidEventType) {
+			statusCode = http.StatusBadRequest
+		}
+
+		c.JSON(statusCode, NewErrorResponse(
+			statusCode,
+			utils.OperationFailed,
+			err.Error(),
+		))
+		return
+	}
+
+	// The secret is only disclosed once, receivers need it to verify signatures
+	response := ToSubscriptionResponse(sub)
+	response.Secret = sub.Secret
+	c.JSON(http.StatusCreated, NewWebhookOperationResponse(http.StatusCreated, response))
+}
+
+func (h *Handler) List(c *gin.Context) {
+	owner, err := strconv.ParseUint(c.Query("owner"), 10, 64)
+	if err != nil || owner == 0 {
+		c.JSON(http.StatusBadRequest, NewErrorResponse(
+			http.StatusBadRequest,
+			utils.OperationFailed,
+			ErrInvalidOwner.Error(),
+		))
+		return
+	}
+
+	subs, err := h.service.ListSubscriptions(uint(owner))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, NewErrorResponse(
+			http.StatusInternalServerError,
+			utils.OperationFailed,
+			err.Error(),
+		))
+		return
+	}
+
+	c.JSON(http.StatusOK, NewWebhookOperationResponse(http.StatusOK, SubscriptionsToResponse(subs)))
+}
+
+func (h *Handler) ListById(c *gin.Context) {
+	id, ok := parseID(c, "id")
+	if !ok {
+		return
+	}
+
+	sub, err := h.service.GetSubscription(id)
+	if err != nil {
+		respondWithLookupError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, NewWebhookOperationResponse(http.StatusOK, ToSubscriptionResponse(sub)))
+}
+
+func (h *Handler) Delete(c *gin.Context) {
+	id, ok := parseID(c, "id")
+	if !ok {
+		return
+	}
+
+	if err := h.service.DeleteSubscription(id); err != nil {
+		respondWithLookupError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, NewWebhookOperationResponse(http.StatusOK, nil))
+}
+
+// Deliveries returns the delivery history of a subscription, ?status=dead
+// lists its dead letters
+func (h *Handler) Deliveries(c *gin.Context) {
+	id, ok := parseID(c, "id")
+	if !ok {
+		return
+	}
+
+	var query DeliveryHistoryQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, NewErrorResponse(
+			http.StatusBadRequest,
+			utils.OperationFailed,
+			ErrInvalidQuery.Error(),
+		))
+		return
+	}
+
+	if query.Page < 1 {
+		query.Page = 1
+	}
+	if query.Limit < 1 || query.Limit > utils.MaxLimit {
+		query.Limit = utils.DefaultLimit
+	}
+
+	deliveries, totalCount, err := h.service.ListDeliveries(id, query.Status, query.Page, query.Limit)
+	if err != nil {
+		respondWithLookupError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, NewWebhookOperationResponse(http.StatusOK,
+		buildDeliveryListResponse(deliveries, totalCount, query.Page, query.Limit)))
+}
+
+func (h *Handler) Redeliver(c *gin.Context) {
+	id, ok := parseID(c, "deliveryId")
+	if !ok {
+		return
+	}
+
+	delivery, err := h.service.Redeliver(id)
+	if err != nil {
+		statusCode := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, ErrDeliveryNotFound):
+			statusCode = http.StatusNotFound
+		case errors.Is(err, ErrDeliveryInFlight):
+			statusCode = http.StatusConflict
+		}
+
+		c.JSON(statusCode, NewErrorResponse(
+			statusCode,
+			utils.OperationFailed,
+			err.Error(),
+		))
+		return
+	}
+
+	c.JSON(http.StatusAccepted, NewWebhookOperationResponse(http.StatusAccepted, ToDeliveryResponse(delivery)))
+}
+
+func parseID(c *gin.Context, param string) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param(param), 10, 64)
+	if err != nil || id == 0 {
+		c.JSON(http.StatusBadRequest, NewErrorResponse(
+			http.StatusBadRequest,
+			utils.OperationFailed,
+			ErrInvalidID.Error(),
+		))
+		return 0, false
+	}
+	return uint(id), true
+}
+
+func respondWithLookupError(c *gin.Context, err error) {
+	if strings.Contains(err.Error(), "not found") {
+		c.JSON(http.StatusNotFound, NewErrorResponse(
+			http.StatusNotFound,
+			utils.OperationFailed,
+			ErrSubscriptionNotFound.Error(),
+		))
+		return
+	}
+
+	c.JSON(http.StatusInternalServerError, NewErrorResponse(
+		http.StatusInternalServerError,
+		utils.OperationFailed,
+		err.Error(),
+	))
+}
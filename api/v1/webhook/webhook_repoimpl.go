@@ -0,0 +1,160 @@
+package webhook
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/hftamayo/gotodo/api/v1/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type WebhookRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewWebhookRepositoryImpl(db *gorm.DB) WebhookRepository {
+	if db == nil {
+		return nil
+	}
+	return &WebhookRepositoryImpl{db: db}
+}
+
+func (r *WebhookRepositoryImpl) CreateSubscription(sub *models.WebhookSubscription) (*models.WebhookSubscription, error) {
+	if sub == nil {
+		return nil, errors.New("subscription cannot be nil")
+	}
+
+	if err := r.db.Create(sub).Error; err != nil {
+		return nil, fmt.Errorf("failed to create webhook subscription: %w", err)
+	}
+	return sub, nil
+}
+
+func (r *WebhookRepositoryImpl) ListSubscriptions(owner uint) ([]*models.WebhookSubscription, error) {
+	var subs []*models.WebhookSubscription
+	if err := r.db.Where("owner = ?", owner).Order("id asc").Find(&subs).Error; err != nil {
+		return nil, fmt.Errorf("failed to list webhook subscriptions: %w", err)
+	}
+	return subs, nil
+}
+
+func (r *WebhookRepositoryImpl) GetSubscription(id uint) (*models.WebhookSubscription, error) {
+	var sub models.WebhookSubscription
+	if err := r.db.First(&sub, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get webhook subscription: %w", err)
+	}
+	return &sub, nil
+}
+
+func (r *WebhookRepositoryImpl) DeleteSubscription(id uint) error {
+	result := r.db.Delete(&models.WebhookSubscription{}, id)
+	if result.Error != nil {
+		return fmt.Errorf("failed to delete webhook subscription: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf(errSubscriptionNotFoundFmt, id)
+	}
+	return nil
+}
+
+func (r *WebhookRepositoryImpl) FindActiveSubscriptions(owner uint) ([]*models.WebhookSubscription, error) {
+	var subs []*models.WebhookSubscription
+	if err := r.db.Where("owner = ? AND active = ?", owner, true).Find(&subs).Error; err != nil {
+		return nil, fmt.Errorf("failed to find webhook subscriptions: %w", err)
+	}
+	return subs, nil
+}
+
+// CreateDeliveries stores new deliveries, an event already delivered to a
+// subscription is skipped so a republished event doesn't fire twice
+func (r *WebhookRepositoryImpl) CreateDeliveries(deliveries []*models.WebhookDelivery) error {
+	if len(deliveries) == 0 {
+		return nil
+	}
+
+	if err := r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&deliveries).Error; err != nil {
+		return fmt.Errorf("failed to create webhook deliveries: %w", err)
+	}
+	return nil
+}
+
+func (r *WebhookRepositoryImpl) GetDelivery(id uint) (*models.WebhookDelivery, error) {
+	var delivery models.WebhookDelivery
+	if err := r.db.First(&delivery, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get webhook delivery: %w", err)
+	}
+	return &delivery, nil
+}
+
+func (r *WebhookRepositoryImpl) ListDeliveries(subscriptionID uint, status string, page int, limit int) ([]*models.WebhookDelivery, int64, error) {
+	query := r.db.Model(&models.WebhookDelivery{}).Where("subscription_id = ?", subscriptionID)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var totalCount int64
+	if err := query.Count(&totalCount).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count webhook deliveries: %w", err)
+	}
+
+	var deliveries []*models.WebhookDelivery
+	if err := query.Order("id desc").Offset((page - 1) * limit).Limit(limit).Find(&deliveries).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to list webhook deliveries: %w", err)
+	}
+	return deliveries, totalCount, nil
+}
+
+// ClaimDelivery atomically moves a due pending delivery to in-flight so only
+// one worker sends it, it returns nil when the delivery was not claimable
+func (r *WebhookRepositoryImpl) ClaimDelivery(id uint, now time.Time) (*models.WebhookDelivery, error) {
+	result := r.db.Model(&models.WebhookDelivery{}).
+		Where("id = ? AND status = ? AND next_attempt_at <= ?", id, DeliveryPending, now).
+		Update("status", DeliveryInFlight)
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to claim webhook delivery: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return nil, nil
+	}
+	return r.GetDelivery(id)
+}
+
+func (r *WebhookRepositoryImpl) UpdateDelivery(delivery *models.WebhookDelivery) error {
+	if err := r.db.Save(delivery).Error; err != nil {
+		return fmt.Errorf("failed to update webhook delivery: %w", err)
+	}
+	return nil
+}
+
+func (r *WebhookRepositoryImpl) FindDueDeliveryIDs(now time.Time, limit int) ([]uint, error) {
+	var ids []uint
+	err := r.db.Model(&models.WebhookDelivery{}).
+		Where("status = ? AND next_attempt_at <= ?", DeliveryPending, now).
+		Order("next_attempt_at asc").
+		Limit(limit).
+		Pluck("id", &ids).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to find due webhook deliveries: %w", err)
+	}
+	return ids, nil
+}
+
+// ResetStaleDeliveries returns in-flight deliveries claimed before staleBefore,
+// e.g. interrupted by a crash or restart, back to the queue
+func (r *WebhookRepositoryImpl) ResetStaleDeliveries(staleBefore time.Time) error {
+	err := r.db.Model(&models.WebhookDelivery{}).
+		Where("status = ? AND updated_at < ?", DeliveryInFlight, staleBefore).
+		Update("status", DeliveryPending).Error
+	if err != nil {
+		return fmt.Errorf("failed to reset in-flight webhook deliveries: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,26 @@
+package webhook
+
+import (
+	"time"
+
+	"github.com/hftamayo/gotodo/api/v1/models"
+)
+
+type WebhookRepository interface {
+	CreateSubscription(sub *models.WebhookSubscription) (*models.WebhookSubscription, error)
+	ListSubscriptions(owner uint) ([]*models.WebhookSubscription, error)
+	GetSubscription(id uint) (*models.WebhookSubscription, error)
+	DeleteSubscription(id uint) error
+	FindActiveSubscriptions(owner uint) ([]*models.WebhookSubscription, error)
+
+	CreateDeliveries(deliveries []*models.WebhookDelivery) error
+	GetDelivery(id uint) (*models.WebhookDelivery, error)
+	ListDeliveries(subscriptionID uint, status string, page int, limit int) ([]*models.WebhookDelivery, int64, error)
+	ClaimDelivery(id uint, now time.Time) (*models.WebhookDelivery, error)
+	UpdateDelivery(delivery *models.WebhookDelivery) error
+	FindDueDeliveryIDs(now time.Time, limit int) ([]uint, error)
+	ResetStaleDeliveries(staleBefore time.Time) error
+}
+
+// Ensure WebhookRepositoryImpl implements WebhookRepository at compile time
+var _ WebhookRepository = (*WebhookRepositoryImpl)(nil)
@@ -0,0 +1,140 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hftamayo/gotodo/api/v1/models"
+	"github.com/hftamayo/gotodo/pkg/events"
+	"github.com/hftamayo/gotodo/pkg/safehttp"
+)
+
+var (
+	ErrInvalidURL       = errors.New("webhook URL must be an absolute http or https URL")
+	ErrBlockedURL       = errors.New("webhook URL must not point to a loopback, private or link-local address")
+	ErrInvalidEventType = errors.New("unknown event type")
+	ErrDeliveryNotFound = errors.New("webhook delivery not found")
+	ErrDeliveryInFlight = errors.New("webhook delivery is currently in flight")
+)
+
+type WebhookService struct {
+	repo       WebhookRepository
+	dispatcher *Dispatcher
+}
+
+var _ WebhookServiceInterface = (*WebhookService)(nil)
+
+// NewWebhookService creates a webhook service, the dispatcher is used to
+// schedule redeliveries
+func NewWebhookService(repo WebhookRepository, dispatcher *Dispatcher) *WebhookService {
+	return &WebhookService{
+		repo:       repo,
+		dispatcher: dispatcher,
+	}
+}
+
+// CreateSubscription validates and stores a subscription, a random signing
+// secret is generated when none is provided
+func (s *WebhookService) CreateSubscription(sub *models.WebhookSubscription) (*models.WebhookSubscription, error) {
+	if sub == nil {
+		return nil, fmt.Errorf("invalid subscription data")
+	}
+
+	if err := safehttp.ValidateURL(context.Background(), sub.URL); err != nil {
+		if errors.Is(err, safehttp.ErrBlockedAddress) {
+			return nil, ErrBlockedURL
+		}
+		return nil, ErrInvalidURL
+	}
+
+	eventNames := splitEvents(sub.Events)
+	for _, name := range eventNames {
+		if !events.IsValidType(name) {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidEventType, name)
+		}
+	}
+	sub.Events = strings.Join(eventNames, ",")
+
+	if sub.Secret == "" {
+		secret, err := generateSecret()
+		if err != nil {
+			return nil, err
+		}
+		sub.Secret = secret
+	}
+	sub.Active = true
+
+	return s.repo.CreateSubscription(sub)
+}
+
+func (s *WebhookService) ListSubscriptions(owner uint) ([]*models.WebhookSubscription, error) {
+	return s.repo.ListSubscriptions(owner)
+}
+
+func (s *WebhookService) GetSubscription(id uint) (*models.WebhookSubscription, error) {
+	sub, err := s.repo.GetSubscription(id)
+	if err != nil {
+		return nil, err
+	}
+	if sub == nil {
+		return nil, fmt.Errorf(errSubscriptionNotFoundFmt, id)
+	}
+	return sub, nil
+}
+
+func (s *WebhookService) DeleteSubscription(id uint) error {
+	return s.repo.DeleteSubscription(id)
+}
+
+func (s *WebhookService) ListDeliveries(subscriptionID uint, status string, page int, limit int) ([]*models.WebhookDelivery, int64, error) {
+	if _, err := s.GetSubscription(subscriptionID); err != nil {
+		return nil, 0, err
+	}
+	return s.repo.ListDeliveries(subscriptionID, status, page, limit)
+}
+
+// Redeliver resets a delivery, typically a dead letter, and queues it again
+// with a fresh retry budget
+func (s *WebhookService) Redeliver(deliveryID uint) (*models.WebhookDelivery, error) {
+	delivery, err := s.repo.GetDelivery(deliveryID)
+	if err != nil {
+		return nil, err
+	}
+	if delivery == nil {
+		return nil, ErrDeliveryNotFound
+	}
+	if delivery.Status == DeliveryInFlight {
+		return nil, ErrDeliveryInFlight
+	}
+
+	delivery.Status = DeliveryPending
+	delivery.Attempts = 0
+	delivery.LastError = ""
+	delivery.NextAttemptAt = time.Now()
+	if err := s.repo.UpdateDelivery(delivery); err != nil {
+		return nil, err
+	}
+
+	if s.dispatcher != nil {
+		s.dispatcher.Enqueue(delivery.ID)
+	}
+	return delivery, nil
+}
+
+// splitEvents normalizes a comma separated event list, dropping blanks and duplicates
+func splitEvents(list string) []string {
+	var names []string
+	seen := make(map[string]bool)
+	for _, name := range strings.Split(list, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" || seen[name] {
+			continue
+		}
+		seen[name] = true
+		names = append(names, name)
+	}
+	return names
+}
@@ -0,0 +1,17 @@
+package webhook
+
+import (
+	"github.com/hftamayo/gotodo/api/v1/models"
+)
+
+// WebhookServiceInterface defines the contract for webhook subscription operations
+type WebhookServiceInterface interface {
+	CreateSubscription(sub *models.WebhookSubscription) (*models.WebhookSubscription, error)
+	ListSubscriptions(owner uint) ([]*models.WebhookSubscription, error)
+	GetSubscription(id uint) (*models.WebhookSubscription, error)
+	DeleteSubscription(id uint) error
+
+	// Delivery history and dead letters
+	ListDeliveries(subscriptionID uint, status string, page int, limit int) ([]*models.WebhookDelivery, int64, error)
+	Redeliver(deliveryID uint) (*models.WebhookDelivery, error)
+}
@@ -3,7 +3,7 @@ package main
 import (
 	"context"
 	"fmt"
-	"log"
+	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
@@ -12,64 +12,98 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/hftamayo/gotodo/api/routes"
+	"github.com/hftamayo/gotodo/pkg/cache"
 	"github.com/hftamayo/gotodo/pkg/config"
+	"github.com/hftamayo/gotodo/pkg/metrics"
 	"github.com/hftamayo/gotodo/pkg/middleware"
 )
 
 func main() {
-	fmt.Printf("Starting GoToDo API\n")
-
-	r := gin.Default()
-	fmt.Printf("reading environment...\n")
 	envVars, err := config.LoadEnvVars()
 	if err != nil {
-		log.Fatalf("Error loading environment variables: %v", err)
+		fatal("Error loading environment variables", err)
+	}
+
+	// JSON logs on stdout, the log package writes through the same logger
+	logger, err := config.SetupLogging(config.DefaultLoggingConfig())
+	if err != nil {
+		fatal("Invalid logging configuration", err)
 	}
+	slog.Info("Starting GoToDo API")
+
+	r := gin.New()
+	r.Use(middleware.RequestID())
+	r.Use(middleware.Recovery(logger))
 
-	fmt.Printf("setting up CORS...\n")
+	slog.Info("setting up trusted proxies")
+	proxyConfig := config.DefaultProxyConfig()
+	if err := r.SetTrustedProxies(proxyConfig.TrustedProxies); err != nil {
+		fatal("Invalid trusted proxies", err)
+	}
+	clientIPResolver, err := middleware.NewClientIPResolver(proxyConfig.TrustedProxies, proxyConfig.ClientIPHeaders)
+	if err != nil {
+		fatal("Invalid client IP configuration", err)
+	}
+	r.Use(middleware.ClientIPMiddleware(clientIPResolver))
+	r.Use(middleware.RequestLogger(logger))
+
+	slog.Info("setting up CORS")
 	r.Use(middleware.CORSMiddleware(envVars))
 
-	fmt.Printf("verify data layer availability...\n")
+	slog.Info("verify data layer availability")
 	db, err := config.CheckDataLayerAvailability(envVars)
 	if err != nil {
-		log.Fatalf("Error: Data layer is not available, exiting...: %v", err)
+		fatal("Data layer is not available, exiting", err)
 	}
 
-	fmt.Printf("connecting to the database...\n")
+	slog.Info("connecting to the database")
 	db, err = config.DataLayerConnect(envVars)
 	if err != nil {
-		log.Fatalf("Failed to connect to the database: %v", err)
+		fatal("Failed to connect to the database", err)
 	}
 
+	// Background workers (cache janitor, reminders, webhooks, event stream) stop and open task streams close when this context is cancelled
+	appCtx, stopBackground := context.WithCancel(context.Background())
+	defer stopBackground()
+
 	// Setting up cache with new architecture
-	fmt.Printf("Setting up cache...\n")
-	cache, err := config.NewCacheWithDefaults()
-	if err != nil {
-		log.Printf("Warning: Failed to setup Redis cache, falling back to memory cache: %v", err)
-		cache = config.NewMemoryCache()
-	}
+	slog.Info("Setting up cache")
+	cacheConfig := config.DefaultCacheConfig()
+	// Redis with a circuit breaker falling back to memory, it may come up after boot
+	resilientCache := config.NewResilientCache(cacheConfig)
+	go resilientCache.Start(appCtx)
+	appCache := cache.NewInstrumentedCache(resilientCache, cacheConfig.TopKeysTracked)
 
 	// Setting up error logger with new architecture
-	fmt.Printf("Setting up error logger...\n")
-	errorLogger, err := config.NewErrorLogger("redis")
-	if err != nil {
-		log.Printf("Warning: Failed to setup Redis error logger, falling back to memory logger: %v", err)
-		errorLogger = config.NewErrorLoggerWithDefaults()
-	}
-
-	// Setting up rate limiter (still using Redis for now)
-	fmt.Printf("setting up the rate limiter...\n")
+	slog.Info("Setting up error logger")
+	// Sinks from ERROR_LOG_SINKS, Redis falls back to memory when it is unavailable.
+	// Errors are written by a bounded pool of workers, drained on shutdown.
+	errorLogConfig := config.DefaultErrorLogConfig()
+	errorLogger := config.NewAsyncErrorLogger(config.SetupErrorLogger(errorLogConfig), errorLogConfig)
+
+	// Setting up rate limiter, requests are counted in memory while Redis is unavailable
+	slog.Info("setting up the rate limiter")
+	// The client is kept when Redis is down at boot, the limiter switches to it once it comes up
 	redisClient, err := config.ErrorLogConnect()
 	if err != nil {
-		log.Printf("Warning: Failed to connect to Redis for rate limiter, rate limiting will be disabled: %v", err)
-		// TODO: Implement in-memory rate limiter fallback
+		slog.Warn("Redis is unavailable, counting requests in memory until it comes up", "error", err)
+	}
+	rateLimiterConfig := config.DefaultRateLimiterConfig()
+	rateLimiter := config.SetupRateLimiter(redisClient, rateLimiterConfig)
+	go rateLimiter.Start(appCtx)
+	go config.WatchRateLimitPolicy(appCtx, rateLimiter, rateLimiterConfig)
+
+	// Prometheus metrics on their own listener, only with a token to require
+	metricsConfig := config.DefaultMetricsConfig()
+	var appMetrics *metrics.Metrics
+	if metricsConfig.Token != "" {
+		appMetrics = metrics.New()
 	} else {
-		rateLimiter := config.SetupRateLimiter(redisClient, 100, time.Minute)
-		r.Use(middleware.RateLimiter(rateLimiter))
+		slog.Info("METRICS_TOKEN is not set, metrics are disabled")
 	}
 
-	fmt.Printf("Setting up routes... \n")
-	routes.SetupRouter(r, db, cache, errorLogger)
+	slog.Info("Setting up routes")
+	routes.SetupRouter(appCtx, r, db, appCache, errorLogger, redisClient, rateLimiter, appMetrics)
 
     // Server configuration
     server := &http.Server{
@@ -81,25 +115,53 @@ func main() {
 
     // Graceful shutdown
     go func() {
-        fmt.Printf("GoToDo API is running on port %d\n", envVars.AppPort)
+        slog.Info("GoToDo API is running", "port", envVars.AppPort)
         if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-            log.Fatalf("Failed to start server: %v", err)
+            fatal("Failed to start server", err)
         }
     }()
 
+    var metricsServer *http.Server
+    if appMetrics != nil {
+        metricsServer = routes.SetupMetricsServer(appMetrics, metricsConfig)
+        go func() {
+            slog.Info("Metrics are served", "addr", metricsConfig.Addr, "path", metricsConfig.Path)
+            if err := metricsServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+                fatal("Failed to start the metrics server", err)
+            }
+        }()
+    }
+
     // Wait for interrupt signal
     quit := make(chan os.Signal, 1)
     signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
     <-quit
 
-    // Graceful shutdown with timeout
+    // Graceful shutdown with timeout, shared by the servers and the error log
+    // so the process exits within it
     ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
     defer cancel()
 
-    fmt.Println("Shutting down server...")
+    slog.Info("Shutting down server")
+    stopBackground()
     if err := server.Shutdown(ctx); err != nil {
-        log.Fatalf("Server forced to shutdown: %v", err)
+        slog.Error("Server forced to shutdown", "error", err)
+    }
+    if metricsServer != nil {
+        if err := metricsServer.Shutdown(ctx); err != nil {
+            slog.Error("Metrics server forced to shutdown", "error", err)
+        }
     }
+    // The error log is drained with what is left of ctx
+    if err := errorLogger.Shutdown(ctx); err != nil {
+        slog.Error("Failed to drain the error logger", "error", err)
+    }
+
+    slog.Info("Server exiting")
+}
 
-    fmt.Println("Server exiting")
+// fatal logs why the API can't go on and exits
+func fatal(msg string, err error) {
+    slog.Error(msg, "error", err)
+    os.Exit(1)
 }
\ No newline at end of file
@@ -2,33 +2,98 @@ package cache
 
 import (
 	"fmt"
+	"strconv"
 
 	"github.com/hftamayo/gotodo/pkg/cache/keys"
 )
 
+// SchemaVersion is part of every task key, bump it whenever the cached shape
+// of a task or a list changes so entries written by older builds are ignored
+const SchemaVersion = 1
+
 // TaskKeyGenerator provides task-specific cache key generation
 type TaskKeyGenerator struct {
     generator *keys.Generator
 }
 
+// TaskKeys are the cache keys holding or depending on a task
+type TaskKeys struct {
+    Entry       string   `json:"entry"`       // the cached task
+    Tag         string   `json:"tag"`         // tag of every entry holding the task
+    Generations []string `json:"generations"` // namespaces of the lists that may contain it
+}
+
 // NewTaskKeyGenerator creates a new task key generator
 func NewTaskKeyGenerator() *TaskKeyGenerator {
     return &TaskKeyGenerator{
-        generator: keys.NewGenerator("tasks"),
+        generator: keys.NewVersionedGenerator("tasks", SchemaVersion),
     }
 }
 
 // ForTask generates a key for a single task
 func (t *TaskKeyGenerator) ForTask(id int) string {
-    return t.generator.Build("id", fmt.Sprintf("%d", id))
+    return t.generator.Build("id", strconv.Itoa(id))
+}
+
+// ForTaskTag generates the tag of the entries holding a task
+func (t *TaskKeyGenerator) ForTaskTag(id int) string {
+    return t.generator.Build("tag", strconv.Itoa(id))
+}
+
+// ForCursorList generates a key for cursor-based pagination, owner 0 is the
+// list shared by every owner
+func (t *TaskKeyGenerator) ForCursorList(owner uint, cursor string, limit int, order string) string {
+    return t.generator.Build(scope(owner), "cursor", t.generator.Hash(map[string]string{
+        "cursor": cursor,
+        "limit":  strconv.Itoa(limit),
+        "order":  order,
+    }))
+}
+
+// ForPageList generates a key for page-based pagination, owner 0 is the list
+// shared by every owner
+func (t *TaskKeyGenerator) ForPageList(owner uint, page int, limit int, order string) string {
+    return t.generator.Build(scope(owner), "page", strconv.Itoa(page), t.generator.Hash(map[string]string{
+        "limit": strconv.Itoa(limit),
+        "order": order,
+    }))
+}
+
+// ListGeneration is the generation namespace of every task list
+func (t *TaskKeyGenerator) ListGeneration() string {
+    return t.generator.Build("lists")
 }
 
-// ForCursorList generates a key for cursor-based pagination
-func (t *TaskKeyGenerator) ForCursorList(cursor string, limit int, order string) string {
-    return t.generator.Build("cursor", cursor, "limit", fmt.Sprintf("%d", limit), "order", order)
+// PageGeneration is the generation namespace of the page lists
+func (t *TaskKeyGenerator) PageGeneration() string {
+    return t.generator.Build("pages")
 }
 
-// ForPageList generates a key for page-based pagination
-func (t *TaskKeyGenerator) ForPageList(page int, limit int, order string) string {
-    return t.generator.Build("page", fmt.Sprintf("%d", page), "limit", fmt.Sprintf("%d", limit), "order", order)
-}
\ No newline at end of file
+// UserGeneration is the generation namespace of the lists of an owner
+func (t *TaskKeyGenerator) UserGeneration(owner uint) string {
+    return t.generator.Build(scope(owner), "lists")
+}
+
+// ListGenerations returns the namespaces a list of owner depends on
+func (t *TaskKeyGenerator) ListGenerations(owner uint) []string {
+    if owner == 0 {
+        return []string{t.ListGeneration()}
+    }
+    return []string{t.ListGeneration(), t.UserGeneration(owner)}
+}
+
+// ForTaskKeys enumerates the keys holding or depending on a task of owner
+func (t *TaskKeyGenerator) ForTaskKeys(id int, owner uint) TaskKeys {
+    return TaskKeys{
+        Entry:       t.ForTask(id),
+        Tag:         t.ForTaskTag(id),
+        Generations: append(t.ListGenerations(owner), t.PageGeneration()),
+    }
+}
+
+func scope(owner uint) string {
+    if owner == 0 {
+        return "all"
+    }
+    return fmt.Sprintf("user_%d", owner)
+}
@@ -0,0 +1,47 @@
+// Package cache defines the cache port used by the services and the backends
+// that implement it. Every backend stores values as JSON, so a value read back
+// from Redis or from memory decodes the same way into the destination.
+package cache
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrCacheMiss is returned by Get when the key is absent or expired
+var ErrCacheMiss = errors.New("cache: key not found")
+
+// Cache defines the contract every cache backend implements
+type Cache interface {
+	// Get retrieves a value and unmarshals it into dest, a missing or
+	// expired key returns ErrCacheMiss
+	Get(key string, dest interface{}) error
+
+	// Set stores a value, a zero ttl keeps it until it is deleted
+	Set(key string, value interface{}, ttl time.Duration) error
+
+	// SetWithTags stores a value and associates it with the given tags
+	SetWithTags(key string, value interface{}, ttl time.Duration, tags ...string) error
+
+	// Delete removes a key, deleting a missing key is not an error
+	Delete(key string) error
+
+	// DeletePattern removes the keys matching a glob pattern (e.g. "tasks_*")
+	DeletePattern(pattern string) error
+
+	// InvalidateByTags removes every key associated with any of the tags
+	InvalidateByTags(tags ...string) error
+
+	// Exists reports whether a key is present and not expired
+	Exists(key string) bool
+
+	// Clear removes every key owned by the cache (use with caution)
+	Clear() error
+}
+
+// TagInvalidator is implemented by backends that can report the keys removed
+// by a tag invalidation, the layered cache uses it to evict exactly those
+// keys from every node's local tier
+type TagInvalidator interface {
+	InvalidateTags(tags ...string) ([]string, error)
+}
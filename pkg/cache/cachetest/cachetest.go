@@ -0,0 +1,300 @@
+// Package cachetest implements a conformance check for cache backends. Every
+// backend is expected to pass it, which keeps the memory fallback behaving
+// like Redis. It follows testing/fstest and reports failures as an error:
+//
+//	func TestMemoryCache(t *testing.T) {
+//		if err := cachetest.TestCache(func() cache.Cache { return cache.NewMemoryCache() }); err != nil {
+//			t.Fatal(err)
+//		}
+//	}
+package cachetest
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/hftamayo/gotodo/pkg/cache"
+)
+
+// shortTTL is long enough for Redis to store the key and short enough to keep the check fast
+const shortTTL = 150 * time.Millisecond
+
+type record struct {
+	ID      uint       `json:"id"`
+	Title   string     `json:"title"`
+	Done    bool       `json:"done"`
+	Tags    []string   `json:"tags"`
+	DueDate *time.Time `json:"dueDate,omitempty"`
+}
+
+type check struct {
+	name string
+	run  func(c cache.Cache) error
+}
+
+var checks = []check{
+	{"get missing key", checkMiss},
+	{"set and get round trip", checkRoundTrip},
+	{"overwrite", checkOverwrite},
+	{"delete", checkDelete},
+	{"ttl expiry", checkExpiry},
+	{"delete pattern", checkDeletePattern},
+	{"invalidate by tags", checkTags},
+	{"tag outlives its members", checkTagLifetime},
+	{"tag set expiry", checkTagExpiry},
+	{"clear", checkClear},
+}
+
+// TestCache runs every check against a fresh cache from newCache and returns
+// the failures joined in a single error, or nil when the backend conforms
+func TestCache(newCache func() cache.Cache) error {
+	var errs []error
+	for _, check := range checks {
+		c := newCache()
+		if err := c.Clear(); err != nil {
+			errs = append(errs, fmt.Errorf("%s: clear before check: %w", check.name, err))
+			continue
+		}
+		if err := check.run(c); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", check.name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func checkMiss(c cache.Cache) error {
+	var dest record
+	if err := c.Get("missing", &dest); !errors.Is(err, cache.ErrCacheMiss) {
+		return fmt.Errorf("Get returned %v, want ErrCacheMiss", err)
+	}
+	if c.Exists("missing") {
+		return errors.New("Exists reported a missing key")
+	}
+	if err := c.Delete("missing"); err != nil {
+		return fmt.Errorf("Delete of a missing key returned %v", err)
+	}
+	return nil
+}
+
+func checkRoundTrip(c cache.Cache) error {
+	due := time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC)
+	want := record{ID: 7, Title: "write spec", Done: true, Tags: []string{"a", "b"}, DueDate: &due}
+	if err := c.Set("record", want, time.Minute); err != nil {
+		return fmt.Errorf("Set: %w", err)
+	}
+
+	var got record
+	if err := c.Get("record", &got); err != nil {
+		return fmt.Errorf("Get: %w", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		return fmt.Errorf("Get returned %+v, want %+v", got, want)
+	}
+
+	// Pointer destinations and scalar values must decode as well
+	var ptr *record
+	if err := c.Get("record", &ptr); err != nil || ptr == nil || ptr.ID != want.ID {
+		return fmt.Errorf("Get into a pointer returned %+v, %v", ptr, err)
+	}
+	if err := c.Set("count", 42, 0); err != nil {
+		return fmt.Errorf("Set without ttl: %w", err)
+	}
+	var count int64
+	if err := c.Get("count", &count); err != nil || count != 42 {
+		return fmt.Errorf("Get of a scalar returned %d, %v", count, err)
+	}
+	if !c.Exists("record") || !c.Exists("count") {
+		return errors.New("Exists did not report stored keys")
+	}
+	return nil
+}
+
+func checkOverwrite(c cache.Cache) error {
+	if err := c.Set("key", "first", time.Minute); err != nil {
+		return fmt.Errorf("Set: %w", err)
+	}
+	if err := c.Set("key", "second", time.Minute); err != nil {
+		return fmt.Errorf("Set: %w", err)
+	}
+
+	var got string
+	if err := c.Get("key", &got); err != nil || got != "second" {
+		return fmt.Errorf("Get returned %q, %v, want %q", got, err, "second")
+	}
+	return nil
+}
+
+func checkDelete(c cache.Cache) error {
+	if err := c.Set("key", "value", time.Minute); err != nil {
+		return fmt.Errorf("Set: %w", err)
+	}
+	if err := c.Delete("key"); err != nil {
+		return fmt.Errorf("Delete: %w", err)
+	}
+
+	var got string
+	if err := c.Get("key", &got); !errors.Is(err, cache.ErrCacheMiss) {
+		return fmt.Errorf("Get after Delete returned %v, want ErrCacheMiss", err)
+	}
+	return nil
+}
+
+func checkExpiry(c cache.Cache) error {
+	if err := c.Set("short", "value", shortTTL); err != nil {
+		return fmt.Errorf("Set: %w", err)
+	}
+	if err := c.Set("long", "value", time.Minute); err != nil {
+		return fmt.Errorf("Set: %w", err)
+	}
+
+	time.Sleep(2 * shortTTL)
+
+	var got string
+	if err := c.Get("short", &got); !errors.Is(err, cache.ErrCacheMiss) {
+		return fmt.Errorf("Get of an expired key returned %v, want ErrCacheMiss", err)
+	}
+	if c.Exists("short") {
+		return errors.New("Exists reported an expired key")
+	}
+	if err := c.Get("long", &got); err != nil {
+		return fmt.Errorf("Get of a live key: %w", err)
+	}
+	return nil
+}
+
+func checkDeletePattern(c cache.Cache) error {
+	for _, key := range []string{"tasks_page_1", "tasks_page_2", "task_1"} {
+		if err := c.Set(key, key, time.Minute); err != nil {
+			return fmt.Errorf("Set %s: %w", key, err)
+		}
+	}
+
+	if err := c.DeletePattern("tasks_page_*"); err != nil {
+		return fmt.Errorf("DeletePattern: %w", err)
+	}
+	if c.Exists("tasks_page_1") || c.Exists("tasks_page_2") {
+		return errors.New("keys matching the pattern survived")
+	}
+	if !c.Exists("task_1") {
+		return errors.New("a key not matching the pattern was deleted")
+	}
+	return nil
+}
+
+func checkTags(c cache.Cache) error {
+	if err := c.SetWithTags("page_1", "p1", time.Minute, "list", "page:1"); err != nil {
+		return fmt.Errorf("SetWithTags: %w", err)
+	}
+	if err := c.SetWithTags("page_2", "p2", time.Minute, "list", "page:2"); err != nil {
+		return fmt.Errorf("SetWithTags: %w", err)
+	}
+	if err := c.SetWithTags("task_1", "t1", time.Minute, "task:1"); err != nil {
+		return fmt.Errorf("SetWithTags: %w", err)
+	}
+	if err := c.Set("untagged", "u", time.Minute); err != nil {
+		return fmt.Errorf("Set: %w", err)
+	}
+
+	if err := c.InvalidateByTags("page:1"); err != nil {
+		return fmt.Errorf("InvalidateByTags: %w", err)
+	}
+	if c.Exists("page_1") {
+		return errors.New("a key with the invalidated tag survived")
+	}
+	if !c.Exists("page_2") || !c.Exists("task_1") || !c.Exists("untagged") {
+		return errors.New("keys without the invalidated tag were removed")
+	}
+
+	if err := c.InvalidateByTags("list", "task:1"); err != nil {
+		return fmt.Errorf("InvalidateByTags: %w", err)
+	}
+	if c.Exists("page_2") || c.Exists("task_1") {
+		return errors.New("keys with the invalidated tags survived")
+	}
+	if !c.Exists("untagged") {
+		return errors.New("an untagged key was removed")
+	}
+
+	if err := c.InvalidateByTags("unknown"); err != nil {
+		return fmt.Errorf("InvalidateByTags of an unknown tag returned %v", err)
+	}
+	return nil
+}
+
+func checkTagLifetime(c cache.Cache) error {
+	if err := c.SetWithTags("long", "l", time.Minute, "list"); err != nil {
+		return fmt.Errorf("SetWithTags: %w", err)
+	}
+	if err := c.SetWithTags("short", "s", shortTTL, "list"); err != nil {
+		return fmt.Errorf("SetWithTags: %w", err)
+	}
+	if err := c.SetWithTags("forever", "f", 0, "persistent"); err != nil {
+		return fmt.Errorf("SetWithTags: %w", err)
+	}
+	if err := c.SetWithTags("brief", "b", shortTTL, "persistent"); err != nil {
+		return fmt.Errorf("SetWithTags: %w", err)
+	}
+
+	// A shorter-lived member must not shorten the tag, or "long" escapes invalidation
+	time.Sleep(2 * shortTTL)
+
+	if err := c.InvalidateByTags("list", "persistent"); err != nil {
+		return fmt.Errorf("InvalidateByTags: %w", err)
+	}
+	if c.Exists("long") {
+		return errors.New("a member outlived its tag set and escaped invalidation")
+	}
+	if c.Exists("forever") {
+		return errors.New("a member without ttl escaped invalidation")
+	}
+	return nil
+}
+
+func checkTagExpiry(c cache.Cache) error {
+	if err := c.SetWithTags("key", "v1", shortTTL, "tag"); err != nil {
+		return fmt.Errorf("SetWithTags: %w", err)
+	}
+
+	time.Sleep(2 * shortTTL)
+
+	// The tag set expired with its only member, so it must not reach the new value
+	if err := c.Set("key", "v2", time.Minute); err != nil {
+		return fmt.Errorf("Set: %w", err)
+	}
+	if err := c.InvalidateByTags("tag"); err != nil {
+		return fmt.Errorf("InvalidateByTags: %w", err)
+	}
+	if !c.Exists("key") {
+		return errors.New("an expired tag set invalidated a key")
+	}
+	return nil
+}
+
+func checkClear(c cache.Cache) error {
+	if err := c.SetWithTags("a", 1, time.Minute, "tag"); err != nil {
+		return fmt.Errorf("SetWithTags: %w", err)
+	}
+	if err := c.Set("b", 2, 0); err != nil {
+		return fmt.Errorf("Set: %w", err)
+	}
+	if err := c.Clear(); err != nil {
+		return fmt.Errorf("Clear: %w", err)
+	}
+	if c.Exists("a") || c.Exists("b") {
+		return errors.New("keys survived Clear")
+	}
+
+	// A cleared tag must not resurrect the association with a new value
+	if err := c.Set("a", 1, time.Minute); err != nil {
+		return fmt.Errorf("Set: %w", err)
+	}
+	if err := c.InvalidateByTags("tag"); err != nil {
+		return fmt.Errorf("InvalidateByTags: %w", err)
+	}
+	if !c.Exists("a") {
+		return errors.New("a tag cleared by Clear still invalidated a new key")
+	}
+	return nil
+}
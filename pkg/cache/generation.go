@@ -0,0 +1,76 @@
+package cache
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// generationKeyPrefix namespaces the generation keys inside the cache
+const generationKeyPrefix = "gen:"
+
+// Generations invalidates groups of keys in O(1). Keys built with Versioned
+// embed the current generation of their namespaces, bumping a namespace
+// orphans every key built with the previous generation and they are left to
+// expire. Generations are stored in the cache itself, so the L1 broadcast
+// and the fallback replay apply to them like to any other key.
+type Generations struct {
+	cache Cache
+}
+
+// NewGenerations creates the generation store of c
+func NewGenerations(c Cache) *Generations {
+	return &Generations{cache: c}
+}
+
+// Current returns the generation of namespace, starting a new one when the
+// namespace was never used or has been bumped
+func (g *Generations) Current(namespace string) (string, error) {
+	key := generationKeyPrefix + namespace
+
+	var generation string
+	err := g.cache.Get(key, &generation)
+	if err == nil {
+		return generation, nil
+	}
+	if !errors.Is(err, ErrCacheMiss) {
+		return "", err
+	}
+
+	// Two readers racing here both start a generation and the last write
+	// wins, the keys built with the other one are merely missed
+	generation = strconv.FormatInt(time.Now().UnixNano(), 36)
+	if err := g.cache.Set(key, generation, 0); err != nil {
+		return "", err
+	}
+	return generation, nil
+}
+
+// Bump starts a new generation for every namespace. It deletes the current
+// generation rather than incrementing it, a delete is the one write every
+// backend propagates as an invalidation.
+func (g *Generations) Bump(namespaces ...string) error {
+	var errs []error
+	for _, namespace := range namespaces {
+		if err := g.cache.Delete(generationKeyPrefix + namespace); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Versioned appends the current generation of every namespace to key
+func (g *Generations) Versioned(key string, namespaces ...string) (string, error) {
+	var b strings.Builder
+	b.WriteString(key)
+	for _, namespace := range namespaces {
+		generation, err := g.Current(namespace)
+		if err != nil {
+			return "", err
+		}
+		b.WriteString(":g")
+		b.WriteString(generation)
+	}
+	return b.String(), nil
+}
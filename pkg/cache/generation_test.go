@@ -0,0 +1,79 @@
+package cache_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hftamayo/gotodo/pkg/cache"
+)
+
+func TestGenerationsBumpOrphansKeys(t *testing.T) {
+	c := cache.NewMemoryCache()
+	generations := cache.NewGenerations(c)
+
+	oldKey, err := generations.Versioned("tasks_v1_all_page_1", "tasks", "owner:1")
+	if err != nil {
+		t.Fatalf("Versioned() error = %v", err)
+	}
+	if !strings.HasPrefix(oldKey, "tasks_v1_all_page_1:g") || strings.Count(oldKey, ":g") != 2 {
+		t.Fatalf("Versioned() = %q, want the key followed by two generations", oldKey)
+	}
+	if err := c.Set(oldKey, "page 1", 0); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+
+	// The generation is stable until it is bumped
+	if again, _ := generations.Versioned("tasks_v1_all_page_1", "tasks", "owner:1"); again != oldKey {
+		t.Fatalf("Versioned() = %q then %q without a bump", oldKey, again)
+	}
+
+	if err := generations.Bump("owner:1"); err != nil {
+		t.Fatalf("Bump() error = %v", err)
+	}
+	newKey, err := generations.Versioned("tasks_v1_all_page_1", "tasks", "owner:1")
+	if err != nil {
+		t.Fatalf("Versioned() error = %v", err)
+	}
+	if newKey == oldKey {
+		t.Fatalf("Versioned() = %q after a bump, want a new key", newKey)
+	}
+
+	var page string
+	if err := c.Get(newKey, &page); err != cache.ErrCacheMiss {
+		t.Fatalf("Get() of the new key = %q, %v, want a miss", page, err)
+	}
+}
+
+func TestGenerationsBumpIsPerNamespace(t *testing.T) {
+	generations := cache.NewGenerations(cache.NewMemoryCache())
+
+	owner1, _ := generations.Current("owner:1")
+	owner2, _ := generations.Current("owner:2")
+	if err := generations.Bump("owner:1"); err != nil {
+		t.Fatalf("Bump() error = %v", err)
+	}
+
+	if current, _ := generations.Current("owner:1"); current == owner1 {
+		t.Fatal("owner:1 kept its generation after a bump")
+	}
+	if current, _ := generations.Current("owner:2"); current != owner2 {
+		t.Fatalf("owner:2 generation = %q after bumping owner:1, want %q", current, owner2)
+	}
+}
+
+func TestGenerationsBumpSeveralNamespaces(t *testing.T) {
+	generations := cache.NewGenerations(cache.NewMemoryCache())
+
+	before, _ := generations.Versioned("key", "tasks", "pages")
+	if err := generations.Bump("tasks", "pages", "never-used"); err != nil {
+		t.Fatalf("Bump() error = %v", err)
+	}
+	after, _ := generations.Versioned("key", "tasks", "pages")
+
+	beforeParts, afterParts := strings.Split(before, ":g"), strings.Split(after, ":g")
+	for i := 1; i < len(beforeParts); i++ {
+		if beforeParts[i] == afterParts[i] {
+			t.Fatalf("generation %d unchanged after the bump: %q", i, before)
+		}
+	}
+}
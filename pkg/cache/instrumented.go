@@ -0,0 +1,360 @@
+package cache
+
+import (
+	"container/heap"
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Cache operations reported by InstrumentedCache
+const (
+	OpGet              = "get"
+	OpSet              = "set"
+	OpDelete           = "delete"
+	OpDeletePattern    = "delete_pattern"
+	OpInvalidateByTags = "invalidate_by_tags"
+	OpExists           = "exists"
+	OpClear            = "clear"
+)
+
+var operations = []string{OpGet, OpSet, OpDelete, OpDeletePattern, OpInvalidateByTags, OpExists, OpClear}
+
+// OperationStats are the counters of one cache operation, hits and misses
+// only apply to reads
+type OperationStats struct {
+	Calls        uint64  `json:"calls"`
+	Hits         uint64  `json:"hits,omitempty"`
+	Misses       uint64  `json:"misses,omitempty"`
+	Errors       uint64  `json:"errors"`
+	HitRatio     float64 `json:"hitRatio,omitempty"`
+	AvgLatencyMs float64 `json:"avgLatencyMs"`
+	MaxLatencyMs float64 `json:"maxLatencyMs"`
+}
+
+// KeyStats counts the reads of a key, older reads decay as new keys show up
+type KeyStats struct {
+	Key   string `json:"key"`
+	Reads uint64 `json:"reads"`
+	Hits  uint64 `json:"hits"`
+}
+
+// Stats is a snapshot of an InstrumentedCache and the backends it wraps
+type Stats struct {
+	Operations map[string]OperationStats `json:"operations"`
+	TopKeys    []KeyStats                `json:"topKeys"`
+	Breaker    string                    `json:"breaker,omitempty"`
+	Memory     *MemoryStats              `json:"memory,omitempty"`
+	L1         *MemoryStats              `json:"l1,omitempty"`
+	Fallback   *MemoryStats              `json:"fallback,omitempty"`
+}
+
+type operationCounters struct {
+	calls   atomic.Uint64
+	hits    atomic.Uint64
+	misses  atomic.Uint64
+	errors  atomic.Uint64
+	totalNs atomic.Uint64
+	maxNs   atomic.Uint64
+}
+
+// InstrumentedCache counts calls, hits, misses, errors and latency of every
+// operation of the cache it wraps, and tracks the most read keys
+type InstrumentedCache struct {
+	inner    Cache
+	counters map[string]*operationCounters
+	keys     *keyTracker
+}
+
+var _ Cache = (*InstrumentedCache)(nil)
+
+// NewInstrumentedCache wraps inner, at most trackedKeys keys are tracked for TopKeys
+func NewInstrumentedCache(inner Cache, trackedKeys int) *InstrumentedCache {
+	if trackedKeys <= 0 {
+		trackedKeys = 1000
+	}
+
+	counters := make(map[string]*operationCounters, len(operations))
+	for _, op := range operations {
+		counters[op] = &operationCounters{}
+	}
+	return &InstrumentedCache{
+		inner:    inner,
+		counters: counters,
+		keys:     newKeyTracker(trackedKeys),
+	}
+}
+
+// Get reads through the wrapped cache
+func (c *InstrumentedCache) Get(key string, dest interface{}) error {
+	start := time.Now()
+	err := c.inner.Get(key, dest)
+
+	hit := err == nil
+	c.record(OpGet, start, err, &hit)
+	// Generations are read before every list key, they would crowd the top keys
+	if !strings.HasPrefix(key, generationKeyPrefix) {
+		c.keys.record(key, hit)
+	}
+	return err
+}
+
+// Set writes through the wrapped cache
+func (c *InstrumentedCache) Set(key string, value interface{}, ttl time.Duration) error {
+	start := time.Now()
+	err := c.inner.Set(key, value, ttl)
+	c.record(OpSet, start, err, nil)
+	return err
+}
+
+// SetWithTags writes through the wrapped cache
+func (c *InstrumentedCache) SetWithTags(key string, value interface{}, ttl time.Duration, tags ...string) error {
+	start := time.Now()
+	err := c.inner.SetWithTags(key, value, ttl, tags...)
+	c.record(OpSet, start, err, nil)
+	return err
+}
+
+// Delete deletes through the wrapped cache
+func (c *InstrumentedCache) Delete(key string) error {
+	start := time.Now()
+	err := c.inner.Delete(key)
+	c.record(OpDelete, start, err, nil)
+	return err
+}
+
+// DeletePattern deletes through the wrapped cache
+func (c *InstrumentedCache) DeletePattern(pattern string) error {
+	start := time.Now()
+	err := c.inner.DeletePattern(pattern)
+	c.record(OpDeletePattern, start, err, nil)
+	return err
+}
+
+// InvalidateByTags invalidates through the wrapped cache
+func (c *InstrumentedCache) InvalidateByTags(tags ...string) error {
+	start := time.Now()
+	err := c.inner.InvalidateByTags(tags...)
+	c.record(OpInvalidateByTags, start, err, nil)
+	return err
+}
+
+// Exists checks the wrapped cache
+func (c *InstrumentedCache) Exists(key string) bool {
+	start := time.Now()
+	exists := c.inner.Exists(key)
+	c.record(OpExists, start, nil, &exists)
+	return exists
+}
+
+// Clear empties the wrapped cache
+func (c *InstrumentedCache) Clear() error {
+	start := time.Now()
+	err := c.inner.Clear()
+	c.record(OpClear, start, err, nil)
+	return err
+}
+
+// OnRecover registers fn on the wrapped cache when it can recover from a fallback
+func (c *InstrumentedCache) OnRecover(fn func()) {
+	if recoverable, ok := c.inner.(interface{ OnRecover(fn func()) }); ok {
+		recoverable.OnRecover(fn)
+	}
+}
+
+// Stats returns the operation counters, the topN most read keys and the
+// state of the wrapped backends
+func (c *InstrumentedCache) Stats(topN int) Stats {
+	stats := Stats{
+		Operations: make(map[string]OperationStats, len(c.counters)),
+		TopKeys:    c.keys.top(topN),
+	}
+	for op, counters := range c.counters {
+		stats.Operations[op] = counters.snapshot()
+	}
+	describeBackend(c.inner, &stats)
+	return stats
+}
+
+// record updates the counters of op, hit is nil for operations that don't read
+func (c *InstrumentedCache) record(op string, start time.Time, err error, hit *bool) {
+	counters := c.counters[op]
+	elapsed := uint64(time.Since(start))
+
+	counters.calls.Add(1)
+	counters.totalNs.Add(elapsed)
+	for {
+		max := counters.maxNs.Load()
+		if elapsed <= max || counters.maxNs.CompareAndSwap(max, elapsed) {
+			break
+		}
+	}
+
+	switch {
+	case err != nil && !errors.Is(err, ErrCacheMiss):
+		counters.errors.Add(1)
+	case hit == nil:
+	case *hit:
+		counters.hits.Add(1)
+	default:
+		counters.misses.Add(1)
+	}
+}
+
+func (o *operationCounters) snapshot() OperationStats {
+	stats := OperationStats{
+		Calls:        o.calls.Load(),
+		Hits:         o.hits.Load(),
+		Misses:       o.misses.Load(),
+		Errors:       o.errors.Load(),
+		MaxLatencyMs: float64(o.maxNs.Load()) / float64(time.Millisecond),
+	}
+	if stats.Calls > 0 {
+		stats.AvgLatencyMs = float64(o.totalNs.Load()) / float64(stats.Calls) / float64(time.Millisecond)
+	}
+	if reads := stats.Hits + stats.Misses; reads > 0 {
+		stats.HitRatio = float64(stats.Hits) / float64(reads)
+	}
+	return stats
+}
+
+// describeBackend adds the state of the known backends to stats
+func describeBackend(c Cache, stats *Stats) {
+	switch backend := c.(type) {
+	case *ResilientCache:
+		stats.Breaker = backend.State().String()
+		fallback := backend.fallback.Stats()
+		stats.Fallback = &fallback
+		describeBackend(backend.primary, stats)
+	case *LayeredCache:
+		l1 := backend.l1.Stats()
+		stats.L1 = &l1
+	case *MemoryCache:
+		memory := backend.Stats()
+		stats.Memory = &memory
+	}
+}
+
+// keyTracker counts reads per key for a bounded number of keys. When full the
+// coldest key makes room for the new one, and every max admissions all counts
+// are halved so keys that stop being read fade out. The keys are kept in a
+// min-heap on reads, so finding the coldest one doesn't scan them all.
+type keyTracker struct {
+	mu       sync.Mutex
+	max      int
+	admitted int
+	counts   map[string]*trackedKey
+	heap     keyHeap
+}
+
+type trackedKey struct {
+	KeyStats
+	index int // position in the heap
+}
+
+// keyHeap is a container/heap of the tracked keys, the least read first
+type keyHeap []*trackedKey
+
+func (h keyHeap) Len() int           { return len(h) }
+func (h keyHeap) Less(i, j int) bool { return h[i].Reads < h[j].Reads }
+func (h keyHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+
+func (h *keyHeap) Push(x interface{}) {
+	key := x.(*trackedKey)
+	key.index = len(*h)
+	*h = append(*h, key)
+}
+
+func (h *keyHeap) Pop() interface{} {
+	old := *h
+	key := old[len(old)-1]
+	old[len(old)-1] = nil
+	*h = old[:len(old)-1]
+	return key
+}
+
+func newKeyTracker(max int) *keyTracker {
+	return &keyTracker{max: max, counts: make(map[string]*trackedKey)}
+}
+
+func (t *keyTracker) record(key string, hit bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	stats, ok := t.counts[key]
+	if !ok {
+		t.admit()
+		stats = &trackedKey{KeyStats: KeyStats{Key: key}}
+		t.counts[key] = stats
+		heap.Push(&t.heap, stats)
+	}
+	stats.Reads++
+	if hit {
+		stats.Hits++
+	}
+	heap.Fix(&t.heap, stats.index)
+}
+
+// admit makes room for a new key, the caller holds the mutex
+func (t *keyTracker) admit() {
+	if t.admitted++; t.admitted >= t.max {
+		t.admitted = 0
+		t.decay()
+	}
+	if len(t.counts) < t.max {
+		return
+	}
+
+	coldest := heap.Pop(&t.heap).(*trackedKey)
+	delete(t.counts, coldest.Key)
+}
+
+// decay halves every count and drops the keys left without reads, halving
+// keeps the heap order but the heap is rebuilt without the dropped keys
+func (t *keyTracker) decay() {
+	kept := t.heap[:0]
+	for _, stats := range t.heap {
+		stats.Reads /= 2
+		stats.Hits /= 2
+		if stats.Reads == 0 {
+			delete(t.counts, stats.Key)
+			continue
+		}
+		kept = append(kept, stats)
+	}
+	for i := len(kept); i < len(t.heap); i++ {
+		t.heap[i] = nil
+	}
+	t.heap = kept
+	for i, stats := range t.heap {
+		stats.index = i
+	}
+	heap.Init(&t.heap)
+}
+
+func (t *keyTracker) top(n int) []KeyStats {
+	t.mu.Lock()
+	keys := make([]KeyStats, 0, len(t.counts))
+	for _, stats := range t.counts {
+		keys = append(keys, stats.KeyStats)
+	}
+	t.mu.Unlock()
+
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].Reads != keys[j].Reads {
+			return keys[i].Reads > keys[j].Reads
+		}
+		return keys[i].Key < keys[j].Key
+	})
+	if n >= 0 && len(keys) > n {
+		keys = keys[:n]
+	}
+	return keys
+}
@@ -1,13 +1,17 @@
 package keys
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"sort"
+	"strconv"
 	"strings"
 )
 
 // Generator provides a standard way to create cache keys
 type Generator struct {
-    Prefix string
+    Prefix  string
+    Version int // schema version, 0 leaves it out of the keys
 }
 
 // NewGenerator creates a cache key generator with optional prefix
@@ -17,32 +21,61 @@ func NewGenerator(prefix string) *Generator {
     }
 }
 
+// NewVersionedGenerator creates a generator whose keys carry a schema version,
+// bumping it orphans every key built with the previous one
+func NewVersionedGenerator(prefix string, version int) *Generator {
+    return &Generator{
+        Prefix:  prefix,
+        Version: version,
+    }
+}
+
 // Build creates a cache key with parts joined by underscore
 func (g *Generator) Build(parts ...string) string {
-    if g.Prefix != "" {
-        return g.Prefix + "_" + strings.Join(parts, "_")
-    }
-    return strings.Join(parts, "_")
+    return strings.Join(append(g.base(), parts...), "_")
 }
 
 // ForList builds a key for list operations
 func (g *Generator) ForList(params map[string]string) string {
-    // Sort the param keys for consistent ordering
-    var keys []string
-    for k := range params {
-        keys = append(keys, k)
+    parts := append(g.base(), "list")
+    for _, k := range sortedKeys(params) {
+        parts = append(parts, k+"_"+params[k])
     }
-    sort.Strings(keys)
-    
+
+    return strings.Join(parts, "_")
+}
+
+// Hash returns a short stable digest of params, for filters too long or too
+// free-form to appear in a key
+func (g *Generator) Hash(params map[string]string) string {
+    var b strings.Builder
+    for _, k := range sortedKeys(params) {
+        b.WriteString(k)
+        b.WriteByte('=')
+        b.WriteString(params[k])
+        b.WriteByte('&')
+    }
+    sum := sha256.Sum256([]byte(b.String()))
+    return hex.EncodeToString(sum[:8])
+}
+
+func (g *Generator) base() []string {
     var parts []string
     if g.Prefix != "" {
         parts = append(parts, g.Prefix)
     }
-    parts = append(parts, "list")
-    
-    for _, k := range keys {
-        parts = append(parts, k+"_"+params[k])
+    if g.Version > 0 {
+        parts = append(parts, "v"+strconv.Itoa(g.Version))
     }
-    
-    return strings.Join(parts, "_")
-}
\ No newline at end of file
+    return parts
+}
+
+// sortedKeys returns the param keys in order, for consistent keys
+func sortedKeys(params map[string]string) []string {
+    keys := make([]string, 0, len(params))
+    for k := range params {
+        keys = append(keys, k)
+    }
+    sort.Strings(keys)
+    return keys
+}
@@ -0,0 +1,248 @@
+package cache
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/hftamayo/gotodo/pkg/utils"
+	"github.com/redis/go-redis/v9"
+)
+
+// LayeredOptions configures the local tier of a LayeredCache
+type LayeredOptions struct {
+	// L1TTL caps how long a value is served locally, it bounds the staleness
+	// window when an invalidation message is lost
+	L1TTL time.Duration
+
+	// Channel is the Redis pub/sub channel shared by every replica
+	Channel string
+}
+
+// invalidation is broadcast to the other replicas after every write
+type invalidation struct {
+	Origin   string   `json:"origin"`
+	Keys     []string `json:"keys,omitempty"`
+	Patterns []string `json:"patterns,omitempty"`
+	Clear    bool     `json:"clear,omitempty"`
+}
+
+// LayeredCache serves hot keys from an in-process L1 in front of a shared L2
+// (Redis). Every write goes to L2 first and then evicts the key from the L1
+// of every replica through Redis pub/sub.
+type LayeredCache struct {
+	l1      *MemoryCache
+	l2      Cache
+	client  utils.RedisClientInterface
+	options LayeredOptions
+	origin  string
+
+	// epoch changes on every invalidation, an L1 fill that started before it
+	// changed is dropped so a stale L2 read cannot outlive the invalidation
+	epoch  atomic.Uint64
+	fillMu sync.Mutex
+}
+
+var _ Cache = (*LayeredCache)(nil)
+
+// NewLayeredCache creates a two-tier cache, client carries the invalidation messages
+func NewLayeredCache(l1 *MemoryCache, l2 Cache, client utils.RedisClientInterface, options LayeredOptions) *LayeredCache {
+	if options.L1TTL <= 0 {
+		options.L1TTL = 30 * time.Second
+	}
+	if options.Channel == "" {
+		options.Channel = "cache:invalidate"
+	}
+
+	return &LayeredCache{
+		l1:      l1,
+		l2:      l2,
+		client:  client,
+		options: options,
+		origin:  newOrigin(),
+	}
+}
+
+// L1 returns the local tier
+func (c *LayeredCache) L1() *MemoryCache {
+	return c.l1
+}
+
+// Get serves the key from L1, falling back to L2 and keeping a local copy
+func (c *LayeredCache) Get(key string, dest interface{}) error {
+	if err := c.l1.Get(key, dest); err == nil {
+		return nil
+	}
+
+	epoch := c.epoch.Load()
+	if err := c.l2.Get(key, dest); err != nil {
+		return err
+	}
+
+	c.fillMu.Lock()
+	if c.epoch.Load() == epoch {
+		c.l1.Set(key, dest, c.options.L1TTL)
+	}
+	c.fillMu.Unlock()
+	return nil
+}
+
+// Set writes the value to L2 and evicts stale copies from every L1
+func (c *LayeredCache) Set(key string, value interface{}, ttl time.Duration) error {
+	return c.SetWithTags(key, value, ttl)
+}
+
+// SetWithTags writes the value to L2 and evicts stale copies from every L1,
+// the local copy is refreshed on the next read
+func (c *LayeredCache) SetWithTags(key string, value interface{}, ttl time.Duration, tags ...string) error {
+	if err := c.l2.SetWithTags(key, value, ttl, tags...); err != nil {
+		return err
+	}
+	c.invalidate(invalidation{Keys: []string{key}})
+	return nil
+}
+
+// Delete removes the key from both tiers of every replica
+func (c *LayeredCache) Delete(key string) error {
+	if err := c.l2.Delete(key); err != nil {
+		return err
+	}
+	c.invalidate(invalidation{Keys: []string{key}})
+	return nil
+}
+
+// DeletePattern removes the matching keys from both tiers of every replica
+func (c *LayeredCache) DeletePattern(pattern string) error {
+	if err := c.l2.DeletePattern(pattern); err != nil {
+		return err
+	}
+	c.invalidate(invalidation{Patterns: []string{pattern}})
+	return nil
+}
+
+// InvalidateByTags removes the tagged keys from L2 and the L1 of every replica.
+// Replicas don't know the tags of the values they copied from L2, so the
+// removed keys are broadcast, or the whole L1 when L2 can't report them.
+func (c *LayeredCache) InvalidateByTags(tags ...string) error {
+	invalidator, ok := c.l2.(TagInvalidator)
+	if !ok {
+		if err := c.l2.InvalidateByTags(tags...); err != nil {
+			return err
+		}
+		c.invalidate(invalidation{Clear: true})
+		return nil
+	}
+
+	keys, err := invalidator.InvalidateTags(tags...)
+	if err != nil {
+		return err
+	}
+	if len(keys) > 0 {
+		c.invalidate(invalidation{Keys: keys})
+	}
+	return nil
+}
+
+// Exists checks L1 first and then L2
+func (c *LayeredCache) Exists(key string) bool {
+	return c.l1.Exists(key) || c.l2.Exists(key)
+}
+
+// Clear empties L2 and the L1 of every replica
+func (c *LayeredCache) Clear() error {
+	if err := c.l2.Clear(); err != nil {
+		return err
+	}
+	c.invalidate(invalidation{Clear: true})
+	return nil
+}
+
+// Start applies the invalidations published by other replicas and sweeps the
+// local tier until ctx is cancelled
+func (c *LayeredCache) Start(ctx context.Context) {
+	go c.l1.StartJanitor(ctx)
+
+	pubsub := c.client.Subscribe(ctx, c.options.Channel)
+	defer pubsub.Close()
+
+	subscribed := false
+	for {
+		msg, err := pubsub.Receive(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			// Messages may be lost while disconnected, drop every local copy
+			c.apply(invalidation{Clear: true})
+			time.Sleep(time.Second)
+			continue
+		}
+
+		switch msg := msg.(type) {
+		case *redis.Subscription:
+			// A repeated confirmation means go-redis reconnected, anything
+			// published in between was missed
+			if subscribed {
+				c.apply(invalidation{Clear: true})
+			}
+			subscribed = true
+		case *redis.Message:
+			var message invalidation
+			if err := json.Unmarshal([]byte(msg.Payload), &message); err != nil {
+				slog.Warn("Discarding malformed cache invalidation", "error", err)
+				continue
+			}
+			if message.Origin != c.origin {
+				c.apply(message)
+			}
+		}
+	}
+}
+
+// invalidate applies an invalidation locally and broadcasts it
+func (c *LayeredCache) invalidate(message invalidation) {
+	c.apply(message)
+
+	message.Origin = c.origin
+	payload, err := json.Marshal(message)
+	if err != nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if err := c.client.Publish(ctx, c.options.Channel, payload).Err(); err != nil {
+		// Other replicas catch up within L1TTL
+		slog.Warn("Failed to broadcast cache invalidation", "error", err)
+	}
+}
+
+func (c *LayeredCache) apply(message invalidation) {
+	c.fillMu.Lock()
+	defer c.fillMu.Unlock()
+
+	c.epoch.Add(1)
+	if message.Clear {
+		c.l1.Clear()
+		return
+	}
+	for _, key := range message.Keys {
+		c.l1.Delete(key)
+	}
+	for _, pattern := range message.Patterns {
+		c.l1.DeletePattern(pattern)
+	}
+}
+
+func newOrigin() string {
+	buf := make([]byte, 8)
+	if _, err := rand.Read(buf); err != nil {
+		return time.Now().Format(time.RFC3339Nano)
+	}
+	return hex.EncodeToString(buf)
+}
@@ -0,0 +1,19 @@
+package cache_test
+
+import (
+	"testing"
+
+	"github.com/hftamayo/gotodo/pkg/cache"
+	"github.com/hftamayo/gotodo/pkg/cache/cachetest"
+)
+
+func TestLayeredCache(t *testing.T) {
+	client := redisClient(t)
+	newCache := func() cache.Cache {
+		return cache.NewLayeredCache(cache.NewMemoryCache(), cache.NewRedisCache(client, testPrefix), client,
+			cache.LayeredOptions{Channel: testPrefix + "invalidate"})
+	}
+	if err := cachetest.TestCache(newCache); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,153 @@
+package cache
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"math/rand"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// LoadStatus tells where a Loader result came from
+type LoadStatus string
+
+const (
+	// StatusHit is a fresh cached value
+	StatusHit LoadStatus = "HIT"
+	// StatusStale is a cached value past its soft TTL, a refresh is running
+	StatusStale LoadStatus = "STALE"
+	// StatusMiss is a value produced by the load function
+	StatusMiss LoadStatus = "MISS"
+	// StatusBypass is a value read without the cache, caching is disabled
+	StatusBypass LoadStatus = "BYPASS"
+)
+
+// LoadOptions controls how a Loader caches one key
+type LoadOptions struct {
+	// TTL is how long the value is considered fresh
+	TTL time.Duration
+
+	// StaleTTL extends the lifetime of the value past TTL, during that window
+	// the stale value is served while a single background refresh runs
+	StaleTTL time.Duration
+
+	// Beta enables probabilistic early expiration (XFetch) when above zero,
+	// values close to TTL are refreshed early with a probability that grows
+	// with Beta and with how long the load took. 1.0 is the usual setting.
+	Beta float64
+
+	Tags []string
+}
+
+// loadedEntry wraps a cached value with the metadata the Loader needs
+type loadedEntry struct {
+	Value      json.RawMessage `json:"value"`
+	FreshUntil int64           `json:"freshUntil"` // unix nanoseconds
+	Delta      int64           `json:"delta"`      // load duration in nanoseconds
+}
+
+// Loader protects a cache against stampedes: concurrent misses for a key run
+// a single load, and stale values are served while one refresh repopulates them
+type Loader struct {
+	cache      Cache
+	group      singleflight.Group
+	refreshing sync.Map
+	onError    func(key string, err error)
+}
+
+// NewLoader creates a Loader on top of c, onError receives the failures of
+// cache writes and background refreshes, which never reach the caller
+func NewLoader(c Cache, onError func(key string, err error)) *Loader {
+	if onError == nil {
+		onError = func(string, error) {}
+	}
+	return &Loader{cache: c, onError: onError}
+}
+
+// Load decodes the cached value of key into dest, calling load to produce it
+// when it is missing. Values cached by Load must only be read through Load.
+func (l *Loader) Load(key string, dest interface{}, options LoadOptions, load func() (interface{}, error)) (LoadStatus, error) {
+	var entry loadedEntry
+	if err := l.cache.Get(key, &entry); err == nil {
+		if err := json.Unmarshal(entry.Value, dest); err == nil {
+			now := time.Now()
+			if entry.stale(now) {
+				l.refreshAsync(key, options, load)
+				return StatusStale, nil
+			}
+			if entry.refreshEarly(now, options.Beta) {
+				l.refreshAsync(key, options, load)
+			}
+			return StatusHit, nil
+		}
+	} else if !errors.Is(err, ErrCacheMiss) {
+		l.onError(key, err)
+	}
+
+	value, err, _ := l.group.Do(key, func() (interface{}, error) {
+		return l.loadAndStore(key, options, load)
+	})
+	if err != nil {
+		return StatusMiss, err
+	}
+	return StatusMiss, json.Unmarshal(value.(json.RawMessage), dest)
+}
+
+func (e loadedEntry) stale(now time.Time) bool {
+	return !now.Before(time.Unix(0, e.FreshUntil))
+}
+
+// refreshEarly implements XFetch: a fresh entry is recomputed ahead of its
+// expiry with a probability that grows as the expiry gets closer
+func (e loadedEntry) refreshEarly(now time.Time, beta float64) bool {
+	if beta <= 0 || e.Delta <= 0 {
+		return false
+	}
+
+	// -ln(rand) is exponentially distributed over (0, +inf)
+	early := time.Duration(float64(e.Delta) * beta * -math.Log(1-rand.Float64()))
+	return !now.Add(early).Before(time.Unix(0, e.FreshUntil))
+}
+
+func (l *Loader) refreshAsync(key string, options LoadOptions, load func() (interface{}, error)) {
+	if _, running := l.refreshing.LoadOrStore(key, struct{}{}); running {
+		return
+	}
+
+	go func() {
+		defer l.refreshing.Delete(key)
+		if _, err, _ := l.group.Do(key, func() (interface{}, error) {
+			return l.loadAndStore(key, options, load)
+		}); err != nil {
+			// The stale value keeps being served until StaleTTL runs out
+			l.onError(key, err)
+		}
+	}()
+}
+
+func (l *Loader) loadAndStore(key string, options LoadOptions, load func() (interface{}, error)) (interface{}, error) {
+	start := time.Now()
+	value, err := load()
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	entry := loadedEntry{
+		Value:      data,
+		FreshUntil: now.Add(options.TTL).UnixNano(),
+		Delta:      int64(now.Sub(start)),
+	}
+	if err := l.cache.SetWithTags(key, entry, options.TTL+options.StaleTTL, options.Tags...); err != nil {
+		l.onError(key, err)
+	}
+	return json.RawMessage(data), nil
+}
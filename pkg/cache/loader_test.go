@@ -0,0 +1,195 @@
+package cache_test
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/hftamayo/gotodo/pkg/cache"
+)
+
+// waitFor polls cond until it holds or a second has passed
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		if cond() {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatalf("timed out waiting for %s", what)
+}
+
+func TestLoaderCoalescesConcurrentMisses(t *testing.T) {
+	loader := cache.NewLoader(cache.NewMemoryCache(), nil)
+
+	var loads atomic.Int32
+	release := make(chan struct{})
+	load := func() (interface{}, error) {
+		loads.Add(1)
+		<-release
+		return "value", nil
+	}
+
+	const readers = 20
+	var wg sync.WaitGroup
+	errs := make(chan error, readers)
+	for i := 0; i < readers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var got string
+			if _, err := loader.Load("key", &got, cache.LoadOptions{TTL: time.Minute}, load); err != nil {
+				errs <- err
+				return
+			}
+			if got != "value" {
+				errs <- errors.New("unexpected value " + got)
+			}
+		}()
+	}
+
+	waitFor(t, "the load to start", func() bool { return loads.Load() == 1 })
+	// Let the other readers reach the in-flight load before it completes
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		t.Fatal(err)
+	}
+	if n := loads.Load(); n != 1 {
+		t.Fatalf("load ran %d times for concurrent misses, want 1", n)
+	}
+}
+
+func TestLoaderServesStaleWhileRefreshing(t *testing.T) {
+	loader := cache.NewLoader(cache.NewMemoryCache(), nil)
+	options := cache.LoadOptions{TTL: 10 * time.Millisecond, StaleTTL: time.Minute}
+
+	var got string
+	status, err := loader.Load("key", &got, options, func() (interface{}, error) { return "v1", nil })
+	if err != nil || status != cache.StatusMiss || got != "v1" {
+		t.Fatalf("first Load() = %s %q, %v, want MISS v1", status, got, err)
+	}
+	time.Sleep(20 * time.Millisecond)
+
+	var refreshes atomic.Int32
+	release := make(chan struct{})
+	refresh := func() (interface{}, error) {
+		refreshes.Add(1)
+		<-release
+		return "v2", nil
+	}
+
+	// Past TTL the old value is served at once while a single refresh runs
+	for i := 0; i < 3; i++ {
+		status, err := loader.Load("key", &got, options, refresh)
+		if err != nil || status != cache.StatusStale || got != "v1" {
+			t.Fatalf("Load() during the refresh = %s %q, %v, want STALE v1", status, got, err)
+		}
+	}
+	waitFor(t, "the refresh to start", func() bool { return refreshes.Load() == 1 })
+	close(release)
+
+	waitFor(t, "the refreshed value", func() bool {
+		status, err := loader.Load("key", &got, options, refresh)
+		return err == nil && status == cache.StatusHit && got == "v2"
+	})
+	if n := refreshes.Load(); n != 1 {
+		t.Fatalf("refresh ran %d times, want 1", n)
+	}
+}
+
+func TestLoaderKeepsStaleValueWhenRefreshFails(t *testing.T) {
+	refreshErr := errors.New("database down")
+	var reported atomic.Value
+	loader := cache.NewLoader(cache.NewMemoryCache(), func(key string, err error) { reported.Store(err) })
+	options := cache.LoadOptions{TTL: 10 * time.Millisecond, StaleTTL: time.Minute}
+
+	var got string
+	if _, err := loader.Load("key", &got, options, func() (interface{}, error) { return "v1", nil }); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	time.Sleep(20 * time.Millisecond)
+
+	failing := func() (interface{}, error) { return nil, refreshErr }
+	if status, err := loader.Load("key", &got, options, failing); err != nil || status != cache.StatusStale || got != "v1" {
+		t.Fatalf("Load() = %s %q, %v, want STALE v1", status, got, err)
+	}
+	waitFor(t, "the refresh error", func() bool {
+		err, _ := reported.Load().(error)
+		return errors.Is(err, refreshErr)
+	})
+	if status, err := loader.Load("key", &got, options, failing); err != nil || status != cache.StatusStale || got != "v1" {
+		t.Fatalf("Load() after the failed refresh = %s %q, %v, want STALE v1", status, got, err)
+	}
+}
+
+func TestLoaderReturnsLoadErrors(t *testing.T) {
+	c := cache.NewMemoryCache()
+	loader := cache.NewLoader(c, nil)
+	loadErr := errors.New("not found")
+
+	var got string
+	if _, err := loader.Load("key", &got, cache.LoadOptions{TTL: time.Minute}, func() (interface{}, error) {
+		return nil, loadErr
+	}); !errors.Is(err, loadErr) {
+		t.Fatalf("Load() error = %v, want %v", err, loadErr)
+	}
+	if c.Exists("key") {
+		t.Fatal("a failed load was cached")
+	}
+}
+
+func TestLoaderRefreshesEarly(t *testing.T) {
+	newLoad := func(loads *atomic.Int32) func() (interface{}, error) {
+		return func() (interface{}, error) {
+			loads.Add(1)
+			// XFetch weighs the refresh by how long the load took
+			time.Sleep(2 * time.Millisecond)
+			return "value", nil
+		}
+	}
+
+	tests := []struct {
+		name      string
+		beta      float64
+		wantLoads int32
+	}{
+		// A huge beta makes every read of a fresh value refresh it early
+		{"beta refreshes fresh values", 1e12, 2},
+		{"no beta never refreshes fresh values", 0, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			loader := cache.NewLoader(cache.NewMemoryCache(), nil)
+			options := cache.LoadOptions{TTL: time.Hour, Beta: tt.beta}
+			var loads atomic.Int32
+			load := newLoad(&loads)
+
+			var got string
+			if _, err := loader.Load("key", &got, options, load); err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+			status, err := loader.Load("key", &got, options, load)
+			if err != nil || status != cache.StatusHit {
+				t.Fatalf("second Load() = %s, %v, want HIT", status, err)
+			}
+
+			if tt.wantLoads > 1 {
+				waitFor(t, "the early refresh", func() bool { return loads.Load() == tt.wantLoads })
+				return
+			}
+			time.Sleep(20 * time.Millisecond)
+			if n := loads.Load(); n != tt.wantLoads {
+				t.Fatalf("load ran %d times, want %d", n, tt.wantLoads)
+			}
+		})
+	}
+}
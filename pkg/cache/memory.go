@@ -0,0 +1,353 @@
+package cache
+
+import (
+	"container/list"
+	"context"
+	"encoding/json"
+	"errors"
+	"path"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// entryOverhead approximates the bookkeeping bytes of an entry (list element,
+// map slot, expiration) so MaxBytes tracks real memory use more closely
+const entryOverhead = 96
+
+// tagOverhead approximates the bookkeeping bytes of a key in a tag set
+const tagOverhead = 48
+
+// ErrEntryTooLarge is returned when a single value exceeds the cache MaxBytes
+var ErrEntryTooLarge = errors.New("cache: entry exceeds the memory limit")
+
+// MemoryOptions bounds an in-memory cache, zero values disable a limit
+type MemoryOptions struct {
+	MaxEntries      int
+	MaxBytes        int64
+	JanitorInterval time.Duration
+}
+
+// MemoryStats is a snapshot of the in-memory cache counters
+type MemoryStats struct {
+	Hits        uint64 `json:"hits"`
+	Misses      uint64 `json:"misses"`
+	Evictions   uint64 `json:"evictions"`
+	Expirations uint64 `json:"expirations"`
+	Entries     int    `json:"entries"`
+	Bytes       int64  `json:"bytes"`
+}
+
+// MemoryCache implements Cache with an in-process map, values are stored as
+// JSON so reads behave like the Redis backend. When bounded, the least
+// recently used entries are evicted first. Tag sets follow the Redis
+// semantics: a set lives as long as its longest-lived member and is dropped
+// once it expires. An entry leaves its tag sets when it is removed or
+// overwritten, and the sets count towards MaxBytes.
+type MemoryCache struct {
+	options MemoryOptions
+	data    map[string]*list.Element
+	lru     *list.List // front is the most recently used entry
+	tags    map[string]*memoryTag
+	bytes   int64
+	mutex   sync.Mutex
+
+	hits        atomic.Uint64
+	misses      atomic.Uint64
+	evictions   atomic.Uint64
+	expirations atomic.Uint64
+}
+
+type memoryItem struct {
+	key        string
+	value      []byte
+	expiration time.Time
+	tags       []string
+}
+
+type memoryTag struct {
+	keys       map[string]struct{}
+	expiration time.Time // zero when a member never expires
+}
+
+var (
+	_ Cache          = (*MemoryCache)(nil)
+	_ TagInvalidator = (*MemoryCache)(nil)
+)
+
+// NewMemoryCache creates an unbounded in-memory cache
+func NewMemoryCache() *MemoryCache {
+	return NewMemoryCacheWithOptions(MemoryOptions{})
+}
+
+// NewMemoryCacheWithOptions creates an in-memory cache bounded by options,
+// run StartJanitor to sweep expired entries in the background
+func NewMemoryCacheWithOptions(options MemoryOptions) *MemoryCache {
+	return &MemoryCache{
+		options: options,
+		data:    make(map[string]*list.Element),
+		lru:     list.New(),
+		tags:    make(map[string]*memoryTag),
+	}
+}
+
+func (i *memoryItem) expired(now time.Time) bool {
+	return !i.expiration.IsZero() && !now.Before(i.expiration)
+}
+
+func (i *memoryItem) size() int64 {
+	size := int64(len(i.key)+len(i.value)) + entryOverhead
+	for _, tag := range i.tags {
+		size += int64(len(tag)+len(i.key)) + tagOverhead
+	}
+	return size
+}
+
+func (t *memoryTag) expired(now time.Time) bool {
+	return !t.expiration.IsZero() && !now.Before(t.expiration)
+}
+
+// Get retrieves a value from cache
+func (c *MemoryCache) Get(key string, dest interface{}) error {
+	c.mutex.Lock()
+	element, exists := c.data[key]
+	if !exists {
+		c.mutex.Unlock()
+		c.misses.Add(1)
+		return ErrCacheMiss
+	}
+
+	item := element.Value.(*memoryItem)
+	if item.expired(time.Now()) {
+		c.removeElement(element)
+		c.mutex.Unlock()
+		c.expirations.Add(1)
+		c.misses.Add(1)
+		return ErrCacheMiss
+	}
+	c.lru.MoveToFront(element)
+	value := item.value
+	c.mutex.Unlock()
+
+	c.hits.Add(1)
+	return json.Unmarshal(value, dest)
+}
+
+// Set stores a value in cache
+func (c *MemoryCache) Set(key string, value interface{}, ttl time.Duration) error {
+	return c.SetWithTags(key, value, ttl)
+}
+
+// SetWithTags stores a value and records it under each tag, extending the
+// lifetime of every tag set to cover the new entry
+func (c *MemoryCache) SetWithTags(key string, value interface{}, ttl time.Duration, tags ...string) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	item := &memoryItem{key: key, value: data, tags: tags}
+	if ttl > 0 {
+		item.expiration = now.Add(ttl)
+	}
+	if c.options.MaxBytes > 0 && item.size() > c.options.MaxBytes {
+		return ErrEntryTooLarge
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if element, exists := c.data[key]; exists {
+		c.removeElement(element)
+	}
+	c.data[key] = c.lru.PushFront(item)
+	c.bytes += item.size()
+
+	for _, name := range tags {
+		tag, ok := c.tags[name]
+		if !ok || tag.expired(now) {
+			tag = &memoryTag{keys: make(map[string]struct{}), expiration: item.expiration}
+			c.tags[name] = tag
+		}
+		tag.keys[key] = struct{}{}
+
+		switch {
+		case item.expiration.IsZero():
+			tag.expiration = time.Time{}
+		case !tag.expiration.IsZero() && tag.expiration.Before(item.expiration):
+			tag.expiration = item.expiration
+		}
+	}
+	c.evict()
+	return nil
+}
+
+// Delete removes a key from cache
+func (c *MemoryCache) Delete(key string) error {
+	c.mutex.Lock()
+	if element, exists := c.data[key]; exists {
+		c.removeElement(element)
+	}
+	c.mutex.Unlock()
+	return nil
+}
+
+// DeletePattern removes keys matching a glob pattern
+func (c *MemoryCache) DeletePattern(pattern string) error {
+	if _, err := path.Match(pattern, ""); err != nil {
+		return err
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	for key, element := range c.data {
+		if matched, _ := path.Match(pattern, key); matched {
+			c.removeElement(element)
+		}
+	}
+	return nil
+}
+
+// InvalidateByTags removes the keys recorded under the given tags, an expired
+// tag set no longer refers to any key
+func (c *MemoryCache) InvalidateByTags(tags ...string) error {
+	_, err := c.InvalidateTags(tags...)
+	return err
+}
+
+// InvalidateTags removes the keys recorded under the given tags and returns them
+func (c *MemoryCache) InvalidateTags(tags ...string) ([]string, error) {
+	now := time.Now()
+	var removed []string
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	for _, name := range tags {
+		tag, ok := c.tags[name]
+		if !ok {
+			continue
+		}
+		if !tag.expired(now) {
+			for key := range tag.keys {
+				if element, exists := c.data[key]; exists {
+					c.removeElement(element)
+					removed = append(removed, key)
+				}
+			}
+		}
+		delete(c.tags, name)
+	}
+	return removed, nil
+}
+
+// Exists checks if a key exists, it does not count as a use for eviction
+func (c *MemoryCache) Exists(key string) bool {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	element, exists := c.data[key]
+	if !exists {
+		return false
+	}
+	if element.Value.(*memoryItem).expired(time.Now()) {
+		c.removeElement(element)
+		c.expirations.Add(1)
+		return false
+	}
+	return true
+}
+
+// Clear empties the cache
+func (c *MemoryCache) Clear() error {
+	c.mutex.Lock()
+	c.data = make(map[string]*list.Element)
+	c.lru.Init()
+	c.tags = make(map[string]*memoryTag)
+	c.bytes = 0
+	c.mutex.Unlock()
+	return nil
+}
+
+// Stats returns a snapshot of the cache counters
+func (c *MemoryCache) Stats() MemoryStats {
+	c.mutex.Lock()
+	entries, bytes := len(c.data), c.bytes
+	c.mutex.Unlock()
+
+	return MemoryStats{
+		Hits:        c.hits.Load(),
+		Misses:      c.misses.Load(),
+		Evictions:   c.evictions.Load(),
+		Expirations: c.expirations.Load(),
+		Entries:     entries,
+		Bytes:       bytes,
+	}
+}
+
+// StartJanitor removes expired entries and tag sets every JanitorInterval
+// until ctx is cancelled, without it they are only dropped when read
+func (c *MemoryCache) StartJanitor(ctx context.Context) {
+	if c.options.JanitorInterval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(c.options.JanitorInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			c.DeleteExpired()
+		}
+	}
+}
+
+// DeleteExpired removes every expired entry and tag set
+func (c *MemoryCache) DeleteExpired() {
+	now := time.Now()
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	for _, element := range c.data {
+		if element.Value.(*memoryItem).expired(now) {
+			c.removeElement(element)
+			c.expirations.Add(1)
+		}
+	}
+	for name, tag := range c.tags {
+		if tag.expired(now) {
+			delete(c.tags, name)
+		}
+	}
+}
+
+// evict drops least recently used entries until the cache is within bounds,
+// the caller holds the mutex
+func (c *MemoryCache) evict() {
+	for c.lru.Len() > 0 &&
+		((c.options.MaxEntries > 0 && c.lru.Len() > c.options.MaxEntries) ||
+			(c.options.MaxBytes > 0 && c.bytes > c.options.MaxBytes)) {
+		c.removeElement(c.lru.Back())
+		c.evictions.Add(1)
+	}
+}
+
+// removeElement unlinks an entry and takes it out of its tag sets, a set left
+// empty is dropped, the caller holds the mutex
+func (c *MemoryCache) removeElement(element *list.Element) {
+	item := c.lru.Remove(element).(*memoryItem)
+	delete(c.data, item.key)
+	c.bytes -= item.size()
+
+	for _, name := range item.tags {
+		if tag, ok := c.tags[name]; ok {
+			delete(tag.keys, item.key)
+			if len(tag.keys) == 0 {
+				delete(c.tags, name)
+			}
+		}
+	}
+}
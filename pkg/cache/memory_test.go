@@ -0,0 +1,128 @@
+package cache_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hftamayo/gotodo/pkg/cache"
+	"github.com/hftamayo/gotodo/pkg/cache/cachetest"
+)
+
+func TestMemoryCache(t *testing.T) {
+	if err := cachetest.TestCache(func() cache.Cache { return cache.NewMemoryCache() }); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestMemoryCacheBounded(t *testing.T) {
+	newCache := func() cache.Cache {
+		return cache.NewMemoryCacheWithOptions(cache.MemoryOptions{MaxEntries: 100, MaxBytes: 1 << 20})
+	}
+	if err := cachetest.TestCache(newCache); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestMemoryCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	c := cache.NewMemoryCacheWithOptions(cache.MemoryOptions{MaxEntries: 3})
+	for _, key := range []string{"a", "b", "c"} {
+		if err := c.Set(key, key, 0); err != nil {
+			t.Fatalf("Set(%s) error = %v", key, err)
+		}
+	}
+
+	// Reading a makes b the least recently used, Exists doesn't count as a use
+	var value string
+	if err := c.Get("a", &value); err != nil {
+		t.Fatalf("Get(a) error = %v", err)
+	}
+	c.Exists("b")
+	if err := c.Set("d", "d", 0); err != nil {
+		t.Fatalf("Set(d) error = %v", err)
+	}
+
+	for key, want := range map[string]bool{"a": true, "b": false, "c": true, "d": true} {
+		if got := c.Exists(key); got != want {
+			t.Errorf("Exists(%s) = %v, want %v", key, got, want)
+		}
+	}
+	if stats := c.Stats(); stats.Entries != 3 || stats.Evictions != 1 {
+		t.Fatalf("Stats() = %d entries %d evictions, want 3 and 1", stats.Entries, stats.Evictions)
+	}
+}
+
+func TestMemoryCacheEvictsByBytes(t *testing.T) {
+	c := cache.NewMemoryCacheWithOptions(cache.MemoryOptions{MaxBytes: 1024})
+	value := strings.Repeat("x", 300)
+	for i := 0; i < 10; i++ {
+		if err := c.Set(fmt.Sprintf("key%d", i), value, 0); err != nil {
+			t.Fatalf("Set() error = %v", err)
+		}
+		if bytes := c.Stats().Bytes; bytes > 1024 {
+			t.Fatalf("cache holds %d bytes over a limit of 1024", bytes)
+		}
+	}
+	if !c.Exists("key9") || c.Exists("key0") {
+		t.Fatal("the newest entry should be kept and the oldest evicted")
+	}
+
+	if err := c.Set("huge", strings.Repeat("x", 2048), 0); !errors.Is(err, cache.ErrEntryTooLarge) {
+		t.Fatalf("Set() of an entry over MaxBytes error = %v, want %v", err, cache.ErrEntryTooLarge)
+	}
+}
+
+func TestMemoryCacheJanitorSweepsExpiredEntries(t *testing.T) {
+	c := cache.NewMemoryCacheWithOptions(cache.MemoryOptions{JanitorInterval: 5 * time.Millisecond})
+	if err := c.SetWithTags("short", "value", 10*time.Millisecond, "tag"); err != nil {
+		t.Fatalf("SetWithTags() error = %v", err)
+	}
+	if err := c.Set("long", "value", time.Hour); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		c.StartJanitor(ctx)
+		close(done)
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	// Nothing reads the entry, only the janitor can drop it
+	deadline := time.Now().Add(time.Second)
+	for c.Stats().Entries != 1 {
+		if time.Now().After(deadline) {
+			t.Fatalf("janitor left %d entries, want 1", c.Stats().Entries)
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if stats := c.Stats(); stats.Expirations != 1 {
+		t.Fatalf("Stats() = %d expirations, want 1", stats.Expirations)
+	}
+	if removed, _ := c.InvalidateTags("tag"); len(removed) != 0 {
+		t.Fatalf("expired tag still refers to %v", removed)
+	}
+}
+
+func TestMemoryCacheDeleteExpired(t *testing.T) {
+	c := cache.NewMemoryCache()
+	if err := c.Set("short", "value", time.Millisecond); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	if err := c.Set("forever", "value", 0); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	time.Sleep(5 * time.Millisecond)
+
+	c.DeleteExpired()
+	if stats := c.Stats(); stats.Entries != 1 || stats.Expirations != 1 {
+		t.Fatalf("Stats() = %d entries %d expirations, want 1 and 1", stats.Entries, stats.Expirations)
+	}
+}
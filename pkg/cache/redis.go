@@ -0,0 +1,209 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/hftamayo/gotodo/pkg/utils"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	tagKeyPrefix  = "tag:"
+	scanBatchSize = 100
+)
+
+// setWithTagsScript stores the value and adds it to its tag sets atomically.
+// A tag set must outlive every member, so its TTL only ever grows and a
+// member without expiry makes the set persistent.
+//
+// KEYS[1] is the value key, KEYS[2..n] the tag sets
+// ARGV[1] is the encoded value, ARGV[2] the TTL in milliseconds (0 = none)
+var setWithTagsScript = redis.NewScript(`
+local ttl = tonumber(ARGV[2])
+if ttl > 0 then
+	redis.call('SET', KEYS[1], ARGV[1], 'PX', ttl)
+else
+	redis.call('SET', KEYS[1], ARGV[1])
+end
+
+for i = 2, #KEYS do
+	local existed = redis.call('EXISTS', KEYS[i])
+	local current = redis.call('PTTL', KEYS[i])
+	redis.call('SADD', KEYS[i], KEYS[1])
+	if ttl == 0 then
+		redis.call('PERSIST', KEYS[i])
+	elseif existed == 0 or (current >= 0 and current < ttl) then
+		redis.call('PEXPIRE', KEYS[i], ttl)
+	end
+end
+return 1
+`)
+
+// RedisCache implements Cache on top of Redis. Every key is stored under the
+// prefix, so Clear and DeletePattern never touch keys owned by other
+// components (rate limiter, error log) sharing the same database.
+type RedisCache struct {
+	client utils.RedisClientInterface
+	prefix string
+}
+
+// invalidateTagsScript deletes the members of the tag sets and the sets
+// themselves atomically, returning the deleted member keys
+//
+// KEYS are the tag sets
+var invalidateTagsScript = redis.NewScript(`
+local removed = {}
+for i = 1, #KEYS do
+	local members = redis.call('SMEMBERS', KEYS[i])
+	for _, member in ipairs(members) do
+		if redis.call('DEL', member) == 1 then
+			table.insert(removed, member)
+		end
+	end
+	redis.call('DEL', KEYS[i])
+end
+return removed
+`)
+
+var (
+	_ Cache          = (*RedisCache)(nil)
+	_ TagInvalidator = (*RedisCache)(nil)
+)
+
+// NewRedisCache creates a Redis backed cache that namespaces its keys with prefix
+func NewRedisCache(client utils.RedisClientInterface, prefix string) *RedisCache {
+	return &RedisCache{client: client, prefix: prefix}
+}
+
+// Client returns the Redis client backing the cache
+func (c *RedisCache) Client() utils.RedisClientInterface {
+	return c.client
+}
+
+func (c *RedisCache) key(key string) string {
+	return c.prefix + key
+}
+
+func (c *RedisCache) tagKey(tag string) string {
+	return c.prefix + tagKeyPrefix + tag
+}
+
+// Get retrieves a value from cache
+func (c *RedisCache) Get(key string, dest interface{}) error {
+	data, err := c.client.Get(context.Background(), c.key(key)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return ErrCacheMiss
+	}
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, dest)
+}
+
+// Set stores a value in cache
+func (c *RedisCache) Set(key string, value interface{}, ttl time.Duration) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return c.client.Set(context.Background(), c.key(key), data, ttl).Err()
+}
+
+// SetWithTags stores a value and adds it to the set of each tag
+func (c *RedisCache) SetWithTags(key string, value interface{}, ttl time.Duration, tags ...string) error {
+	if len(tags) == 0 {
+		return c.Set(key, value, ttl)
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	keys := make([]string, 0, len(tags)+1)
+	keys = append(keys, c.key(key))
+	for _, tag := range tags {
+		keys = append(keys, c.tagKey(tag))
+	}
+
+	return setWithTagsScript.Run(context.Background(), c.client, keys, data, ttl.Milliseconds()).Err()
+}
+
+// Delete removes a key from cache
+func (c *RedisCache) Delete(key string) error {
+	return c.client.Del(context.Background(), c.key(key)).Err()
+}
+
+// DeletePattern removes keys matching a glob pattern
+func (c *RedisCache) DeletePattern(pattern string) error {
+	return c.deleteMatching(c.key(pattern))
+}
+
+// InvalidateByTags deletes all keys associated with the given tags
+func (c *RedisCache) InvalidateByTags(tags ...string) error {
+	_, err := c.InvalidateTags(tags...)
+	return err
+}
+
+// InvalidateTags deletes the keys associated with the given tags and returns them
+func (c *RedisCache) InvalidateTags(tags ...string) ([]string, error) {
+	if len(tags) == 0 {
+		return nil, nil
+	}
+
+	tagKeys := make([]string, len(tags))
+	for i, tag := range tags {
+		tagKeys[i] = c.tagKey(tag)
+	}
+
+	members, err := invalidateTagsScript.Run(context.Background(), c.client, tagKeys).StringSlice()
+	if err != nil {
+		return nil, err
+	}
+
+	// Members are stored with the prefix applied, callers work with bare keys
+	removed := make([]string, 0, len(members))
+	for _, member := range members {
+		removed = append(removed, strings.TrimPrefix(member, c.prefix))
+	}
+	return removed, nil
+}
+
+// Exists checks if a key exists
+func (c *RedisCache) Exists(key string) bool {
+	count, err := c.client.Exists(context.Background(), c.key(key)).Result()
+	return err == nil && count > 0
+}
+
+// Clear removes every key under the cache prefix
+func (c *RedisCache) Clear() error {
+	return c.deleteMatching(c.prefix + "*")
+}
+
+func (c *RedisCache) deleteMatching(match string) error {
+	ctx := context.Background()
+
+	iter := c.client.Scan(ctx, 0, match, scanBatchSize).Iterator()
+	batch := make([]string, 0, scanBatchSize)
+	for iter.Next(ctx) {
+		batch = append(batch, iter.Val())
+		if len(batch) == scanBatchSize {
+			if err := c.client.Del(ctx, batch...).Err(); err != nil {
+				return err
+			}
+			batch = batch[:0]
+		}
+	}
+	if err := iter.Err(); err != nil {
+		return err
+	}
+
+	if len(batch) > 0 {
+		return c.client.Del(ctx, batch...).Err()
+	}
+	return nil
+}
@@ -0,0 +1,42 @@
+package cache_test
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/hftamayo/gotodo/pkg/cache"
+	"github.com/hftamayo/gotodo/pkg/cache/cachetest"
+	"github.com/redis/go-redis/v9"
+)
+
+// testPrefix keeps the keys of the tests apart from those of a running API
+const testPrefix = "cachetest:"
+
+// redisClient connects to REDIS_ADDR (default localhost:6379) and skips the
+// test when no server answers
+func redisClient(t *testing.T) *redis.Client {
+	t.Helper()
+
+	addr := os.Getenv("REDIS_ADDR")
+	if addr == "" {
+		addr = "localhost:6379"
+	}
+	client := redis.NewClient(&redis.Options{Addr: addr, MaxRetries: -1})
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := client.Ping(ctx).Err(); err != nil {
+		client.Close()
+		t.Skipf("Redis is not reachable at %s: %v", addr, err)
+	}
+	t.Cleanup(func() { client.Close() })
+	return client
+}
+
+func TestRedisCache(t *testing.T) {
+	client := redisClient(t)
+	if err := cachetest.TestCache(func() cache.Cache { return cache.NewRedisCache(client, testPrefix) }); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,334 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"path"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// maxPendingInvalidations bounds the invalidations remembered while the
+// primary is down, past it the primary is cleared on recovery instead
+const maxPendingInvalidations = 10000
+
+// BreakerState is the state of the ResilientCache circuit breaker
+type BreakerState int32
+
+const (
+	// BreakerClosed sends every operation to the primary backend
+	BreakerClosed BreakerState = iota
+	// BreakerOpen sends every operation to the in-memory fallback
+	BreakerOpen
+)
+
+func (s BreakerState) String() string {
+	if s == BreakerOpen {
+		return "open"
+	}
+	return "closed"
+}
+
+// ResilientOptions configures the circuit breaker of a ResilientCache
+type ResilientOptions struct {
+	// FailureThreshold is the number of consecutive primary failures that
+	// opens the breaker
+	FailureThreshold int
+
+	// ProbeInterval is how often Probe is tried while the breaker is open
+	ProbeInterval time.Duration
+
+	// Probe checks whether the primary is reachable again, without it the
+	// breaker never closes once opened
+	Probe func(ctx context.Context) error
+
+	// StartOpen starts on the fallback, for a primary that was down at boot
+	StartOpen bool
+}
+
+// ResilientCache sends operations to a primary backend (Redis) and switches
+// to an in-memory fallback when the primary keeps failing. While the breaker
+// is open the primary is probed in the background, and the invalidations it
+// missed are replayed on it before switching back, so it never serves values
+// that were invalidated during the outage.
+type ResilientCache struct {
+	primary  Cache
+	fallback *MemoryCache
+	options  ResilientOptions
+
+	state    atomic.Int32
+	failures atomic.Int32
+
+	// mu serializes recording missed invalidations with the recovery that replays them
+	mu        sync.Mutex
+	pending   pendingInvalidations
+	onRecover []func()
+}
+
+var _ Cache = (*ResilientCache)(nil)
+
+// NewResilientCache wraps primary with a circuit breaker falling back to fallback
+func NewResilientCache(primary Cache, fallback *MemoryCache, options ResilientOptions) *ResilientCache {
+	if options.FailureThreshold <= 0 {
+		options.FailureThreshold = 5
+	}
+	if options.ProbeInterval <= 0 {
+		options.ProbeInterval = 5 * time.Second
+	}
+
+	c := &ResilientCache{primary: primary, fallback: fallback, options: options}
+	if options.StartOpen {
+		c.state.Store(int32(BreakerOpen))
+	}
+	return c
+}
+
+// State returns the current breaker state
+func (c *ResilientCache) State() BreakerState {
+	return BreakerState(c.state.Load())
+}
+
+// OnRecover registers fn to run after switching back to the primary, e.g. to rewarm it
+func (c *ResilientCache) OnRecover(fn func()) {
+	c.mu.Lock()
+	c.onRecover = append(c.onRecover, fn)
+	c.mu.Unlock()
+}
+
+// Get reads from the active backend, a failed primary read is served by the fallback
+func (c *ResilientCache) Get(key string, dest interface{}) error {
+	if c.open() {
+		return c.fallback.Get(key, dest)
+	}
+	err := c.primary.Get(key, dest)
+	if !c.failed(err) {
+		return err
+	}
+	return c.fallback.Get(key, dest)
+}
+
+// Set stores a value in the active backend
+func (c *ResilientCache) Set(key string, value interface{}, ttl time.Duration) error {
+	return c.SetWithTags(key, value, ttl)
+}
+
+// SetWithTags stores a tagged value in the active backend
+func (c *ResilientCache) SetWithTags(key string, value interface{}, ttl time.Duration, tags ...string) error {
+	if !c.open() {
+		err := c.primary.SetWithTags(key, value, ttl, tags...)
+		if !c.failed(err) {
+			return err
+		}
+	}
+	return c.fallback.SetWithTags(key, value, ttl, tags...)
+}
+
+// Delete removes a key from both backends
+func (c *ResilientCache) Delete(key string) error {
+	return c.invalidate(func(b Cache) error { return b.Delete(key) },
+		func(p *pendingInvalidations) { p.add(&p.keys, key) })
+}
+
+// DeletePattern removes the matching keys from both backends
+func (c *ResilientCache) DeletePattern(pattern string) error {
+	return c.invalidate(func(b Cache) error { return b.DeletePattern(pattern) },
+		func(p *pendingInvalidations) { p.add(&p.patterns, pattern) })
+}
+
+// InvalidateByTags removes the tagged keys from both backends
+func (c *ResilientCache) InvalidateByTags(tags ...string) error {
+	return c.invalidate(func(b Cache) error { return b.InvalidateByTags(tags...) },
+		func(p *pendingInvalidations) { p.add(&p.tags, tags...) })
+}
+
+// Exists checks the active backend
+func (c *ResilientCache) Exists(key string) bool {
+	if c.open() {
+		return c.fallback.Exists(key)
+	}
+	return c.primary.Exists(key)
+}
+
+// Clear empties both backends
+func (c *ResilientCache) Clear() error {
+	return c.invalidate(func(b Cache) error { return b.Clear() },
+		func(p *pendingInvalidations) { *p = pendingInvalidations{clear: true} })
+}
+
+// Start runs the background work of both backends and probes the primary
+// while the breaker is open, until ctx is cancelled
+func (c *ResilientCache) Start(ctx context.Context) {
+	go c.fallback.StartJanitor(ctx)
+	if starter, ok := c.primary.(interface{ Start(context.Context) }); ok {
+		go starter.Start(ctx)
+	}
+
+	ticker := time.NewTicker(c.options.ProbeInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if c.open() {
+				c.recover(ctx)
+			}
+		}
+	}
+}
+
+// invalidate applies an invalidation to the fallback and the primary. When
+// the primary is unavailable the invalidation is kept for the recovery.
+func (c *ResilientCache) invalidate(apply func(Cache) error, record func(*pendingInvalidations)) error {
+	if err := apply(c.fallback); err != nil {
+		return err
+	}
+
+	if !c.open() {
+		err := apply(c.primary)
+		if !isBackendFailure(err) {
+			c.failures.Store(0)
+			return err
+		}
+		// The primary may now hold a value that should be gone, trip right
+		// away so the invalidation is replayed before it is read again
+		c.trip(err)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.open() {
+		// Recovered in the meantime
+		err := apply(c.primary)
+		if !isBackendFailure(err) {
+			return err
+		}
+		c.trip(err)
+	}
+	record(&c.pending)
+	return nil
+}
+
+// recover switches back to the primary once it answers the probe and has
+// caught up with the invalidations it missed
+func (c *ResilientCache) recover(ctx context.Context) {
+	if c.options.Probe == nil {
+		return
+	}
+
+	probeCtx, cancel := context.WithTimeout(ctx, c.options.ProbeInterval)
+	defer cancel()
+	if err := c.options.Probe(probeCtx); err != nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.pending.replay(c.primary); err != nil {
+		slog.Warn("Cache primary is reachable but replaying invalidations failed", "error", err)
+		return
+	}
+	c.pending = pendingInvalidations{}
+
+	// Values cached during the outage are not invalidated by other replicas
+	c.fallback.Clear()
+	c.failures.Store(0)
+	c.state.Store(int32(BreakerClosed))
+	slog.Info("Cache primary recovered, switching back from the memory fallback")
+
+	for _, fn := range c.onRecover {
+		go fn()
+	}
+}
+
+func (c *ResilientCache) open() bool {
+	return c.State() == BreakerOpen
+}
+
+// failed reports whether err is a primary failure, counting it towards the threshold
+func (c *ResilientCache) failed(err error) bool {
+	if !isBackendFailure(err) {
+		c.failures.Store(0)
+		return false
+	}
+	if int(c.failures.Add(1)) >= c.options.FailureThreshold {
+		c.trip(err)
+	}
+	return true
+}
+
+func (c *ResilientCache) trip(err error) {
+	if c.state.CompareAndSwap(int32(BreakerClosed), int32(BreakerOpen)) {
+		slog.Warn("Cache primary failing, switching to the memory fallback", "error", err)
+	}
+}
+
+// isBackendFailure tells an unavailable backend apart from a miss or from
+// errors caused by the caller, which the fallback would return as well
+func isBackendFailure(err error) bool {
+	if err == nil || errors.Is(err, ErrCacheMiss) || errors.Is(err, ErrEntryTooLarge) || errors.Is(err, path.ErrBadPattern) {
+		return false
+	}
+
+	var (
+		syntaxErr      *json.SyntaxError
+		typeErr        *json.UnmarshalTypeError
+		unsupportedErr *json.UnsupportedTypeError
+		valueErr       *json.UnsupportedValueError
+		marshalerErr   *json.MarshalerError
+	)
+	return !errors.As(err, &syntaxErr) && !errors.As(err, &typeErr) &&
+		!errors.As(err, &unsupportedErr) && !errors.As(err, &valueErr) && !errors.As(err, &marshalerErr)
+}
+
+// pendingInvalidations are the invalidations the primary missed while down
+type pendingInvalidations struct {
+	clear    bool
+	keys     map[string]struct{}
+	patterns map[string]struct{}
+	tags     map[string]struct{}
+}
+
+func (p *pendingInvalidations) add(set *map[string]struct{}, values ...string) {
+	if p.clear {
+		return
+	}
+	if *set == nil {
+		*set = make(map[string]struct{})
+	}
+	for _, value := range values {
+		(*set)[value] = struct{}{}
+	}
+	if len(p.keys)+len(p.patterns)+len(p.tags) > maxPendingInvalidations {
+		*p = pendingInvalidations{clear: true}
+	}
+}
+
+// replay applies the invalidations to c, it is safe to repeat after a failure
+func (p *pendingInvalidations) replay(c Cache) error {
+	if p.clear {
+		return c.Clear()
+	}
+	for key := range p.keys {
+		if err := c.Delete(key); err != nil {
+			return err
+		}
+	}
+	for pattern := range p.patterns {
+		if err := c.DeletePattern(pattern); err != nil {
+			return err
+		}
+	}
+	if len(p.tags) > 0 {
+		tags := make([]string, 0, len(p.tags))
+		for tag := range p.tags {
+			tags = append(tags, tag)
+		}
+		return c.InvalidateByTags(tags...)
+	}
+	return nil
+}
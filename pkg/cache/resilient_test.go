@@ -0,0 +1,37 @@
+package cache_test
+
+import (
+	"testing"
+
+	"github.com/hftamayo/gotodo/pkg/cache"
+	"github.com/hftamayo/gotodo/pkg/cache/cachetest"
+)
+
+func TestResilientCache(t *testing.T) {
+	newCache := func() cache.Cache {
+		return cache.NewResilientCache(cache.NewMemoryCache(), cache.NewMemoryCache(), cache.ResilientOptions{})
+	}
+	if err := cachetest.TestCache(newCache); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestResilientCacheOpen runs the checks on the fallback, as served while the primary is down
+func TestResilientCacheOpen(t *testing.T) {
+	newCache := func() cache.Cache {
+		return cache.NewResilientCache(cache.NewMemoryCache(), cache.NewMemoryCache(), cache.ResilientOptions{StartOpen: true})
+	}
+	if err := cachetest.TestCache(newCache); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestResilientCacheRedis(t *testing.T) {
+	client := redisClient(t)
+	newCache := func() cache.Cache {
+		return cache.NewResilientCache(cache.NewRedisCache(client, testPrefix), cache.NewMemoryCache(), cache.ResilientOptions{})
+	}
+	if err := cachetest.TestCache(newCache); err != nil {
+		t.Fatal(err)
+	}
+}
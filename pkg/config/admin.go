@@ -0,0 +1,15 @@
+package config
+
+// AdminConfig holds the configuration of the admin endpoints
+type AdminConfig struct {
+	// Token is expected in the X-Admin-Token header, the admin endpoints are
+	// disabled when it is empty
+	Token string
+}
+
+// DefaultAdminConfig returns default admin configuration
+func DefaultAdminConfig() *AdminConfig {
+	return &AdminConfig{
+		Token: getEnvOrDefault("ADMIN_TOKEN", ""),
+	}
+}
@@ -3,24 +3,15 @@ package config
 import (
 	"context"
 	"fmt"
+	"log/slog"
 	"os"
 	"strconv"
 	"time"
 
+	"github.com/hftamayo/gotodo/pkg/cache"
 	"github.com/redis/go-redis/v9"
-	"github.com/hftamayo/gotodo/pkg/utils"
 )
 
-// CacheInterface defines the contract for cache operations
-// This interface matches what the task service expects
-type CacheInterface interface {
-	Get(key string, dest interface{}) error
-	Set(key string, value interface{}, ttl time.Duration) error
-	SetWithTags(key string, value interface{}, ttl time.Duration, tags ...string) error
-	Delete(key string) error
-	InvalidateByTags(tags ...string) error
-}
-
 // CacheConfig holds cache configuration
 type CacheConfig struct {
 	Host            string
@@ -35,6 +26,25 @@ type CacheConfig struct {
 	WriteTimeout    time.Duration
 	PoolSize        int
 	MinIdleConns    int
+	KeyPrefix       string
+
+	// In-memory fallback bounds
+	MemoryMaxEntries      int
+	MemoryMaxBytes        int64
+	MemoryJanitorInterval time.Duration
+
+	// In-process L1 in front of Redis
+	L1Enabled           bool
+	L1TTL               time.Duration
+	L1MaxEntries        int
+	InvalidationChannel string
+
+	// Circuit breaker switching to the memory fallback when Redis fails
+	BreakerFailureThreshold int
+	BreakerProbeInterval    time.Duration
+
+	// Number of keys tracked for the most read keys of the cache stats
+	TopKeysTracked int
 }
 
 // DefaultCacheConfig returns default cache configuration
@@ -52,16 +62,44 @@ func DefaultCacheConfig() *CacheConfig {
 		WriteTimeout:    getEnvAsDurationOrDefault("REDIS_WRITE_TIMEOUT", 3*time.Second),
 		PoolSize:        getEnvAsIntOrDefault("REDIS_POOL_SIZE", 10),
 		MinIdleConns:    getEnvAsIntOrDefault("REDIS_MIN_IDLE_CONNS", 5),
+		KeyPrefix:       getEnvOrDefault("CACHE_KEY_PREFIX", "cache:"),
+
+		MemoryMaxEntries:      getEnvAsIntOrDefault("CACHE_MEMORY_MAX_ENTRIES", 10000),
+		MemoryMaxBytes:        int64(getEnvAsIntOrDefault("CACHE_MEMORY_MAX_BYTES", 64<<20)),
+		MemoryJanitorInterval: getEnvAsDurationOrDefault("CACHE_MEMORY_JANITOR_INTERVAL", time.Minute),
+
+		L1Enabled:           getEnvOrDefault("CACHE_L1_ENABLED", "true") == "true",
+		L1TTL:               getEnvAsDurationOrDefault("CACHE_L1_TTL", 30*time.Second),
+		L1MaxEntries:        getEnvAsIntOrDefault("CACHE_L1_MAX_ENTRIES", 1000),
+		InvalidationChannel: getEnvOrDefault("CACHE_INVALIDATION_CHANNEL", "cache:invalidate"),
+
+		BreakerFailureThreshold: getEnvAsIntOrDefault("CACHE_BREAKER_FAILURE_THRESHOLD", 5),
+		BreakerProbeInterval:    getEnvAsDurationOrDefault("CACHE_BREAKER_PROBE_INTERVAL", 5*time.Second),
+
+		TopKeysTracked: getEnvAsIntOrDefault("CACHE_TOP_KEYS_TRACKED", 1000),
 	}
 }
 
 // SetupCache creates a new cache instance with configuration
-func SetupCache(config *CacheConfig) (*utils.Cache, error) {
+func SetupCache(config *CacheConfig) (*cache.RedisCache, error) {
 	if config == nil {
 		return nil, fmt.Errorf("cache config cannot be nil")
 	}
 	
-	redisClient := redis.NewClient(&redis.Options{
+	redisClient := newRedisClient(config)
+
+	// Test the connection
+	ctx := context.Background()
+	if err := redisClient.Ping(ctx).Err(); err != nil {
+		return nil, err
+	}
+
+	// The real redis.Client implements RedisClientInterface, so this will work
+	return cache.NewRedisCache(redisClient, config.KeyPrefix), nil
+}
+
+func newRedisClient(config *CacheConfig) *redis.Client {
+	return redis.NewClient(&redis.Options{
 		Addr:            config.Host + ":" + config.Port,
 		DB:              config.DB,
 		Password:        config.Password,
@@ -74,114 +112,94 @@ func SetupCache(config *CacheConfig) (*utils.Cache, error) {
 		PoolSize:        config.PoolSize,
 		MinIdleConns:    config.MinIdleConns,
 	})
-
-	// Test the connection
-	ctx := context.Background()
-	if err := redisClient.Ping(ctx).Err(); err != nil {
-		return nil, err
-	}
-
-	// The real redis.Client implements RedisClientInterface, so this will work
-	return utils.NewCache(redisClient), nil
 }
 
 // SetupCacheWithDefaults creates a cache instance with default configuration
-func SetupCacheWithDefaults() (*utils.Cache, error) {
+func SetupCacheWithDefaults() (*cache.RedisCache, error) {
 	config := DefaultCacheConfig()
 	return SetupCache(config)
 }
 
-// NewCache creates a new cache instance that implements CacheInterface
+// NewCache creates a new Redis backed cache.Cache
 // This is the preferred way to create cache instances for the task service
-func NewCache(config *CacheConfig) (CacheInterface, error) {
-	cache, err := SetupCache(config)
+func NewCache(config *CacheConfig) (cache.Cache, error) {
+	redisCache, err := SetupCache(config)
 	if err != nil {
 		return nil, err
 	}
-	return cache, nil
+	return redisCache, nil
 }
 
 // NewCacheWithDefaults creates a cache instance with default configuration
 // This is the preferred way to create cache instances for the task service
-func NewCacheWithDefaults() (CacheInterface, error) {
+func NewCacheWithDefaults() (cache.Cache, error) {
 	config := DefaultCacheConfig()
 	return NewCache(config)
 }
 
-// NewMemoryCache creates a memory-based cache for testing
-// This implements CacheInterface but stores data in memory instead of Redis
-func NewMemoryCache() CacheInterface {
-	return &MemoryCache{
-		data: make(map[string]interface{}),
-		ttl:  make(map[string]time.Time),
+// NewMemoryCache creates a bounded memory-based cache, used when Redis is unavailable.
+// Run its StartJanitor to sweep expired entries in the background.
+func NewMemoryCache(config *CacheConfig) *cache.MemoryCache {
+	if config == nil {
+		config = DefaultCacheConfig()
 	}
-}
 
-// MemoryCache implements CacheInterface using in-memory storage
-// This is useful for testing and development without Redis
-type MemoryCache struct {
-	data map[string]interface{}
-	ttl  map[string]time.Time
+	return cache.NewMemoryCacheWithOptions(cache.MemoryOptions{
+		MaxEntries:      config.MemoryMaxEntries,
+		MaxBytes:        config.MemoryMaxBytes,
+		JanitorInterval: config.MemoryJanitorInterval,
+	})
 }
 
-func (m *MemoryCache) Get(key string, dest interface{}) error {
-	// Check if key exists and is not expired
-	if value, exists := m.data[key]; exists {
-		if ttl, hasTTL := m.ttl[key]; hasTTL && time.Now().After(ttl) {
-			// Key has expired, remove it
-			delete(m.data, key)
-			delete(m.ttl, key)
-			return fmt.Errorf("key not found or expired")
-		}
-		
-		// For simplicity, we'll just copy the value
-		// In a real implementation, you'd want proper serialization
-		if destPtr, ok := dest.(*string); ok {
-			if strValue, ok := value.(string); ok {
-				*destPtr = strValue
-				return nil
-			}
-		}
-		if destPtr, ok := dest.(*interface{}); ok {
-			*destPtr = value
-			return nil
-		}
-		return fmt.Errorf("destination type not supported")
+// NewLayeredCache puts a bounded in-process L1 in front of the Redis cache.
+// Run its Start to receive the invalidations of the other replicas.
+func NewLayeredCache(config *CacheConfig, l2 *cache.RedisCache) *cache.LayeredCache {
+	if config == nil {
+		config = DefaultCacheConfig()
 	}
-	return fmt.Errorf("key not found")
-}
 
-func (m *MemoryCache) Set(key string, value interface{}, ttl time.Duration) error {
-	m.data[key] = value
-	if ttl > 0 {
-		m.ttl[key] = time.Now().Add(ttl)
-	}
-	return nil
+	l1 := cache.NewMemoryCacheWithOptions(cache.MemoryOptions{
+		MaxEntries:      config.L1MaxEntries,
+		MaxBytes:        config.MemoryMaxBytes,
+		JanitorInterval: config.MemoryJanitorInterval,
+	})
+	return cache.NewLayeredCache(l1, l2, l2.Client(), cache.LayeredOptions{
+		L1TTL:   config.L1TTL,
+		Channel: config.InvalidationChannel,
+	})
 }
 
-func (m *MemoryCache) SetWithTags(key string, value interface{}, ttl time.Duration, tags ...string) error {
-	// For memory cache, we'll just set the value
-	// Tag management would require additional complexity
-	return m.Set(key, value, ttl)
-}
+// NewResilientCache creates the application cache: Redis (behind the L1 when
+// enabled) with a circuit breaker that falls back to the bounded memory cache.
+// Redis doesn't need to be reachable at boot, it is picked up once it answers.
+// Run its Start to probe Redis and run the background work of both tiers.
+func NewResilientCache(config *CacheConfig) *cache.ResilientCache {
+	if config == nil {
+		config = DefaultCacheConfig()
+	}
 
-func (m *MemoryCache) Delete(key string) error {
-	delete(m.data, key)
-	delete(m.ttl, key)
-	return nil
-}
+	redisClient := newRedisClient(config)
+	redisCache := cache.NewRedisCache(redisClient, config.KeyPrefix)
+	var primary cache.Cache = redisCache
+	if config.L1Enabled {
+		primary = NewLayeredCache(config, redisCache)
+	}
 
-func (m *MemoryCache) InvalidateByTags(tags ...string) error {
-	// For memory cache, we'll just clear all data
-	// In a real implementation, you'd want proper tag management
-	m.data = make(map[string]interface{})
-	m.ttl = make(map[string]time.Time)
-	return nil
-}
+	ctx, cancel := context.WithTimeout(context.Background(), config.DialTimeout)
+	defer cancel()
+	pingErr := redisClient.Ping(ctx).Err()
+	if pingErr != nil {
+		slog.Warn("Redis cache unavailable, starting on the memory fallback", "error", pingErr)
+	}
 
-// Legacy function for backward compatibility
-func SetupCacheLegacy(redisClient utils.RedisClientInterface) *utils.Cache {
-	return utils.NewCache(redisClient)
+	return cache.NewResilientCache(primary, NewMemoryCache(config), cache.ResilientOptions{
+		FailureThreshold: config.BreakerFailureThreshold,
+		ProbeInterval:    config.BreakerProbeInterval,
+		Probe: func(ctx context.Context) error {
+			return redisClient.Ping(ctx).Err()
+		},
+		StartOpen: pingErr != nil,
+	})
 }
 
 // Helper functions for environment variable handling
@@ -3,7 +3,7 @@ package config
 import (
 	"errors"
 	"fmt"
-	"log"
+	"log/slog"
 	"regexp"
 	"time"
 
@@ -22,16 +22,16 @@ func buildConnectionString(envVars *EnvVars) string {
 func isTestEnviro(envVars *EnvVars) bool {
 	switch envVars.Mode {
 	case "development":
-		fmt.Println("running in development mode")
+		slog.Info("running in development mode")
 		return false
 	case "testing":
-		fmt.Println("running in testing mode")
+		slog.Info("running in testing mode")
 		return true
 	case "production":
-		fmt.Println("running in production mode")
+		slog.Info("running in production mode")
 		return true
 	default:
-		fmt.Println("no run mode specified")
+		slog.Warn("no run mode specified")
 		return false
 	}
 }
@@ -43,17 +43,17 @@ func CheckDataLayerAvailability(envVars *EnvVars) (*gorm.DB, error) {
 
     for i := 0; i < maxRetries; i++ {
         start := time.Now()
-        fmt.Printf("Attempting to connect to database (attempt %d/%d)...\n", i+1, maxRetries)
-        fmt.Printf("Connection string: %s\n", maskConnectionString(connectionString))
+        slog.Info("Attempting to connect to database", "attempt", i+1, "max_attempts", maxRetries,
+            "connection", maskConnectionString(connectionString))
 
         db, err := gorm.Open(postgres.Open(connectionString), &gorm.Config{})
         elapsed := time.Since(start)
 
         if err != nil {
-            log.Printf("Connection attempt %d failed after %v: %v\n", i+1, elapsed, err)
+            slog.Warn("Connection attempt failed", "attempt", i+1, "elapsed", elapsed.String(), "error", err)
             
             if i < maxRetries-1 {
-                fmt.Printf("Retrying in %v...\n", retryDelay)
+                slog.Info("Retrying database connection", "delay", retryDelay.String())
                 time.Sleep(retryDelay)
                 continue
             }
@@ -63,17 +63,17 @@ func CheckDataLayerAvailability(envVars *EnvVars) (*gorm.DB, error) {
         // Test the connection
         sqlDB, err := db.DB()
         if err != nil {
-            log.Printf("Error getting underlying *sql.DB: %v\n", err)
+            slog.Error("Error getting underlying *sql.DB", "error", err)
             return nil, err
         }
 
         err = sqlDB.Ping()
         if err != nil {
-            log.Printf("Error pinging database: %v\n", err)
+            slog.Error("Error pinging database", "error", err)
             return nil, err
         }
 
-        fmt.Printf("Successfully connected to database after %v\n", elapsed)
+        slog.Info("Successfully connected to database", "elapsed", elapsed.String())
         return db, nil
     }
 
@@ -92,29 +92,30 @@ func DataLayerConnect(envVars *EnvVars) (*gorm.DB, error) {
 
 		db, err := gorm.Open(postgres.Open(connectionString), &gorm.Config{})
 		if err != nil {
-			log.Printf("Error connecting to the database.\n%v", err)
+			slog.Error("Error connecting to the database", "error", err)
 			return nil, err
 		}
 
 		// AutoMigrate will create the tables based on the models
-		err = db.AutoMigrate(&models.User{}, &models.Task{})
+		err = db.AutoMigrate(&models.User{}, &models.Task{}, &models.NotificationPreference{}, &models.TaskReminder{},
+			&models.WebhookSubscription{}, &models.WebhookDelivery{}, &models.OutboxEvent{}, &models.APIKey{})
 		if err != nil {
-			log.Printf("Error during migration.\n%v", err)
+			slog.Error("Error during migration", "error", err)
 			return nil, err
 		}
 
 		if envVars.seedDev || envVars.seedProd {
-			log.Println("Data seeding required")
+			slog.Info("Data seeding required")
 
 			err = seeder.SeedData(db)
 			if err != nil {
-				log.Printf("Error during data seeding.\n%v", err)
+				slog.Error("Error during data seeding", "error", err)
 				return nil, err
 			}
 
-			log.Println("Data seeding successful")
+			slog.Info("Data seeding successful")
 		} else {
-			log.Println("No data seeding required")
+			slog.Info("No data seeding required")
 		}
 		return db, nil
 	} else {
@@ -2,29 +2,68 @@ package config
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"log"
+	"log/slog"
 	"os"
+	"strconv"
 	"sync"
+	"sync/atomic"
 	"time"
 
-	"github.com/redis/go-redis/v9"
 	"github.com/hftamayo/gotodo/pkg/utils"
+	"github.com/redis/go-redis/v9"
 )
 
 // ErrorLogger defines the interface for error logging operations
 type ErrorLogger interface {
 	LogError(ctx context.Context, service, operation, errorMsg string, metadata map[string]interface{}) error
+	// Report logs a structured record, the request ID, client and user are
+	// taken from ctx when left empty
+	Report(ctx context.Context, entry ErrorLogEntry) error
 	Close() error
 }
 
-// RedisErrorLogger implements ErrorLogger using Redis
+// ErrorLogBatchWriter is an ErrorLogger that writes several records at once
+type ErrorLogBatchWriter interface {
+	ReportBatch(ctx context.Context, entries []ErrorLogEntry) error
+}
+
+// ErrorLogReader is an ErrorLogger whose errors can be queried back
+type ErrorLogReader interface {
+	QueryErrors(ctx context.Context, query ErrorLogQuery) (*ErrorLogPage, error)
+}
+
+// ErrorSeverity ranks the logged errors
+type ErrorSeverity string
+
+const (
+	SeverityWarning ErrorSeverity = "warning"
+	SeverityError   ErrorSeverity = "error"
+	// SeverityCritical records carry the stack of the caller
+	SeverityCritical ErrorSeverity = "critical"
+)
+
+// Error log sinks, see ErrorLogConfig.Sinks
+const (
+	ErrorLogSinkRedis  = "redis"
+	ErrorLogSinkMemory = "memory"
+	ErrorLogSinkFile   = "file"
+)
+
+// RedisErrorLogger implements ErrorLogger using a capped Redis stream, the
+// stream IDs identify the errors and order them by time
 type RedisErrorLogger struct {
-	client utils.RedisClientInterface
+	client   utils.RedisClientInterface
+	config   *ErrorLogConfig
+	lastTrim atomic.Int64
 }
 
 // NewRedisErrorLogger creates a new Redis-based error logger
-func NewRedisErrorLogger() (*RedisErrorLogger, error) {
+func NewRedisErrorLogger(config *ErrorLogConfig) (*RedisErrorLogger, error) {
+	if config == nil {
+		config = DefaultErrorLogConfig()
+	}
 	redisClient := redis.NewClient(&redis.Options{
 		Addr:     os.Getenv("REDIS_HOST") + ":" + os.Getenv("REDIS_PORT"),
 		Password: os.Getenv("REDIS_PASSWORD"),
@@ -39,21 +78,57 @@ func NewRedisErrorLogger() (*RedisErrorLogger, error) {
 		return nil, fmt.Errorf("failed to connect to Redis: %w", err)
 	}
 
-	return &RedisErrorLogger{client: redisClient}, nil
+	return &RedisErrorLogger{client: redisClient, config: config}, nil
 }
 
 // LogError logs an error to Redis
 func (r *RedisErrorLogger) LogError(ctx context.Context, service, operation, errorMsg string, metadata map[string]interface{}) error {
-	errorLog := map[string]interface{}{
-		"service":   service,
-		"operation": operation,
-		"error":     errorMsg,
-		"timestamp": time.Now().Unix(),
-		"metadata":  metadata,
+	return r.Report(ctx, errorLogEntry(service, operation, errorMsg, metadata))
+}
+
+// Report logs a record to Redis
+func (r *RedisErrorLogger) Report(ctx context.Context, entry ErrorLogEntry) error {
+	return r.ReportBatch(ctx, []ErrorLogEntry{entry})
+}
+
+// ReportBatch logs records to Redis in one round trip
+func (r *RedisErrorLogger) ReportBatch(ctx context.Context, entries []ErrorLogEntry) error {
+	pipe := r.client.Pipeline()
+	for _, entry := range entries {
+		values, err := prepareErrorLogEntry(ctx, entry).streamValues()
+		if err != nil {
+			return err
+		}
+		pipe.XAdd(ctx, &redis.XAddArgs{
+			Stream: r.config.Stream,
+			MaxLen: r.config.MaxEntries,
+			Approx: r.config.MaxEntries > 0,
+			Values: values,
+		})
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return err
+	}
+	return r.trim(ctx)
+}
+
+// trim drops the errors past the retention, at most once per
+// errorLogTrimInterval since XADD can only cap the stream length
+func (r *RedisErrorLogger) trim(ctx context.Context) error {
+	if r.config.Retention <= 0 {
+		return nil
+	}
+	now := time.Now()
+	last := r.lastTrim.Load()
+	if now.UnixMilli()-last < errorLogTrimInterval.Milliseconds() || !r.lastTrim.CompareAndSwap(last, now.UnixMilli()) {
+		return nil
 	}
 
-	key := fmt.Sprintf("errorlog:%s:%d", service, time.Now().Unix())
-	return r.client.HMSet(ctx, key, errorLog).Err()
+	minID := strconv.FormatInt(now.Add(-r.config.Retention).UnixMilli(), 10)
+	if err := r.client.XTrimMinIDApprox(ctx, r.config.Stream, minID, 0).Err(); err != nil {
+		return fmt.Errorf("failed to apply the error log retention: %w", err)
+	}
+	return nil
 }
 
 // Close closes the Redis connection
@@ -61,41 +136,74 @@ func (r *RedisErrorLogger) Close() error {
 	return r.client.Close()
 }
 
-// MemoryErrorLogger implements ErrorLogger using in-memory storage
+// MemoryErrorLogger implements ErrorLogger using in-memory storage, the
+// oldest errors are dropped past the retention or MaxEntries
 type MemoryErrorLogger struct {
-	errors []map[string]interface{}
+	errors []ErrorLogEntry
+	config *ErrorLogConfig
+	lastID errorLogID
 	mu     sync.RWMutex
 }
 
 // NewMemoryErrorLogger creates a new memory-based error logger
-func NewMemoryErrorLogger() *MemoryErrorLogger {
+func NewMemoryErrorLogger(config *ErrorLogConfig) *MemoryErrorLogger {
+	if config == nil {
+		config = DefaultErrorLogConfig()
+	}
 	return &MemoryErrorLogger{
-		errors: make([]map[string]interface{}, 0),
+		errors: make([]ErrorLogEntry, 0),
+		config: config,
 	}
 }
 
 // LogError logs an error to memory
 func (m *MemoryErrorLogger) LogError(ctx context.Context, service, operation, errorMsg string, metadata map[string]interface{}) error {
-	errorLog := map[string]interface{}{
-		"service":   service,
-		"operation": operation,
-		"error":     errorMsg,
-		"timestamp": time.Now().Unix(),
-		"metadata":  metadata,
-	}
+	return m.Report(ctx, errorLogEntry(service, operation, errorMsg, metadata))
+}
+
+// Report logs a record to memory
+func (m *MemoryErrorLogger) Report(ctx context.Context, entry ErrorLogEntry) error {
+	return m.ReportBatch(ctx, []ErrorLogEntry{entry})
+}
 
+// ReportBatch logs records to memory
+func (m *MemoryErrorLogger) ReportBatch(ctx context.Context, entries []ErrorLogEntry) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
-	m.errors = append(m.errors, errorLog)
+	for _, entry := range entries {
+		entry = prepareErrorLogEntry(ctx, entry)
+		// IDs follow the Redis stream format so cursors work the same on both backends
+		m.lastID = m.lastID.next(time.Now())
+		entry.ID = m.lastID.String()
+		m.errors = append(m.errors, entry)
+	}
+
+	drop := 0
+	if maxEntries := int(m.config.MaxEntries); maxEntries > 0 && len(m.errors) > maxEntries {
+		drop = len(m.errors) - maxEntries
+	}
+	if m.config.Retention > 0 {
+		oldest := uint64(time.Now().Add(-m.config.Retention).UnixMilli())
+		for drop < len(m.errors) {
+			id, err := parseErrorLogID(m.errors[drop].ID)
+			if err == nil && id.ms >= oldest {
+				break
+			}
+			drop++
+		}
+	}
+	if drop > 0 {
+		m.errors = append(m.errors[:0:0], m.errors[drop:]...)
+	}
 	return nil
 }
 
 // GetErrors returns all logged errors (for testing/debugging)
-func (m *MemoryErrorLogger) GetErrors() []map[string]interface{} {
+func (m *MemoryErrorLogger) GetErrors() []ErrorLogEntry {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
 	
-	errors := make([]map[string]interface{}, len(m.errors))
+	errors := make([]ErrorLogEntry, len(m.errors))
 	copy(errors, m.errors)
 	return errors
 }
@@ -105,66 +213,156 @@ func (m *MemoryErrorLogger) Close() error {
 	return nil
 }
 
-// NonBlockingErrorLogger wraps an ErrorLogger to make operations non-blocking
-type NonBlockingErrorLogger struct {
-	logger ErrorLogger
+// MultiErrorLogger writes every record to several sinks, queries are
+// answered by the first sink that can read its errors back
+type MultiErrorLogger struct {
+	loggers []ErrorLogger
+}
+
+// NewMultiErrorLogger creates a logger writing to every logger
+func NewMultiErrorLogger(loggers ...ErrorLogger) *MultiErrorLogger {
+	return &MultiErrorLogger{loggers: loggers}
+}
+
+// LogError logs an error to every sink
+func (m *MultiErrorLogger) LogError(ctx context.Context, service, operation, errorMsg string, metadata map[string]interface{}) error {
+	return m.Report(ctx, errorLogEntry(service, operation, errorMsg, metadata))
 }
 
-// NewNonBlockingErrorLogger creates a new non-blocking error logger
-func NewNonBlockingErrorLogger(logger ErrorLogger) *NonBlockingErrorLogger {
-	return &NonBlockingErrorLogger{logger: logger}
+// Report logs a record to every sink, a failing sink doesn't stop the others
+func (m *MultiErrorLogger) Report(ctx context.Context, entry ErrorLogEntry) error {
+	return m.ReportBatch(ctx, []ErrorLogEntry{entry})
 }
 
-// LogError logs an error without blocking the main application flow
-func (n *NonBlockingErrorLogger) LogError(ctx context.Context, service, operation, errorMsg string, metadata map[string]interface{}) error {
-	go func() {
-		if err := n.logger.LogError(ctx, service, operation, errorMsg, metadata); err != nil {
-			// Log the logging error to stderr to avoid infinite loops
-			log.Printf("Failed to log error: %v", err)
+// ReportBatch logs records to every sink, a failing sink doesn't stop the others
+func (m *MultiErrorLogger) ReportBatch(ctx context.Context, entries []ErrorLogEntry) error {
+	prepared := make([]ErrorLogEntry, len(entries))
+	for i, entry := range entries {
+		prepared[i] = prepareErrorLogEntry(ctx, entry)
+	}
+
+	var errs []error
+	for _, logger := range m.loggers {
+		if err := reportBatch(ctx, logger, prepared); err != nil {
+			errs = append(errs, err)
 		}
-	}()
-	return nil
+	}
+	return errors.Join(errs...)
 }
 
-// Close closes the underlying logger
-func (n *NonBlockingErrorLogger) Close() error {
-	return n.logger.Close()
+// QueryErrors queries the first sink that can be queried
+func (m *MultiErrorLogger) QueryErrors(ctx context.Context, query ErrorLogQuery) (*ErrorLogPage, error) {
+	for _, logger := range m.loggers {
+		if reader, ok := logger.(ErrorLogReader); ok {
+			return reader.QueryErrors(ctx, query)
+		}
+	}
+	return nil, ErrErrorLogNotQueryable
+}
+
+// Close closes every sink
+func (m *MultiErrorLogger) Close() error {
+	var errs []error
+	for _, logger := range m.loggers {
+		if err := logger.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// reportBatch writes entries in one call when logger supports it
+func reportBatch(ctx context.Context, logger ErrorLogger, entries []ErrorLogEntry) error {
+	if batchWriter, ok := logger.(ErrorLogBatchWriter); ok {
+		return batchWriter.ReportBatch(ctx, entries)
+	}
+	var errs []error
+	for _, entry := range entries {
+		if err := logger.Report(ctx, entry); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
 }
 
 // NewErrorLogger creates an error logger based on the specified type
 // This is a factory function to simplify logger creation
 func NewErrorLogger(loggerType string) (ErrorLogger, error) {
 	switch loggerType {
-	case "redis":
-		return NewRedisErrorLogger()
-	case "memory":
-		return NewMemoryErrorLogger(), nil
-	case "nonblocking":
-		memoryLogger := NewMemoryErrorLogger()
-		return NewNonBlockingErrorLogger(memoryLogger), nil
+	case ErrorLogSinkRedis:
+		return NewRedisErrorLogger(nil)
+	case ErrorLogSinkMemory:
+		return NewMemoryErrorLogger(nil), nil
+	case ErrorLogSinkFile:
+		return NewFileErrorLogger(nil)
+	case "async", "nonblocking":
+		memoryLogger := NewMemoryErrorLogger(nil)
+		return NewAsyncErrorLogger(memoryLogger, nil), nil
 	default:
 		// Default to memory logger
-		return NewMemoryErrorLogger(), nil
+		return NewMemoryErrorLogger(nil), nil
+	}
+}
+
+// SetupErrorLogger creates a logger writing to the configured sinks. A
+// Redis sink that can't connect is replaced by memory and a file sink that
+// can't be opened is left out, so errors are always kept somewhere.
+func SetupErrorLogger(config *ErrorLogConfig) ErrorLogger {
+	if config == nil {
+		config = DefaultErrorLogConfig()
+	}
+
+	var loggers []ErrorLogger
+	for _, sink := range config.Sinks {
+		switch sink {
+		case ErrorLogSinkRedis:
+			redisLogger, err := NewRedisErrorLogger(config)
+			if err != nil {
+				slog.Warn("Failed to setup Redis error logger, falling back to memory logger", "error", err)
+				loggers = append(loggers, NewMemoryErrorLogger(config))
+				continue
+			}
+			loggers = append(loggers, redisLogger)
+		case ErrorLogSinkMemory:
+			loggers = append(loggers, NewMemoryErrorLogger(config))
+		case ErrorLogSinkFile:
+			fileLogger, err := NewFileErrorLogger(config)
+			if err != nil {
+				slog.Warn("Failed to setup file error logger", "error", err)
+				continue
+			}
+			loggers = append(loggers, fileLogger)
+		default:
+			slog.Warn("Unknown error log sink", "sink", sink)
+		}
+	}
+
+	switch len(loggers) {
+	case 0:
+		return NewMemoryErrorLogger(config)
+	case 1:
+		return loggers[0]
+	default:
+		return NewMultiErrorLogger(loggers...)
 	}
 }
 
-// NewErrorLoggerWithDefaults creates a non-blocking memory logger by default
+// NewErrorLoggerWithDefaults creates a memory logger, its writes don't block
 // This is useful for testing and development
 func NewErrorLoggerWithDefaults() ErrorLogger {
-	memoryLogger := NewMemoryErrorLogger()
-	return NewNonBlockingErrorLogger(memoryLogger)
+	return NewMemoryErrorLogger(nil)
 }
 
-// ErrorLogConnect creates a Redis client (legacy function for backward compatibility)
+// ErrorLogConnect creates a Redis client and pings it. The client is returned
+// even when the ping fails, it connects on its own once Redis comes up and
+// its users fall back while it is down.
 func ErrorLogConnect() (utils.RedisClientInterface, error) {
 	redisClient := redis.NewClient(&redis.Options{
 		Addr: os.Getenv("REDIS_HOST") + ":" + os.Getenv("REDIS_PORT"),
 	})
 
-	ctx := context.Background()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
 	_, err := redisClient.Ping(ctx).Result()
-	if err != nil {
-		return nil, err
-	}
-	return redisClient, nil
+	return redisClient, err
 }
@@ -0,0 +1,208 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+)
+
+// ErrErrorLoggerClosed is returned for the errors reported after Shutdown
+var ErrErrorLoggerClosed = errors.New("error logger is closed")
+
+// AsyncErrorLogStats are the counters of an AsyncErrorLogger
+type AsyncErrorLogStats struct {
+	Queued   int   `json:"queued"`   // waiting for a worker
+	Enqueued int64 `json:"enqueued"` // accepted since startup
+	Written  int64 `json:"written"`
+	Failed   int64 `json:"failed"`  // the sink returned an error
+	Dropped  int64 `json:"dropped"` // the queue was full or the logger closed
+	Sampled  int64 `json:"sampled"` // left out by sampling while the queue was filling up
+}
+
+// AsyncErrorLogger hands the errors to a fixed pool of workers through a
+// bounded queue, so reporting never blocks and an error storm can't pile up
+// goroutines. Workers write what is queued in batches. When the queue fills
+// up, errors below critical are sampled and then every error is dropped,
+// both are counted.
+type AsyncErrorLogger struct {
+	logger ErrorLogger
+	config *ErrorLogConfig
+	queue  chan ErrorLogEntry
+
+	// mu orders the reports with Shutdown, nothing is queued once closed is set
+	mu      sync.RWMutex
+	closed  bool
+	closing chan struct{}
+	workers sync.WaitGroup
+
+	enqueued atomic.Int64
+	written  atomic.Int64
+	failed   atomic.Int64
+	dropped  atomic.Int64
+	sampled  atomic.Int64
+	sampling atomic.Uint64
+}
+
+// NewAsyncErrorLogger creates the logger and starts its workers, call
+// Shutdown or Close to write the queued errors and stop them
+func NewAsyncErrorLogger(logger ErrorLogger, config *ErrorLogConfig) *AsyncErrorLogger {
+	if config == nil {
+		config = DefaultErrorLogConfig()
+	}
+	a := &AsyncErrorLogger{
+		logger:  logger,
+		config:  config,
+		queue:   make(chan ErrorLogEntry, max(config.QueueSize, 1)),
+		closing: make(chan struct{}),
+	}
+	for i := 0; i < max(config.Workers, 1); i++ {
+		a.workers.Add(1)
+		go a.work()
+	}
+	return a
+}
+
+// LogError queues an error
+func (a *AsyncErrorLogger) LogError(ctx context.Context, service, operation, errorMsg string, metadata map[string]interface{}) error {
+	return a.Report(ctx, errorLogEntry(service, operation, errorMsg, metadata))
+}
+
+// Report queues a record, the request fields are read from ctx right away
+// since it may be cancelled before the record is written
+func (a *AsyncErrorLogger) Report(ctx context.Context, entry ErrorLogEntry) error {
+	entry = prepareErrorLogEntry(ctx, entry)
+
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	if a.closed {
+		a.dropped.Add(1)
+		return ErrErrorLoggerClosed
+	}
+	if a.sample(entry) {
+		a.sampled.Add(1)
+		return nil
+	}
+
+	select {
+	case a.queue <- entry:
+		a.enqueued.Add(1)
+	default:
+		a.dropped.Add(1)
+	}
+	return nil
+}
+
+// sample reports whether entry is left out to make room in the queue
+func (a *AsyncErrorLogger) sample(entry ErrorLogEntry) bool {
+	if a.config.SampleRate <= 1 || entry.Severity == SeverityCritical {
+		return false
+	}
+	if len(a.queue) < cap(a.queue)*3/4 {
+		return false
+	}
+	return a.sampling.Add(1)%uint64(a.config.SampleRate) != 0
+}
+
+func (a *AsyncErrorLogger) work() {
+	defer a.workers.Done()
+
+	batch := make([]ErrorLogEntry, 0, max(a.config.BatchSize, 1))
+	for {
+		select {
+		case entry := <-a.queue:
+			a.write(a.fill(append(batch[:0], entry)))
+		case <-a.closing:
+			for {
+				batch = a.fill(batch[:0])
+				if len(batch) == 0 {
+					return
+				}
+				a.write(batch)
+			}
+		}
+	}
+}
+
+// fill adds the queued entries to batch, without waiting for more
+func (a *AsyncErrorLogger) fill(batch []ErrorLogEntry) []ErrorLogEntry {
+	for len(batch) < cap(batch) {
+		select {
+		case entry := <-a.queue:
+			batch = append(batch, entry)
+		default:
+			return batch
+		}
+	}
+	return batch
+}
+
+func (a *AsyncErrorLogger) write(batch []ErrorLogEntry) {
+	ctx, cancel := context.WithTimeout(context.Background(), a.config.WriteTimeout)
+	defer cancel()
+
+	if err := reportBatch(ctx, a.logger, batch); err != nil {
+		a.failed.Add(int64(len(batch)))
+		// Not reported to the error log to avoid infinite loops
+		slog.Error("Failed to write to the error log", "count", len(batch), "error", err)
+		return
+	}
+	a.written.Add(int64(len(batch)))
+}
+
+// Stats returns the counters of the logger
+func (a *AsyncErrorLogger) Stats() AsyncErrorLogStats {
+	return AsyncErrorLogStats{
+		Queued:   len(a.queue),
+		Enqueued: a.enqueued.Load(),
+		Written:  a.written.Load(),
+		Failed:   a.failed.Load(),
+		Dropped:  a.dropped.Load(),
+		Sampled:  a.sampled.Load(),
+	}
+}
+
+// QueryErrors queries the underlying logger
+func (a *AsyncErrorLogger) QueryErrors(ctx context.Context, query ErrorLogQuery) (*ErrorLogPage, error) {
+	reader, ok := a.logger.(ErrorLogReader)
+	if !ok {
+		return nil, ErrErrorLogNotQueryable
+	}
+	return reader.QueryErrors(ctx, query)
+}
+
+// Shutdown stops accepting errors and waits for the workers to write the
+// queued ones until ctx is done, then closes the underlying logger. When ctx
+// ends first the underlying logger is left open for the workers still writing.
+func (a *AsyncErrorLogger) Shutdown(ctx context.Context) error {
+	a.mu.Lock()
+	if a.closed {
+		a.mu.Unlock()
+		return nil
+	}
+	a.closed = true
+	close(a.closing)
+	a.mu.Unlock()
+
+	drained := make(chan struct{})
+	go func() {
+		a.workers.Wait()
+		close(drained)
+	}()
+
+	select {
+	case <-drained:
+		return a.logger.Close()
+	case <-ctx.Done():
+		return fmt.Errorf("error log not drained, %d errors left: %w", len(a.queue), ctx.Err())
+	}
+}
+
+// Close is Shutdown bounded by DrainTimeout
+func (a *AsyncErrorLogger) Close() error {
+	ctx, cancel := context.WithTimeout(context.Background(), a.config.DrainTimeout)
+	defer cancel()
+	return a.Shutdown(ctx)
+}
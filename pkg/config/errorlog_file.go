@@ -0,0 +1,118 @@
+package config
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+const errorLogFilePrefix = "errors-"
+
+// FileErrorLogger implements ErrorLogger with one JSON line per error in a
+// file per UTC day, the files older than the retention are deleted when the
+// day changes
+type FileErrorLogger struct {
+	config *ErrorLogConfig
+	mu     sync.Mutex
+	file   *os.File
+	day    string
+}
+
+// NewFileErrorLogger creates a file-based error logger writing to config.Dir
+func NewFileErrorLogger(config *ErrorLogConfig) (*FileErrorLogger, error) {
+	if config == nil {
+		config = DefaultErrorLogConfig()
+	}
+	if err := os.MkdirAll(config.Dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create the error log directory: %w", err)
+	}
+	return &FileErrorLogger{config: config}, nil
+}
+
+// LogError logs an error to the file of the day
+func (f *FileErrorLogger) LogError(ctx context.Context, service, operation, errorMsg string, metadata map[string]interface{}) error {
+	return f.Report(ctx, errorLogEntry(service, operation, errorMsg, metadata))
+}
+
+// Report logs a record to the file of the day
+func (f *FileErrorLogger) Report(ctx context.Context, entry ErrorLogEntry) error {
+	return f.ReportBatch(ctx, []ErrorLogEntry{entry})
+}
+
+// ReportBatch logs records to the file of the day
+func (f *FileErrorLogger) ReportBatch(ctx context.Context, entries []ErrorLogEntry) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, entry := range entries {
+		entry = prepareErrorLogEntry(ctx, entry)
+		line, err := json.Marshal(entry)
+		if err != nil {
+			return fmt.Errorf("failed to encode error log entry: %w", err)
+		}
+
+		if day := entry.Timestamp.UTC().Format(time.DateOnly); day != f.day || f.file == nil {
+			if err := f.rotate(day); err != nil {
+				return err
+			}
+		}
+		if _, err := f.file.Write(append(line, '\n')); err != nil {
+			return fmt.Errorf("failed to write error log entry: %w", err)
+		}
+	}
+	return nil
+}
+
+// rotate switches to the file of day and applies the retention
+func (f *FileErrorLogger) rotate(day string) error {
+	if f.file != nil {
+		f.file.Close()
+		f.file = nil
+	}
+	file, err := os.OpenFile(filepath.Join(f.config.Dir, errorLogFilePrefix+day+".log"), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to open the error log file: %w", err)
+	}
+	f.file = file
+	f.day = day
+
+	if f.config.Retention > 0 {
+		f.purge(time.Now().UTC().Add(-f.config.Retention))
+	}
+	return nil
+}
+
+// purge deletes the files of the days ended before oldest
+func (f *FileErrorLogger) purge(oldest time.Time) {
+	paths, err := filepath.Glob(filepath.Join(f.config.Dir, errorLogFilePrefix+"*.log"))
+	if err != nil {
+		return
+	}
+	for _, path := range paths {
+		name := strings.TrimSuffix(strings.TrimPrefix(filepath.Base(path), errorLogFilePrefix), ".log")
+		day, err := time.Parse(time.DateOnly, name)
+		if err != nil || !day.AddDate(0, 0, 1).Before(oldest) {
+			continue
+		}
+		if err := os.Remove(path); err != nil {
+			slog.Warn("Failed to delete expired error log", "path", path, "error", err)
+		}
+	}
+}
+
+// Close closes the file of the day
+func (f *FileErrorLogger) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.file == nil {
+		return nil
+	}
+	err := f.file.Close()
+	f.file = nil
+	return err
+}
@@ -0,0 +1,362 @@
+package config
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hftamayo/gotodo/pkg/utils"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	defaultErrorLogPageSize = 50
+	// errorLogScanBatch is how many stream entries are read at once while
+	// looking for the errors matching a filter
+	errorLogScanBatch = 200
+	// errorLogTrimInterval spaces the retention trims of the Redis stream
+	errorLogTrimInterval = time.Minute
+)
+
+var (
+	// ErrInvalidErrorLogQuery is returned for a malformed cursor or time range
+	ErrInvalidErrorLogQuery = errors.New("invalid error log query")
+	// ErrErrorLogNotQueryable is returned when the logger can't read its errors back
+	ErrErrorLogNotQueryable = errors.New("error log can't be queried")
+)
+
+// ErrorLogConfig holds the error log storage configuration
+type ErrorLogConfig struct {
+	// Sinks are the stores every error is written to: redis, memory or file
+	Sinks []string
+	// Stream is the Redis stream holding the errors
+	Stream string
+	// MaxEntries caps the errors kept in Redis and memory, the oldest are dropped first
+	MaxEntries int64
+	// Retention is how long errors are kept by every sink, zero keeps them
+	// until MaxEntries or forever for files
+	Retention time.Duration
+	// Dir holds the daily files of the file sink
+	Dir string
+
+	// Asynchronous logging, see AsyncErrorLogger
+	QueueSize int
+	Workers   int
+	BatchSize int
+	// SampleRate keeps one in SampleRate errors below critical once the
+	// queue is three quarters full, 1 keeps them all until it is full
+	SampleRate   int
+	WriteTimeout time.Duration
+	// DrainTimeout bounds Close, Shutdown is bounded by its context instead
+	DrainTimeout time.Duration
+}
+
+// DefaultErrorLogConfig returns default error log configuration
+func DefaultErrorLogConfig() *ErrorLogConfig {
+	return &ErrorLogConfig{
+		Sinks:      getEnvAsListOrDefault("ERROR_LOG_SINKS", []string{ErrorLogSinkRedis}),
+		Stream:     getEnvOrDefault("ERROR_LOG_STREAM", "errorlog:stream"),
+		MaxEntries: int64(getEnvAsIntOrDefault("ERROR_LOG_MAX_ENTRIES", 10000)),
+		Retention:  getEnvAsDurationOrDefault("ERROR_LOG_RETENTION", 7*24*time.Hour),
+		Dir:        getEnvOrDefault("ERROR_LOG_DIR", "logs"),
+
+		QueueSize:    getEnvAsIntOrDefault("ERROR_LOG_QUEUE_SIZE", 1024),
+		Workers:      getEnvAsIntOrDefault("ERROR_LOG_WORKERS", 2),
+		BatchSize:    getEnvAsIntOrDefault("ERROR_LOG_BATCH_SIZE", 64),
+		SampleRate:   getEnvAsIntOrDefault("ERROR_LOG_SAMPLE_RATE", 10),
+		WriteTimeout: getEnvAsDurationOrDefault("ERROR_LOG_WRITE_TIMEOUT", 2*time.Second),
+		DrainTimeout: getEnvAsDurationOrDefault("ERROR_LOG_DRAIN_TIMEOUT", 5*time.Second),
+	}
+}
+
+// ErrorLogEntry is one logged error
+type ErrorLogEntry struct {
+	ID        string                 `json:"id,omitempty"` // set by the sinks that can be queried
+	Severity  ErrorSeverity          `json:"severity"`
+	Service   string                 `json:"service"`
+	Operation string                 `json:"operation"`
+	Error     string                 `json:"error"`
+	Timestamp time.Time              `json:"timestamp"`
+	RequestID string                 `json:"requestId,omitempty"`
+	User      string                 `json:"user,omitempty"`
+	ClientIP  string                 `json:"clientIp,omitempty"`
+	Client    string                 `json:"client,omitempty"`
+	Stack     string                 `json:"stack,omitempty"`
+	Metadata  map[string]interface{} `json:"metadata,omitempty"`
+}
+
+// ErrorLogQuery filters the logged errors, zero values don't filter
+type ErrorLogQuery struct {
+	Severity  ErrorSeverity
+	Service   string
+	Operation string
+	Since     time.Time
+	Until     time.Time
+	// Cursor is the NextCursor of the previous page
+	Cursor string
+	Limit  int
+}
+
+// ErrorLogPage is a page of errors, newest first
+type ErrorLogPage struct {
+	Entries    []ErrorLogEntry `json:"entries"`
+	NextCursor string          `json:"nextCursor,omitempty"`
+	HasMore    bool            `json:"hasMore"`
+}
+
+// errorLogEntry builds the record of ErrorLogger.LogError
+func errorLogEntry(service, operation, errorMsg string, metadata map[string]interface{}) ErrorLogEntry {
+	return ErrorLogEntry{
+		Severity:  SeverityError,
+		Service:   service,
+		Operation: operation,
+		Error:     errorMsg,
+		Metadata:  metadata,
+	}
+}
+
+// prepareErrorLogEntry fills the fields of entry left empty, from the
+// request ctx belongs to when known. It must run on the caller's goroutine
+// for the stack of a critical record to be useful.
+func prepareErrorLogEntry(ctx context.Context, entry ErrorLogEntry) ErrorLogEntry {
+	if entry.Severity == "" {
+		entry.Severity = SeverityError
+	}
+	if entry.Timestamp.IsZero() {
+		entry.Timestamp = time.Now().UTC()
+	}
+	if entry.RequestID == "" {
+		entry.RequestID = utils.RequestIDFromContext(ctx)
+	}
+	if client, ok := utils.ClientFromContext(ctx); ok {
+		if entry.ClientIP == "" {
+			entry.ClientIP = client.IP
+		}
+		if entry.Client == "" {
+			entry.Client = client.Identity
+		}
+		if entry.User == "" {
+			entry.User = client.User
+		}
+	}
+	if entry.Severity == SeverityCritical && entry.Stack == "" {
+		entry.Stack = string(debug.Stack())
+	}
+	return entry
+}
+
+func (e ErrorLogEntry) streamValues() (map[string]interface{}, error) {
+	values := map[string]interface{}{
+		"severity":   string(e.Severity),
+		"service":    e.Service,
+		"operation":  e.Operation,
+		"error":      e.Error,
+		"timestamp":  e.Timestamp.Format(time.RFC3339Nano),
+		"request_id": e.RequestID,
+		"user":       e.User,
+		"client_ip":  e.ClientIP,
+		"client":     e.Client,
+	}
+	if e.Stack != "" {
+		values["stack"] = e.Stack
+	}
+	if len(e.Metadata) > 0 {
+		metadata, err := json.Marshal(e.Metadata)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode error metadata: %w", err)
+		}
+		values["metadata"] = string(metadata)
+	}
+	return values, nil
+}
+
+func errorLogEntryFromStream(message redis.XMessage) ErrorLogEntry {
+	value := func(field string) string {
+		s, _ := message.Values[field].(string)
+		return s
+	}
+
+	entry := ErrorLogEntry{
+		ID:        message.ID,
+		Severity:  ErrorSeverity(value("severity")),
+		Service:   value("service"),
+		Operation: value("operation"),
+		Error:     value("error"),
+		RequestID: value("request_id"),
+		User:      value("user"),
+		ClientIP:  value("client_ip"),
+		Client:    value("client"),
+		Stack:     value("stack"),
+	}
+	entry.Timestamp, _ = time.Parse(time.RFC3339Nano, value("timestamp"))
+	if metadata := value("metadata"); metadata != "" {
+		// Undecodable metadata is left out rather than hiding the error
+		_ = json.Unmarshal([]byte(metadata), &entry.Metadata)
+	}
+	return entry
+}
+
+// errorLogID is a Redis stream ID, <milliseconds>-<sequence>
+type errorLogID struct {
+	ms  uint64
+	seq uint64
+}
+
+func parseErrorLogID(id string) (errorLogID, error) {
+	msPart, seqPart, found := strings.Cut(id, "-")
+	ms, err := strconv.ParseUint(msPart, 10, 64)
+	if err != nil || !found {
+		return errorLogID{}, fmt.Errorf("%w: malformed cursor %q", ErrInvalidErrorLogQuery, id)
+	}
+	seq, err := strconv.ParseUint(seqPart, 10, 64)
+	if err != nil {
+		return errorLogID{}, fmt.Errorf("%w: malformed cursor %q", ErrInvalidErrorLogQuery, id)
+	}
+	return errorLogID{ms: ms, seq: seq}, nil
+}
+
+func (id errorLogID) String() string {
+	return strconv.FormatUint(id.ms, 10) + "-" + strconv.FormatUint(id.seq, 10)
+}
+
+func (id errorLogID) less(other errorLogID) bool {
+	return id.ms < other.ms || (id.ms == other.ms && id.seq < other.seq)
+}
+
+// next returns the ID of an entry logged at t after id, IDs always grow even
+// when the clock goes back
+func (id errorLogID) next(t time.Time) errorLogID {
+	if ms := uint64(t.UnixMilli()); ms > id.ms {
+		return errorLogID{ms: ms}
+	}
+	return errorLogID{ms: id.ms, seq: id.seq + 1}
+}
+
+// errorLogFilter is a validated ErrorLogQuery
+type errorLogFilter struct {
+	ErrorLogQuery
+	cursor *errorLogID
+}
+
+// newErrorLogFilter validates query, the errors older than retention are
+// hidden even when a sink hasn't dropped them yet
+func newErrorLogFilter(query ErrorLogQuery, retention time.Duration) (*errorLogFilter, error) {
+	if !query.Since.IsZero() && !query.Until.IsZero() && query.Since.After(query.Until) {
+		return nil, fmt.Errorf("%w: since is after until", ErrInvalidErrorLogQuery)
+	}
+	if oldest := time.Now().Add(-retention); retention > 0 && query.Since.Before(oldest) {
+		query.Since = oldest
+	}
+	if query.Limit <= 0 {
+		query.Limit = defaultErrorLogPageSize
+	}
+
+	filter := &errorLogFilter{ErrorLogQuery: query}
+	if query.Cursor != "" {
+		cursor, err := parseErrorLogID(query.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		filter.cursor = &cursor
+	}
+	return filter, nil
+}
+
+// matches reports whether the entry logged under id belongs to the page,
+// the time range applies to the time of the ID
+func (f *errorLogFilter) matches(id errorLogID, entry ErrorLogEntry) bool {
+	if f.cursor != nil && !id.less(*f.cursor) {
+		return false
+	}
+	if !f.Since.IsZero() && id.ms < uint64(f.Since.UnixMilli()) {
+		return false
+	}
+	if !f.Until.IsZero() && id.ms > uint64(f.Until.UnixMilli()) {
+		return false
+	}
+	return (f.Severity == "" || entry.Severity == f.Severity) &&
+		(f.Service == "" || entry.Service == f.Service) &&
+		(f.Operation == "" || entry.Operation == f.Operation)
+}
+
+// add appends entry to page, it returns false once the page is full
+func (f *errorLogFilter) add(page *ErrorLogPage, entry ErrorLogEntry) bool {
+	if len(page.Entries) == f.Limit {
+		page.HasMore = true
+		page.NextCursor = page.Entries[len(page.Entries)-1].ID
+		return false
+	}
+	page.Entries = append(page.Entries, entry)
+	return true
+}
+
+// QueryErrors returns the errors matching query, newest first
+func (r *RedisErrorLogger) QueryErrors(ctx context.Context, query ErrorLogQuery) (*ErrorLogPage, error) {
+	filter, err := newErrorLogFilter(query, r.config.Retention)
+	if err != nil {
+		return nil, err
+	}
+
+	start, end := "-", "+"
+	if !filter.Since.IsZero() {
+		start = strconv.FormatInt(filter.Since.UnixMilli(), 10)
+	}
+	if !filter.Until.IsZero() {
+		end = strconv.FormatInt(filter.Until.UnixMilli(), 10)
+	}
+	if filter.cursor != nil {
+		end = "(" + filter.cursor.String()
+	}
+
+	page := &ErrorLogPage{Entries: []ErrorLogEntry{}}
+	batch := int64(max(filter.Limit+1, errorLogScanBatch))
+	for {
+		messages, err := r.client.XRevRangeN(ctx, r.config.Stream, end, start, batch).Result()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read the error log: %w", err)
+		}
+		for _, message := range messages {
+			id, err := parseErrorLogID(message.ID)
+			if err != nil {
+				continue
+			}
+			entry := errorLogEntryFromStream(message)
+			if filter.matches(id, entry) && !filter.add(page, entry) {
+				return page, nil
+			}
+		}
+		if int64(len(messages)) < batch {
+			return page, nil
+		}
+		end = "(" + messages[len(messages)-1].ID
+	}
+}
+
+// QueryErrors returns the errors matching query, newest first
+func (m *MemoryErrorLogger) QueryErrors(ctx context.Context, query ErrorLogQuery) (*ErrorLogPage, error) {
+	filter, err := newErrorLogFilter(query, m.config.Retention)
+	if err != nil {
+		return nil, err
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	page := &ErrorLogPage{Entries: []ErrorLogEntry{}}
+	for i := len(m.errors) - 1; i >= 0; i-- {
+		entry := m.errors[i]
+		id, err := parseErrorLogID(entry.ID)
+		if err != nil {
+			continue
+		}
+		if filter.matches(id, entry) && !filter.add(page, entry) {
+			break
+		}
+	}
+	return page, nil
+}
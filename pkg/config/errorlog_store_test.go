@@ -0,0 +1,357 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+// seededEntry is an error logged at a given time under a given service
+type seededEntry struct {
+	at      time.Time
+	service string
+}
+
+// errorLogBackend is a queryable sink holding seeded errors
+type errorLogBackend struct {
+	name  string
+	setup func(t *testing.T, config *ErrorLogConfig, entries []seededEntry) ErrorLogReader
+}
+
+// errorLogBackends seed their entries with IDs of their time, as both sinks
+// do when the errors are logged as they happen
+var errorLogBackends = []errorLogBackend{
+	{"memory", func(t *testing.T, config *ErrorLogConfig, entries []seededEntry) ErrorLogReader {
+		logger := NewMemoryErrorLogger(config)
+		for _, seeded := range entries {
+			logger.lastID = logger.lastID.next(seeded.at)
+			logger.errors = append(logger.errors, seededErrorLogEntry(logger.lastID.String(), seeded))
+		}
+		return logger
+	}},
+	{"redis", func(t *testing.T, config *ErrorLogConfig, entries []seededEntry) ErrorLogReader {
+		server := miniredis.RunT(t)
+		client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+		t.Cleanup(func() { client.Close() })
+
+		var lastID errorLogID
+		for _, seeded := range entries {
+			lastID = lastID.next(seeded.at)
+			values, err := seededErrorLogEntry("", seeded).streamValues()
+			if err != nil {
+				t.Fatalf("streamValues() error = %v", err)
+			}
+			if err := client.XAdd(context.Background(), &redis.XAddArgs{
+				Stream: config.Stream, ID: lastID.String(), Values: values,
+			}).Err(); err != nil {
+				t.Fatalf("XAdd() error = %v", err)
+			}
+		}
+		return &RedisErrorLogger{client: client, config: config}
+	}},
+}
+
+func seededErrorLogEntry(id string, seeded seededEntry) ErrorLogEntry {
+	return ErrorLogEntry{
+		ID:        id,
+		Severity:  SeverityError,
+		Service:   seeded.service,
+		Operation: "op",
+		Error:     "failure",
+		Timestamp: seeded.at.UTC(),
+	}
+}
+
+func testErrorLogConfig(retention time.Duration) *ErrorLogConfig {
+	return &ErrorLogConfig{Stream: "errorlog:test", Retention: retention}
+}
+
+// recentEntries are n errors of service one second apart, the newest a minute ago
+func recentEntries(n int, service func(i int) string) []seededEntry {
+	start := time.Now().Add(-time.Minute - time.Duration(n)*time.Second).Truncate(time.Millisecond)
+	entries := make([]seededEntry, n)
+	for i := range entries {
+		entries[i] = seededEntry{at: start.Add(time.Duration(i) * time.Second), service: service(i)}
+	}
+	return entries
+}
+
+func tasksService(int) string {
+	return "tasks"
+}
+
+// queryAll follows the cursors from query and returns every page
+func queryAll(t *testing.T, reader ErrorLogReader, query ErrorLogQuery) []*ErrorLogPage {
+	t.Helper()
+	var pages []*ErrorLogPage
+	for {
+		page, err := reader.QueryErrors(context.Background(), query)
+		if err != nil {
+			t.Fatalf("QueryErrors() error = %v", err)
+		}
+		pages = append(pages, page)
+		if !page.HasMore {
+			return pages
+		}
+		if page.NextCursor == "" {
+			t.Fatal("page has more errors but no cursor")
+		}
+		if len(pages) > 1000 {
+			t.Fatal("paging doesn't end")
+		}
+		query.Cursor = page.NextCursor
+	}
+}
+
+func TestErrorLogQueryPaging(t *testing.T) {
+	tests := []struct {
+		name      string
+		entries   int
+		limit     int
+		wantPages []int
+	}{
+		{"fewer errors than the limit", 3, 5, []int{3}},
+		{"exactly the limit", 5, 5, []int{5}},
+		{"one more than the limit", 6, 5, []int{5, 1}},
+		{"several pages", 7, 2, []int{2, 2, 2, 1}},
+		{"exact multiple of the limit", 6, 2, []int{2, 2, 2}},
+	}
+
+	for _, backend := range errorLogBackends {
+		for _, tt := range tests {
+			t.Run(backend.name+"/"+tt.name, func(t *testing.T) {
+				entries := recentEntries(tt.entries, tasksService)
+				reader := backend.setup(t, testErrorLogConfig(0), entries)
+
+				pages := queryAll(t, reader, ErrorLogQuery{Limit: tt.limit})
+				if len(pages) != len(tt.wantPages) {
+					t.Fatalf("got %d pages, want %d", len(pages), len(tt.wantPages))
+				}
+
+				next := len(entries) - 1
+				for i, page := range pages {
+					if len(page.Entries) != tt.wantPages[i] {
+						t.Fatalf("page %d has %d errors, want %d", i, len(page.Entries), tt.wantPages[i])
+					}
+					last := i == len(pages)-1
+					if page.HasMore == last || (page.NextCursor == "") != last {
+						t.Fatalf("page %d: hasMore %v cursor %q, want more pages %v", i, page.HasMore, page.NextCursor, !last)
+					}
+					if !last && page.NextCursor != page.Entries[len(page.Entries)-1].ID {
+						t.Fatalf("page %d cursor %q, want the ID of its last error", i, page.NextCursor)
+					}
+					// Newest first, without gaps or repeats across pages
+					for _, entry := range page.Entries {
+						if !entry.Timestamp.Equal(entries[next].at) {
+							t.Fatalf("page %d: got the error of %s, want %s", i, entry.Timestamp, entries[next].at)
+						}
+						next--
+					}
+				}
+				if next != -1 {
+					t.Fatalf("%d errors were not returned", next+1)
+				}
+			})
+		}
+	}
+}
+
+func TestErrorLogQueryFilterAcrossScanBatches(t *testing.T) {
+	// The matching errors are spread over several reads of errorLogScanBatch
+	total := 3*errorLogScanBatch + 50
+	service := func(i int) string {
+		if i%97 == 0 {
+			return "rare"
+		}
+		return "tasks"
+	}
+	var want int
+	for i := 0; i < total; i++ {
+		if service(i) == "rare" {
+			want++
+		}
+	}
+
+	for _, backend := range errorLogBackends {
+		t.Run(backend.name, func(t *testing.T) {
+			reader := backend.setup(t, testErrorLogConfig(0), recentEntries(total, service))
+
+			var got int
+			for _, page := range queryAll(t, reader, ErrorLogQuery{Service: "rare", Limit: 2}) {
+				for _, entry := range page.Entries {
+					if entry.Service != "rare" {
+						t.Fatalf("filtered page holds a %s error", entry.Service)
+					}
+					got++
+				}
+			}
+			if got != want {
+				t.Fatalf("got %d rare errors, want %d", got, want)
+			}
+
+			// A page larger than a batch
+			page, err := reader.QueryErrors(context.Background(), ErrorLogQuery{Service: "tasks", Limit: errorLogScanBatch + 10})
+			if err != nil {
+				t.Fatalf("QueryErrors() error = %v", err)
+			}
+			if len(page.Entries) != errorLogScanBatch+10 || !page.HasMore {
+				t.Fatalf("got %d errors hasMore %v, want %d and more", len(page.Entries), page.HasMore, errorLogScanBatch+10)
+			}
+		})
+	}
+}
+
+func TestErrorLogQueryTimeRange(t *testing.T) {
+	for _, backend := range errorLogBackends {
+		t.Run(backend.name, func(t *testing.T) {
+			entries := recentEntries(10, tasksService)
+			reader := backend.setup(t, testErrorLogConfig(0), entries)
+
+			page, err := reader.QueryErrors(context.Background(), ErrorLogQuery{Since: entries[3].at, Until: entries[5].at})
+			if err != nil {
+				t.Fatalf("QueryErrors() error = %v", err)
+			}
+			if len(page.Entries) != 3 || !page.Entries[0].Timestamp.Equal(entries[5].at) || !page.Entries[2].Timestamp.Equal(entries[3].at) {
+				t.Fatalf("got %d errors, want the 3 errors from since to until, bounds included", len(page.Entries))
+			}
+
+			// The cursor applies within the range
+			first, _ := reader.QueryErrors(context.Background(), ErrorLogQuery{Since: entries[3].at, Limit: 2})
+			rest, err := reader.QueryErrors(context.Background(), ErrorLogQuery{Since: entries[3].at, Cursor: first.NextCursor})
+			if err != nil {
+				t.Fatalf("QueryErrors() error = %v", err)
+			}
+			if len(first.Entries)+len(rest.Entries) != 7 || rest.HasMore {
+				t.Fatalf("got %d then %d errors, want 7 since entry 3", len(first.Entries), len(rest.Entries))
+			}
+		})
+	}
+}
+
+func TestErrorLogQueryHidesErrorsPastRetention(t *testing.T) {
+	now := time.Now().Truncate(time.Millisecond)
+	entries := []seededEntry{
+		{at: now.Add(-3 * time.Hour), service: "tasks"},
+		{at: now.Add(-2 * time.Hour), service: "tasks"},
+		{at: now.Add(-30 * time.Minute), service: "tasks"},
+		{at: now.Add(-time.Minute), service: "tasks"},
+	}
+
+	for _, backend := range errorLogBackends {
+		t.Run(backend.name, func(t *testing.T) {
+			// The sinks haven't dropped the old errors yet, queries hide them anyway
+			reader := backend.setup(t, testErrorLogConfig(time.Hour), entries)
+
+			page, err := reader.QueryErrors(context.Background(), ErrorLogQuery{})
+			if err != nil {
+				t.Fatalf("QueryErrors() error = %v", err)
+			}
+			if len(page.Entries) != 2 {
+				t.Fatalf("got %d errors, want the 2 within the retention", len(page.Entries))
+			}
+
+			// An older since is clamped to the retention
+			page, _ = reader.QueryErrors(context.Background(), ErrorLogQuery{Since: now.Add(-24 * time.Hour)})
+			if len(page.Entries) != 2 {
+				t.Fatalf("got %d errors since a day ago, want 2", len(page.Entries))
+			}
+
+			all := backend.setup(t, testErrorLogConfig(0), entries)
+			if page, _ := all.QueryErrors(context.Background(), ErrorLogQuery{}); len(page.Entries) != 4 {
+				t.Fatalf("got %d errors without retention, want 4", len(page.Entries))
+			}
+		})
+	}
+}
+
+func TestNewErrorLogFilter(t *testing.T) {
+	now := time.Now()
+
+	tests := []struct {
+		name      string
+		query     ErrorLogQuery
+		retention time.Duration
+		wantErr   bool
+		check     func(t *testing.T, filter *errorLogFilter)
+	}{
+		{"default limit", ErrorLogQuery{}, 0, false, func(t *testing.T, f *errorLogFilter) {
+			if f.Limit != defaultErrorLogPageSize || !f.Since.IsZero() || f.cursor != nil {
+				t.Fatalf("filter = limit %d since %s cursor %v", f.Limit, f.Since, f.cursor)
+			}
+		}},
+		{"since clamped to the retention", ErrorLogQuery{}, time.Hour, false, func(t *testing.T, f *errorLogFilter) {
+			if diff := f.Since.Sub(now.Add(-time.Hour)); diff < 0 || diff > time.Second {
+				t.Fatalf("since = %s, want an hour ago", f.Since)
+			}
+		}},
+		{"since within the retention kept", ErrorLogQuery{Since: now.Add(-time.Minute)}, time.Hour, false, func(t *testing.T, f *errorLogFilter) {
+			if !f.Since.Equal(now.Add(-time.Minute)) {
+				t.Fatalf("since = %s, want a minute ago", f.Since)
+			}
+		}},
+		{"cursor", ErrorLogQuery{Cursor: "1700000000000-3"}, 0, false, func(t *testing.T, f *errorLogFilter) {
+			if f.cursor == nil || *f.cursor != (errorLogID{ms: 1700000000000, seq: 3}) {
+				t.Fatalf("cursor = %v", f.cursor)
+			}
+		}},
+		{"since after until", ErrorLogQuery{Since: now, Until: now.Add(-time.Minute)}, 0, true, nil},
+		{"cursor without sequence", ErrorLogQuery{Cursor: "1700000000000"}, 0, true, nil},
+		{"cursor with letters", ErrorLogQuery{Cursor: "abc-1"}, 0, true, nil},
+		{"cursor with bad sequence", ErrorLogQuery{Cursor: "1700000000000-x"}, 0, true, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filter, err := newErrorLogFilter(tt.query, tt.retention)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidErrorLogQuery) {
+					t.Fatalf("newErrorLogFilter() error = %v, want %v", err, ErrInvalidErrorLogQuery)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("newErrorLogFilter() error = %v", err)
+			}
+			tt.check(t, filter)
+		})
+	}
+}
+
+func TestErrorLogQueryRejectsInvalidQueries(t *testing.T) {
+	for _, backend := range errorLogBackends {
+		t.Run(backend.name, func(t *testing.T) {
+			reader := backend.setup(t, testErrorLogConfig(0), recentEntries(1, tasksService))
+			for _, query := range []ErrorLogQuery{
+				{Cursor: "not-a-cursor"},
+				{Since: time.Now(), Until: time.Now().Add(-time.Hour)},
+			} {
+				if _, err := reader.QueryErrors(context.Background(), query); !errors.Is(err, ErrInvalidErrorLogQuery) {
+					t.Fatalf("QueryErrors(%+v) error = %v, want %v", query, err, ErrInvalidErrorLogQuery)
+				}
+			}
+		})
+	}
+}
+
+func TestMemoryErrorLoggerDropsErrorsPastRetentionAndMaxEntries(t *testing.T) {
+	logger := NewMemoryErrorLogger(&ErrorLogConfig{MaxEntries: 3, Retention: time.Hour})
+	old := time.Now().Add(-2 * time.Hour)
+	logger.lastID = logger.lastID.next(old)
+	logger.errors = append(logger.errors, seededErrorLogEntry(logger.lastID.String(), seededEntry{at: old, service: "tasks"}))
+
+	for i := 0; i < 5; i++ {
+		if err := logger.LogError(context.Background(), "tasks", "op", fmt.Sprintf("error %d", i), nil); err != nil {
+			t.Fatalf("LogError() error = %v", err)
+		}
+	}
+
+	errs := logger.GetErrors()
+	if len(errs) != 3 || errs[0].Error != "error 2" || errs[2].Error != "error 4" {
+		t.Fatalf("kept %d errors, want the 3 newest", len(errs))
+	}
+}
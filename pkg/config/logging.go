@@ -0,0 +1,39 @@
+package config
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+
+	"github.com/hftamayo/gotodo/pkg/logging"
+)
+
+// LoggingConfig holds the application log configuration
+type LoggingConfig struct {
+	Level  string // debug, info, warn or error
+	Format string // json or text
+}
+
+// DefaultLoggingConfig returns default logging configuration
+func DefaultLoggingConfig() *LoggingConfig {
+	return &LoggingConfig{
+		Level:  getEnvOrDefault("LOG_LEVEL", "info"),
+		Format: getEnvOrDefault("LOG_FORMAT", logging.FormatJSON),
+	}
+}
+
+// SetupLogging makes a logger writing to stdout the default one, the log
+// package writes through it as well
+func SetupLogging(config *LoggingConfig) (*slog.Logger, error) {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(config.Level)); err != nil {
+		return nil, fmt.Errorf("invalid log level %q: %w", config.Level, err)
+	}
+	if config.Format != logging.FormatJSON && config.Format != logging.FormatText {
+		return nil, fmt.Errorf("invalid log format %q", config.Format)
+	}
+
+	logger := logging.New(os.Stdout, config.Format, level)
+	slog.SetDefault(logger)
+	return logger, nil
+}
@@ -0,0 +1,19 @@
+package config
+
+// MetricsConfig holds the configuration of the Prometheus endpoint
+type MetricsConfig struct {
+	// Token is expected as a bearer token, metrics are disabled when it is empty
+	Token string
+	// Addr is the listener of the endpoint, apart from the API
+	Addr string
+	Path string
+}
+
+// DefaultMetricsConfig returns default metrics configuration
+func DefaultMetricsConfig() *MetricsConfig {
+	return &MetricsConfig{
+		Token: getEnvOrDefault("METRICS_TOKEN", ""),
+		Addr:  getEnvOrDefault("METRICS_ADDR", ":9090"),
+		Path:  getEnvOrDefault("METRICS_PATH", "/metrics"),
+	}
+}
@@ -0,0 +1,47 @@
+package config
+
+import "time"
+
+// NotificationConfig holds the reminder scheduler and notifier configuration
+type NotificationConfig struct {
+	Enabled         bool
+	ScanInterval    time.Duration
+	LookAhead       time.Duration
+	DefaultLeadTime time.Duration
+	SMTP            SMTPConfig
+	WebhookTimeout  time.Duration
+}
+
+// SMTPConfig holds the settings used by the email notifier
+type SMTPConfig struct {
+	Host     string
+	Port     string
+	Username string
+	Password string
+	From     string
+}
+
+// DefaultReminderScanInterval is used when REMINDER_SCAN_INTERVAL is not positive
+const DefaultReminderScanInterval = time.Minute
+
+// DefaultNotificationConfig returns default notification configuration
+func DefaultNotificationConfig() *NotificationConfig {
+	config := &NotificationConfig{
+		Enabled:         getEnvOrDefault("REMINDERS_ENABLED", "true") == "true",
+		ScanInterval:    getEnvAsDurationOrDefault("REMINDER_SCAN_INTERVAL", DefaultReminderScanInterval),
+		LookAhead:       getEnvAsDurationOrDefault("REMINDER_LOOKAHEAD", 24*time.Hour),
+		DefaultLeadTime: getEnvAsDurationOrDefault("REMINDER_DEFAULT_LEAD_TIME", time.Hour),
+		SMTP: SMTPConfig{
+			Host:     getEnvOrDefault("SMTP_HOST", ""),
+			Port:     getEnvOrDefault("SMTP_PORT", "587"),
+			Username: getEnvOrDefault("SMTP_USERNAME", ""),
+			Password: getEnvOrDefault("SMTP_PASSWORD", ""),
+			From:     getEnvOrDefault("SMTP_FROM", "gotodo@localhost"),
+		},
+		WebhookTimeout: getEnvAsDurationOrDefault("REMINDER_WEBHOOK_TIMEOUT", 10*time.Second),
+	}
+	if config.ScanInterval <= 0 {
+		config.ScanInterval = DefaultReminderScanInterval
+	}
+	return config
+}
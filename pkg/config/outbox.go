@@ -0,0 +1,38 @@
+package config
+
+import "time"
+
+// OutboxConfig holds the transactional outbox relay configuration
+type OutboxConfig struct {
+	Enabled           bool
+	PollInterval      time.Duration
+	BatchSize         int
+	Retention         time.Duration
+	RedisStream       string
+	RedisStreamMaxLen int64
+
+	// A failed event is retried with exponential backoff and dead-lettered
+	// after MaxAttempts, the later events of its task are then published
+	MaxAttempts    int
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	// ClaimTimeout bounds the publishing of a batch, past it the events of a
+	// relay that stopped are claimed again
+	ClaimTimeout time.Duration
+}
+
+// DefaultOutboxConfig returns default outbox configuration
+func DefaultOutboxConfig() *OutboxConfig {
+	return &OutboxConfig{
+		Enabled:           getEnvOrDefault("OUTBOX_ENABLED", "true") == "true",
+		PollInterval:      getEnvAsDurationOrDefault("OUTBOX_POLL_INTERVAL", time.Second),
+		BatchSize:         getEnvAsIntOrDefault("OUTBOX_BATCH_SIZE", 100),
+		Retention:         getEnvAsDurationOrDefault("OUTBOX_RETENTION", 24*time.Hour),
+		RedisStream:       getEnvOrDefault("OUTBOX_REDIS_STREAM", "tasks:events:stream"),
+		RedisStreamMaxLen: int64(getEnvAsIntOrDefault("OUTBOX_REDIS_STREAM_MAXLEN", 10000)),
+		MaxAttempts:       getEnvAsIntOrDefault("OUTBOX_MAX_ATTEMPTS", 10),
+		InitialBackoff:    getEnvAsDurationOrDefault("OUTBOX_INITIAL_BACKOFF", time.Second),
+		MaxBackoff:        getEnvAsDurationOrDefault("OUTBOX_MAX_BACKOFF", 5*time.Minute),
+		ClaimTimeout:      getEnvAsDurationOrDefault("OUTBOX_CLAIM_TIMEOUT", time.Minute),
+	}
+}
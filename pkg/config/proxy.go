@@ -0,0 +1,41 @@
+package config
+
+import (
+	"strings"
+)
+
+// ProxyConfig holds the configuration used to find the client address of a
+// request that went through load balancers or reverse proxies
+type ProxyConfig struct {
+	// TrustedProxies are the CIDRs or addresses whose forwarding headers are
+	// believed, empty trusts no proxy and uses the peer address
+	TrustedProxies []string
+
+	// ClientIPHeaders are read in order, the first one present is used. Only
+	// the header the proxies rewrite should be listed, any other is passed
+	// through as the client sent it and would let it pick its address.
+	ClientIPHeaders []string
+}
+
+// DefaultProxyConfig returns default proxy configuration
+func DefaultProxyConfig() *ProxyConfig {
+	return &ProxyConfig{
+		TrustedProxies:  getEnvAsListOrDefault("TRUSTED_PROXIES", nil),
+		ClientIPHeaders: getEnvAsListOrDefault("CLIENT_IP_HEADERS", []string{"X-Forwarded-For"}),
+	}
+}
+
+func getEnvAsListOrDefault(key string, defaultValue []string) []string {
+	value := getEnvOrDefault(key, "")
+	if value == "" {
+		return defaultValue
+	}
+
+	var values []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			values = append(values, item)
+		}
+	}
+	return values
+}
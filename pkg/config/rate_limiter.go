@@ -1,20 +1,139 @@
 package config
 
 import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
 	"time"
 
 	"github.com/hftamayo/gotodo/pkg/utils"
 )
 
-func SetupRateLimiter(redisClient utils.RedisClientInterface, defaultLimit int, window time.Duration) *utils.RateLimiter {
-    rateLimiter := utils.NewRateLimiter(redisClient)
-    
-    rateLimiter.Window = window
-    
-    // Configure limits for different operation types
-    rateLimiter.SetLimitForOperation(utils.OperationRead, 100)     // 100 read requests per minute
-    rateLimiter.SetLimitForOperation(utils.OperationWrite, 30)     // 30 write requests per minute
-    rateLimiter.SetLimitForOperation(utils.OperationPrefetch, 200) // 200 prefetch requests per minute
-    
-    return rateLimiter
+// RateLimiterConfig holds the rate limiter configuration
+type RateLimiterConfig struct {
+	// Policy is built from the RATE_LIMIT_* variables, PolicyFile (JSON)
+	// overrides it and is reloaded when it changes or on SIGHUP
+	Policy         *utils.RateLimitPolicy
+	PolicyFile     string
+	ReloadInterval time.Duration
+
+	// RedisTimeout bounds each Redis call, past it the request is counted in memory
+	RedisTimeout time.Duration
+}
+
+// DefaultRateLimiterConfig returns default rate limiter configuration
+func DefaultRateLimiterConfig() *RateLimiterConfig {
+	policy := utils.DefaultRateLimitPolicy()
+	policy.Algorithm = utils.RateLimitAlgorithm(getEnvOrDefault("RATE_LIMIT_ALGORITHM", string(policy.Algorithm)))
+	policy.Window = utils.Duration(getEnvAsDurationOrDefault("RATE_LIMIT_WINDOW", time.Duration(policy.Window)))
+	policy.Operations[utils.OperationRead] = int64(getEnvAsIntOrDefault("RATE_LIMIT_READ", int(policy.Operations[utils.OperationRead])))
+	policy.Operations[utils.OperationWrite] = int64(getEnvAsIntOrDefault("RATE_LIMIT_WRITE", int(policy.Operations[utils.OperationWrite])))
+	policy.Operations[utils.OperationPrefetch] = int64(getEnvAsIntOrDefault("RATE_LIMIT_PREFETCH", int(policy.Operations[utils.OperationPrefetch])))
+
+	return &RateLimiterConfig{
+		Policy:         policy,
+		PolicyFile:     getEnvOrDefault("RATE_LIMIT_POLICY_FILE", ""),
+		ReloadInterval: getEnvAsDurationOrDefault("RATE_LIMIT_POLICY_RELOAD_INTERVAL", 30*time.Second),
+		RedisTimeout:   getEnvAsDurationOrDefault("RATE_LIMIT_REDIS_TIMEOUT", 100*time.Millisecond),
+	}
+}
+
+// SetupRateLimiter creates the rate limiter with the configured policy, an
+// invalid policy is reported and the default limits are used instead
+func SetupRateLimiter(redisClient utils.RedisClientInterface, config *RateLimiterConfig) *utils.RateLimiter {
+	if config == nil {
+		config = DefaultRateLimiterConfig()
+	}
+	rateLimiter := utils.NewRateLimiter(redisClient)
+	if config.RedisTimeout > 0 {
+		rateLimiter.RedisTimeout = config.RedisTimeout
+	}
+
+	policy, err := LoadRateLimitPolicy(config)
+	if err == nil {
+		err = rateLimiter.SetPolicy(policy)
+	}
+	if err != nil {
+		slog.Warn("Invalid rate limit policy, using the default rate limits", "error", err)
+	}
+
+	return rateLimiter
+}
+
+// LoadRateLimitPolicy reads the policy file over the policy from the
+// environment, the fields missing from the file keep their value
+func LoadRateLimitPolicy(config *RateLimiterConfig) (*utils.RateLimitPolicy, error) {
+	policy := config.Policy
+	if policy == nil {
+		policy = utils.DefaultRateLimitPolicy()
+	}
+	if config.PolicyFile == "" {
+		return policy, policy.Validate()
+	}
+
+	data, err := os.ReadFile(config.PolicyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read rate limit policy: %w", err)
+	}
+	// Decode over a copy, the environment policy stays the base of every reload
+	fromFile := policy.Clone()
+	if err := json.Unmarshal(data, fromFile); err != nil {
+		return nil, fmt.Errorf("failed to parse rate limit policy %s: %w", config.PolicyFile, err)
+	}
+	return fromFile, fromFile.Validate()
+}
+
+// WatchRateLimitPolicy reloads the policy file when its modification time
+// changes or the process receives SIGHUP, until ctx is cancelled. A policy
+// that fails validation is reported and the current one is kept.
+func WatchRateLimitPolicy(ctx context.Context, rateLimiter *utils.RateLimiter, config *RateLimiterConfig) {
+	if config.PolicyFile == "" || config.ReloadInterval <= 0 {
+		return
+	}
+
+	hangup := make(chan os.Signal, 1)
+	signal.Notify(hangup, syscall.SIGHUP)
+	defer signal.Stop(hangup)
+
+	ticker := time.NewTicker(config.ReloadInterval)
+	defer ticker.Stop()
+
+	lastModified := policyModTime(config.PolicyFile)
+	reload := func() {
+		lastModified = policyModTime(config.PolicyFile)
+		policy, err := LoadRateLimitPolicy(config)
+		if err == nil {
+			err = rateLimiter.SetPolicy(policy)
+		}
+		if err != nil {
+			slog.Warn("Keeping the current rate limit policy", "error", err)
+			return
+		}
+		slog.Info("Rate limit policy reloaded", "file", config.PolicyFile)
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hangup:
+			reload()
+		case <-ticker.C:
+			if modified := policyModTime(config.PolicyFile); !modified.Equal(lastModified) {
+				reload()
+			}
+		}
+	}
+}
+
+func policyModTime(path string) time.Time {
+	info, err := os.Stat(path)
+	if err != nil {
+		return time.Time{}
+	}
+	return info.ModTime()
 }
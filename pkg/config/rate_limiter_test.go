@@ -0,0 +1,106 @@
+package config_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/hftamayo/gotodo/pkg/config"
+	"github.com/hftamayo/gotodo/pkg/utils"
+)
+
+func writePolicy(t *testing.T, path, policy string, modified time.Time) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(policy), 0o600); err != nil {
+		t.Fatalf("failed to write the policy: %v", err)
+	}
+	// The watcher compares modification times, set them apart explicitly
+	if err := os.Chtimes(path, modified, modified); err != nil {
+		t.Fatalf("failed to set the policy modification time: %v", err)
+	}
+}
+
+func TestLoadRateLimitPolicy(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "policy.json")
+	cfg := &config.RateLimiterConfig{Policy: utils.DefaultRateLimitPolicy(), PolicyFile: path}
+
+	writePolicy(t, path, `{"algorithm": "token_bucket", "operations": {"write": 5}}`, time.Now())
+	policy, err := config.LoadRateLimitPolicy(cfg)
+	if err != nil {
+		t.Fatalf("LoadRateLimitPolicy() error = %v", err)
+	}
+	if policy.Algorithm != utils.AlgorithmTokenBucket || policy.Operations[utils.OperationWrite] != 5 {
+		t.Fatalf("policy = %s with write %d, want token_bucket with write 5", policy.Algorithm, policy.Operations[utils.OperationWrite])
+	}
+	// Fields missing from the file keep the environment policy
+	if policy.Operations[utils.OperationRead] != 100 || time.Duration(policy.Window) != time.Minute {
+		t.Fatalf("read %d per %s, want the base 100 per 1m", policy.Operations[utils.OperationRead], time.Duration(policy.Window))
+	}
+	if cfg.Policy.Algorithm != utils.AlgorithmSlidingWindow {
+		t.Fatal("loading the file changed the base policy")
+	}
+
+	writePolicy(t, path, `{"algorithm": "leaky_bucket"}`, time.Now())
+	if _, err := config.LoadRateLimitPolicy(cfg); err == nil {
+		t.Fatal("LoadRateLimitPolicy() accepted an invalid policy")
+	}
+	writePolicy(t, path, `{"operations": `, time.Now())
+	if _, err := config.LoadRateLimitPolicy(cfg); err == nil {
+		t.Fatal("LoadRateLimitPolicy() accepted malformed JSON")
+	}
+}
+
+func TestWatchRateLimitPolicyReloads(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "policy.json")
+	start := time.Now().Add(-time.Hour)
+	writePolicy(t, path, `{"operations": {"read": 10}}`, start)
+
+	cfg := &config.RateLimiterConfig{Policy: utils.DefaultRateLimitPolicy(), PolicyFile: path, ReloadInterval: 10 * time.Millisecond}
+	limiter := config.SetupRateLimiter(nil, cfg)
+	if got := limiter.GetLimitForOperation(utils.OperationRead); got != 10 {
+		t.Fatalf("read limit = %d, want 10 from the file", got)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		config.WatchRateLimitPolicy(ctx, limiter, cfg)
+		close(done)
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	// The watcher may not have read the first modification time yet, the
+	// file is touched again until the change is seen
+	modified := start
+	reloadPolicy(t, limiter, path, `{"operations": {"read": 20}}`, &modified, 20)
+
+	// An invalid policy keeps the current one, a later valid one is applied
+	modified = modified.Add(time.Minute)
+	writePolicy(t, path, `{"operations": {"read": 0}}`, modified)
+	time.Sleep(50 * time.Millisecond)
+	if got := limiter.GetLimitForOperation(utils.OperationRead); got != 20 {
+		t.Fatalf("read limit = %d after an invalid policy, want 20 kept", got)
+	}
+	reloadPolicy(t, limiter, path, `{"operations": {"read": 30}}`, &modified, 30)
+}
+
+// reloadPolicy writes policy with a later modification time until the read
+// limit of limiter is want
+func reloadPolicy(t *testing.T, limiter *utils.RateLimiter, path, policy string, modified *time.Time, want int) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		*modified = modified.Add(time.Minute)
+		writePolicy(t, path, policy, *modified)
+		time.Sleep(20 * time.Millisecond)
+		if limiter.GetLimitForOperation(utils.OperationRead) == want {
+			return
+		}
+	}
+	t.Fatalf("read limit = %d, want %d after the reload", limiter.GetLimitForOperation(utils.OperationRead), want)
+}
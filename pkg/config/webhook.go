@@ -0,0 +1,27 @@
+package config
+
+import "time"
+
+// WebhookConfig holds the outbound webhook delivery configuration
+type WebhookConfig struct {
+	Workers           int
+	QueueSize         int
+	MaxAttempts       int
+	InitialBackoff    time.Duration
+	MaxBackoff        time.Duration
+	RequestTimeout    time.Duration
+	RetryPollInterval time.Duration
+}
+
+// DefaultWebhookConfig returns default webhook configuration
+func DefaultWebhookConfig() *WebhookConfig {
+	return &WebhookConfig{
+		Workers:           getEnvAsIntOrDefault("WEBHOOK_WORKERS", 4),
+		QueueSize:         getEnvAsIntOrDefault("WEBHOOK_QUEUE_SIZE", 1000),
+		MaxAttempts:       getEnvAsIntOrDefault("WEBHOOK_MAX_ATTEMPTS", 8),
+		InitialBackoff:    getEnvAsDurationOrDefault("WEBHOOK_INITIAL_BACKOFF", 5*time.Second),
+		MaxBackoff:        getEnvAsDurationOrDefault("WEBHOOK_MAX_BACKOFF", time.Hour),
+		RequestTimeout:    getEnvAsDurationOrDefault("WEBHOOK_REQUEST_TIMEOUT", 10*time.Second),
+		RetryPollInterval: getEnvAsDurationOrDefault("WEBHOOK_RETRY_POLL_INTERVAL", 5*time.Second),
+	}
+}
@@ -0,0 +1,50 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// Subscriber receives published events, it must not block the publisher. An
+// error tells publishers that guarantee delivery (the outbox relay) to retry,
+// which runs every subscriber again, so best-effort subscribers log their
+// failures and return nil.
+type Subscriber func(ctx context.Context, event Event) error
+
+// Bus is an in-process Publisher that fans events out to its subscribers
+type Bus struct {
+	subscribers []Subscriber
+	mu          sync.RWMutex
+}
+
+var _ Publisher = (*Bus)(nil)
+
+// NewBus creates a new in-process event bus
+func NewBus() *Bus {
+	return &Bus{}
+}
+
+// Subscribe registers a subscriber for every event published on the bus
+func (b *Bus) Subscribe(subscriber Subscriber) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.subscribers = append(b.subscribers, subscriber)
+}
+
+// Publish delivers the event to every subscriber in registration order and
+// returns the errors of the subscribers that failed
+func (b *Bus) Publish(ctx context.Context, event Event) error {
+	b.mu.RLock()
+	subscribers := make([]Subscriber, len(b.subscribers))
+	copy(subscribers, b.subscribers)
+	b.mu.RUnlock()
+
+	var errs []error
+	for _, subscriber := range subscribers {
+		if err := subscriber(ctx, event); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
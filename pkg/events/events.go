@@ -0,0 +1,81 @@
+package events
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// EventType identifies a task lifecycle event
+type EventType string
+
+const (
+	TaskCreated EventType = "task.created"
+	TaskUpdated EventType = "task.updated"
+	TaskDone    EventType = "task.done"
+	TaskDeleted EventType = "task.deleted"
+)
+
+// AllTaskEvents lists every task lifecycle event type
+var AllTaskEvents = []EventType{TaskCreated, TaskUpdated, TaskDone, TaskDeleted}
+
+// Event is a task lifecycle event, Data holds the serialized task snapshot
+type Event struct {
+	ID         string          `json:"id"`
+	Type       EventType       `json:"type"`
+	TaskID     uint            `json:"taskId"`
+	Owner      uint            `json:"owner"`
+	Data       json.RawMessage `json:"data,omitempty"`
+	OccurredAt time.Time       `json:"occurredAt"`
+}
+
+// Publisher defines the contract for emitting events
+type Publisher interface {
+	Publish(ctx context.Context, event Event) error
+}
+
+// NewEvent creates an event with a unique ID and the data serialized as JSON
+func NewEvent(eventType EventType, taskID uint, owner uint, data interface{}) (Event, error) {
+	id, err := NewEventID()
+	if err != nil {
+		return Event{}, err
+	}
+
+	var raw json.RawMessage
+	if data != nil {
+		if raw, err = json.Marshal(data); err != nil {
+			return Event{}, fmt.Errorf("failed to encode event data: %w", err)
+		}
+	}
+
+	return Event{
+		ID:         id,
+		Type:       eventType,
+		TaskID:     taskID,
+		Owner:      owner,
+		Data:       raw,
+		OccurredAt: time.Now().UTC(),
+	}, nil
+}
+
+// NewEventID returns a random 128-bit identifier encoded as hex
+func NewEventID() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate event id: %w", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// IsValidType reports whether the given name is a known task event type
+func IsValidType(name string) bool {
+	for _, eventType := range AllTaskEvents {
+		if string(eventType) == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,160 @@
+package metrics
+
+import (
+	"github.com/hftamayo/gotodo/pkg/cache"
+	"github.com/hftamayo/gotodo/pkg/config"
+	"github.com/hftamayo/gotodo/pkg/utils"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// CacheStatsProvider is a cache that keeps operation stats
+type CacheStatsProvider interface {
+	Stats(topN int) cache.Stats
+}
+
+// RateLimitStatsProvider counts the rejected requests per operation
+type RateLimitStatsProvider interface {
+	Rejections() map[utils.OperationType]uint64
+}
+
+// ErrorLogStatsProvider is an error logger that counts its writes and drops
+type ErrorLogStatsProvider interface {
+	Stats() config.AsyncErrorLogStats
+}
+
+// WebhookStatsProvider counts the webhook events dropped before their deliveries were stored
+type WebhookStatsProvider interface {
+	DroppedEvents() uint64
+}
+
+// ObserveCache collects the operation counters of the cache
+func (m *Metrics) ObserveCache(provider CacheStatsProvider) error {
+	return m.Register(&cacheCollector{provider: provider})
+}
+
+// ObserveRateLimiter collects the rejections of the rate limiter
+func (m *Metrics) ObserveRateLimiter(provider RateLimitStatsProvider) error {
+	return m.Register(&rateLimitCollector{provider: provider})
+}
+
+// ObserveErrorLogger collects the queue and drop counters of the error logger
+func (m *Metrics) ObserveErrorLogger(provider ErrorLogStatsProvider) error {
+	return m.Register(&errorLogCollector{provider: provider})
+}
+
+// ObserveWebhooks collects the webhook events dropped by the dispatcher
+func (m *Metrics) ObserveWebhooks(provider WebhookStatsProvider) error {
+	return m.Register(&webhookCollector{provider: provider})
+}
+
+var (
+	cacheOperationsDesc = prometheus.NewDesc(namespace+"_cache_operations_total",
+		"Cache operations by operation.", []string{"operation"}, nil)
+	cacheHitsDesc = prometheus.NewDesc(namespace+"_cache_hits_total",
+		"Cache reads that found the key.", []string{"operation"}, nil)
+	cacheMissesDesc = prometheus.NewDesc(namespace+"_cache_misses_total",
+		"Cache reads that didn't find the key.", []string{"operation"}, nil)
+	cacheErrorsDesc = prometheus.NewDesc(namespace+"_cache_errors_total",
+		"Failed cache operations, misses are not counted.", []string{"operation"}, nil)
+	cacheBreakerDesc = prometheus.NewDesc(namespace+"_cache_breaker_open",
+		"1 while the cache circuit breaker serves from the memory fallback.", nil, nil)
+
+	rateLimitRejectionsDesc = prometheus.NewDesc(namespace+"_rate_limit_rejections_total",
+		"Requests rejected by the rate limits or the daily quota by operation.", []string{"operation"}, nil)
+
+	errorLogQueuedDesc = prometheus.NewDesc(namespace+"_error_log_queued",
+		"Errors waiting for a worker.", nil, nil)
+	errorLogEnqueuedDesc = prometheus.NewDesc(namespace+"_error_log_enqueued_total",
+		"Errors accepted by the error logger.", nil, nil)
+	errorLogWrittenDesc = prometheus.NewDesc(namespace+"_error_log_written_total",
+		"Errors written to the sinks.", nil, nil)
+	errorLogFailedDesc = prometheus.NewDesc(namespace+"_error_log_failed_total",
+		"Errors the sinks failed to write.", nil, nil)
+	errorLogDroppedDesc = prometheus.NewDesc(namespace+"_error_log_dropped_total",
+		"Errors dropped because the queue was full or the logger closed.", nil, nil)
+	errorLogSampledDesc = prometheus.NewDesc(namespace+"_error_log_sampled_total",
+		"Errors left out by sampling while the queue was filling up.", nil, nil)
+
+	webhookDroppedDesc = prometheus.NewDesc(namespace+"_webhook_events_dropped_total",
+		"Task events dropped because the webhook event queue was full.", nil, nil)
+)
+
+type cacheCollector struct {
+	provider CacheStatsProvider
+}
+
+func (c *cacheCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- cacheOperationsDesc
+	ch <- cacheHitsDesc
+	ch <- cacheMissesDesc
+	ch <- cacheErrorsDesc
+	ch <- cacheBreakerDesc
+}
+
+func (c *cacheCollector) Collect(ch chan<- prometheus.Metric) {
+	stats := c.provider.Stats(0)
+	for op, ops := range stats.Operations {
+		ch <- prometheus.MustNewConstMetric(cacheOperationsDesc, prometheus.CounterValue, float64(ops.Calls), op)
+		ch <- prometheus.MustNewConstMetric(cacheErrorsDesc, prometheus.CounterValue, float64(ops.Errors), op)
+		if op == cache.OpGet || op == cache.OpExists {
+			ch <- prometheus.MustNewConstMetric(cacheHitsDesc, prometheus.CounterValue, float64(ops.Hits), op)
+			ch <- prometheus.MustNewConstMetric(cacheMissesDesc, prometheus.CounterValue, float64(ops.Misses), op)
+		}
+	}
+	if stats.Breaker != "" {
+		open := 0.0
+		if stats.Breaker != cache.BreakerClosed.String() {
+			open = 1
+		}
+		ch <- prometheus.MustNewConstMetric(cacheBreakerDesc, prometheus.GaugeValue, open)
+	}
+}
+
+type rateLimitCollector struct {
+	provider RateLimitStatsProvider
+}
+
+func (c *rateLimitCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- rateLimitRejectionsDesc
+}
+
+func (c *rateLimitCollector) Collect(ch chan<- prometheus.Metric) {
+	for op, count := range c.provider.Rejections() {
+		ch <- prometheus.MustNewConstMetric(rateLimitRejectionsDesc, prometheus.CounterValue, float64(count), string(op))
+	}
+}
+
+type errorLogCollector struct {
+	provider ErrorLogStatsProvider
+}
+
+func (c *errorLogCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- errorLogQueuedDesc
+	ch <- errorLogEnqueuedDesc
+	ch <- errorLogWrittenDesc
+	ch <- errorLogFailedDesc
+	ch <- errorLogDroppedDesc
+	ch <- errorLogSampledDesc
+}
+
+func (c *errorLogCollector) Collect(ch chan<- prometheus.Metric) {
+	stats := c.provider.Stats()
+	ch <- prometheus.MustNewConstMetric(errorLogQueuedDesc, prometheus.GaugeValue, float64(stats.Queued))
+	ch <- prometheus.MustNewConstMetric(errorLogEnqueuedDesc, prometheus.CounterValue, float64(stats.Enqueued))
+	ch <- prometheus.MustNewConstMetric(errorLogWrittenDesc, prometheus.CounterValue, float64(stats.Written))
+	ch <- prometheus.MustNewConstMetric(errorLogFailedDesc, prometheus.CounterValue, float64(stats.Failed))
+	ch <- prometheus.MustNewConstMetric(errorLogDroppedDesc, prometheus.CounterValue, float64(stats.Dropped))
+	ch <- prometheus.MustNewConstMetric(errorLogSampledDesc, prometheus.CounterValue, float64(stats.Sampled))
+}
+
+type webhookCollector struct {
+	provider WebhookStatsProvider
+}
+
+func (c *webhookCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- webhookDroppedDesc
+}
+
+func (c *webhookCollector) Collect(ch chan<- prometheus.Metric) {
+	ch <- prometheus.MustNewConstMetric(webhookDroppedDesc, prometheus.CounterValue, float64(c.provider.DroppedEvents()))
+}
@@ -0,0 +1,161 @@
+package middleware
+
+import (
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ClientIPKey is the context key of the resolved client address
+const ClientIPKey = "clientIP"
+
+// ClientIPResolver finds the address of the client of a request. Forwarding
+// headers are only believed when the peer is a trusted proxy, and they are
+// read from the closest hop backwards, stopping at the first address that is
+// not a trusted proxy, so a client can't pick its address by sending them.
+type ClientIPResolver struct {
+	trusted []*net.IPNet
+	headers []string
+}
+
+// NewClientIPResolver creates a resolver trusting the proxies in CIDR or
+// address form and reading headers in order, Forwarded (RFC 7239),
+// X-Forwarded-For and X-Real-IP are understood. A single header, the one the
+// proxies rewrite, should be given: the first one present wins, even when
+// the client sent it.
+func NewClientIPResolver(trustedProxies []string, headers []string) (*ClientIPResolver, error) {
+	resolver := &ClientIPResolver{}
+	for _, proxy := range trustedProxies {
+		if !strings.Contains(proxy, "/") {
+			if ip := net.ParseIP(proxy); ip != nil && ip.To4() != nil {
+				proxy += "/32"
+			} else {
+				proxy += "/128"
+			}
+		}
+		_, network, err := net.ParseCIDR(proxy)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy %q: %w", proxy, err)
+		}
+		resolver.trusted = append(resolver.trusted, network)
+	}
+
+	for _, header := range headers {
+		switch canonical := http.CanonicalHeaderKey(header); canonical {
+		case "Forwarded", "X-Forwarded-For", "X-Real-Ip":
+			resolver.headers = append(resolver.headers, canonical)
+		default:
+			return nil, fmt.Errorf("unsupported client IP header %q", header)
+		}
+	}
+	if len(resolver.headers) > 1 {
+		slog.Warn("Several client IP headers are configured, clients can spoof their address through those the proxies don't rewrite",
+			"headers", resolver.headers)
+	}
+	return resolver, nil
+}
+
+// Resolve returns the client address of req, "" when the peer address is invalid
+func (r *ClientIPResolver) Resolve(req *http.Request) string {
+	remote := parseIP(req.RemoteAddr)
+	if remote == nil {
+		return ""
+	}
+	if !r.isTrusted(remote) {
+		return remote.String()
+	}
+
+	for _, header := range r.headers {
+		hops := forwardedHops(header, req.Header.Values(header))
+		if len(hops) == 0 {
+			continue
+		}
+
+		client := remote
+		for i := len(hops) - 1; i >= 0; i-- {
+			if hops[i] == nil {
+				// An obfuscated or invalid hop, nothing before it can be verified
+				break
+			}
+			client = hops[i]
+			if !r.isTrusted(client) {
+				break
+			}
+		}
+		return client.String()
+	}
+	return remote.String()
+}
+
+func (r *ClientIPResolver) isTrusted(ip net.IP) bool {
+	for _, network := range r.trusted {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// ClientIPMiddleware resolves the client address of every request, read it with ClientIP
+func ClientIPMiddleware(resolver *ClientIPResolver) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if clientIP := resolver.Resolve(c.Request); clientIP != "" {
+			c.Set(ClientIPKey, clientIP)
+		}
+		c.Next()
+	}
+}
+
+// ClientIP returns the resolved client address of the request, gin's own
+// resolution when ClientIPMiddleware didn't run
+func ClientIP(c *gin.Context) string {
+	if clientIP := c.GetString(ClientIPKey); clientIP != "" {
+		return clientIP
+	}
+	return c.ClientIP()
+}
+
+// forwardedHops returns the addresses listed in the values of header, from
+// the client to the closest proxy. Hops that aren't addresses are nil.
+func forwardedHops(header string, values []string) []net.IP {
+	var hops []net.IP
+	for _, value := range values {
+		for _, element := range strings.Split(value, ",") {
+			element = strings.TrimSpace(element)
+			if element == "" {
+				continue
+			}
+			if header != "Forwarded" {
+				hops = append(hops, parseIP(element))
+				continue
+			}
+
+			// for=192.0.2.60;proto=http, for="[2001:db8::17]:4711"
+			var node string
+			for _, pair := range strings.Split(element, ";") {
+				name, value, found := strings.Cut(strings.TrimSpace(pair), "=")
+				if found && strings.EqualFold(name, "for") {
+					node = strings.Trim(value, `"`)
+				}
+			}
+			hops = append(hops, parseIP(node))
+		}
+	}
+	if header == "X-Real-Ip" && len(hops) > 1 {
+		hops = hops[len(hops)-1:]
+	}
+	return hops
+}
+
+// parseIP parses an address with an optional port, IPv6 may be in brackets
+func parseIP(value string) net.IP {
+	value = strings.TrimSpace(value)
+	if host, _, err := net.SplitHostPort(value); err == nil {
+		value = host
+	}
+	return net.ParseIP(strings.Trim(value, "[]"))
+}
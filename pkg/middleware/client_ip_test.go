@@ -0,0 +1,216 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/hftamayo/gotodo/pkg/middleware"
+)
+
+func TestClientIPResolverResolve(t *testing.T) {
+	trusted := []string{"10.0.0.0/8", "192.0.2.1", "2001:db8:ffff::/48"}
+
+	tests := []struct {
+		name    string
+		trusted []string
+		headers []string
+		remote  string
+		values  map[string][]string
+		want    string
+	}{
+		{
+			name:   "no proxy trusted ignores the header",
+			remote: "203.0.113.7:1234",
+			values: map[string][]string{"X-Forwarded-For": {"198.51.100.1"}},
+			want:   "203.0.113.7",
+		},
+		{
+			name:    "untrusted peer can't spoof X-Forwarded-For",
+			trusted: trusted,
+			remote:  "203.0.113.7:1234",
+			values:  map[string][]string{"X-Forwarded-For": {"198.51.100.1"}},
+			want:    "203.0.113.7",
+		},
+		{
+			name:    "trusted proxy without header",
+			trusted: trusted,
+			remote:  "10.0.0.2:1234",
+			want:    "10.0.0.2",
+		},
+		{
+			name:    "trusted proxy",
+			trusted: trusted,
+			remote:  "10.0.0.2:1234",
+			values:  map[string][]string{"X-Forwarded-For": {"198.51.100.1"}},
+			want:    "198.51.100.1",
+		},
+		{
+			name:    "multi-hop chain through trusted proxies",
+			trusted: trusted,
+			remote:  "10.0.0.2:1234",
+			values:  map[string][]string{"X-Forwarded-For": {"198.51.100.1, 192.0.2.1, 10.1.1.1"}},
+			want:    "198.51.100.1",
+		},
+		{
+			name:    "chain split over several header lines",
+			trusted: trusted,
+			remote:  "10.0.0.2:1234",
+			values:  map[string][]string{"X-Forwarded-For": {"198.51.100.1", "10.1.1.1"}},
+			want:    "198.51.100.1",
+		},
+		{
+			name:    "spoofed entries before the first untrusted hop are ignored",
+			trusted: trusted,
+			remote:  "10.0.0.2:1234",
+			values:  map[string][]string{"X-Forwarded-For": {"1.2.3.4, 203.0.113.7, 10.1.1.1"}},
+			want:    "203.0.113.7",
+		},
+		{
+			name:    "every hop trusted gives the first one",
+			trusted: trusted,
+			remote:  "10.0.0.2:1234",
+			values:  map[string][]string{"X-Forwarded-For": {"10.3.3.3, 10.1.1.1"}},
+			want:    "10.3.3.3",
+		},
+		{
+			name:    "malformed entry stops at the last verified hop",
+			trusted: trusted,
+			remote:  "10.0.0.2:1234",
+			values:  map[string][]string{"X-Forwarded-For": {"198.51.100.1, not-an-ip, 10.1.1.1"}},
+			want:    "10.1.1.1",
+		},
+		{
+			name:    "malformed closest entry gives the peer",
+			trusted: trusted,
+			remote:  "10.0.0.2:1234",
+			values:  map[string][]string{"X-Forwarded-For": {"198.51.100.1, garbage"}},
+			want:    "10.0.0.2",
+		},
+		{
+			name:    "empty entries are skipped",
+			trusted: trusted,
+			remote:  "10.0.0.2:1234",
+			values:  map[string][]string{"X-Forwarded-For": {"198.51.100.1,, "}},
+			want:    "198.51.100.1",
+		},
+		{
+			name:    "entries with ports",
+			trusted: trusted,
+			remote:  "10.0.0.2:1234",
+			values:  map[string][]string{"X-Forwarded-For": {"198.51.100.1:5555"}},
+			want:    "198.51.100.1",
+		},
+		{
+			name:    "IPv6 peer and client",
+			trusted: trusted,
+			remote:  "[2001:db8:ffff::1]:443",
+			values:  map[string][]string{"X-Forwarded-For": {"2001:db8:1::7"}},
+			want:    "2001:db8:1::7",
+		},
+		{
+			name:    "bracketed IPv6 entry with port",
+			trusted: trusted,
+			remote:  "10.0.0.2:1234",
+			values:  map[string][]string{"X-Forwarded-For": {"[2001:db8:1::7]:8080"}},
+			want:    "2001:db8:1::7",
+		},
+		{
+			name:    "untrusted IPv6 peer",
+			trusted: trusted,
+			remote:  "[2001:db8:1::9]:443",
+			values:  map[string][]string{"X-Forwarded-For": {"198.51.100.1"}},
+			want:    "2001:db8:1::9",
+		},
+		{
+			name:    "other headers are not read by default",
+			trusted: trusted,
+			remote:  "10.0.0.2:1234",
+			values:  map[string][]string{"X-Real-Ip": {"198.51.100.1"}, "Forwarded": {"for=198.51.100.2"}},
+			want:    "10.0.0.2",
+		},
+		{
+			name:    "Forwarded header",
+			trusted: trusted,
+			headers: []string{"Forwarded"},
+			remote:  "10.0.0.2:1234",
+			values:  map[string][]string{"Forwarded": {`for=198.51.100.1;proto=https, for="[2001:db8:1::7]:4711";by=10.0.0.2`}},
+			want:    "2001:db8:1::7",
+		},
+		{
+			name:    "obfuscated Forwarded node gives the last verified hop",
+			trusted: trusted,
+			headers: []string{"Forwarded"},
+			remote:  "10.0.0.2:1234",
+			values:  map[string][]string{"Forwarded": {"for=198.51.100.1, for=_hidden, for=10.1.1.1"}},
+			want:    "10.1.1.1",
+		},
+		{
+			name:    "X-Real-IP uses the last value only",
+			trusted: trusted,
+			headers: []string{"X-Real-IP"},
+			remote:  "10.0.0.2:1234",
+			values:  map[string][]string{"X-Real-Ip": {"1.2.3.4", "198.51.100.1"}},
+			want:    "198.51.100.1",
+		},
+		{
+			name:    "first header present wins",
+			trusted: trusted,
+			headers: []string{"X-Real-IP", "X-Forwarded-For"},
+			remote:  "10.0.0.2:1234",
+			values:  map[string][]string{"X-Forwarded-For": {"198.51.100.1"}},
+			want:    "198.51.100.1",
+		},
+		{
+			name:    "invalid peer address",
+			trusted: trusted,
+			remote:  "not-an-address",
+			values:  map[string][]string{"X-Forwarded-For": {"198.51.100.1"}},
+			want:    "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			headers := tt.headers
+			if headers == nil {
+				headers = []string{"X-Forwarded-For"}
+			}
+			resolver, err := middleware.NewClientIPResolver(tt.trusted, headers)
+			if err != nil {
+				t.Fatalf("NewClientIPResolver() error = %v", err)
+			}
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.RemoteAddr = tt.remote
+			for name, values := range tt.values {
+				for _, value := range values {
+					req.Header.Add(name, value)
+				}
+			}
+			if got := resolver.Resolve(req); got != tt.want {
+				t.Fatalf("Resolve() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewClientIPResolverRejectsInvalidConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		trusted []string
+		headers []string
+	}{
+		{"invalid CIDR", []string{"10.0.0.0/33"}, []string{"X-Forwarded-For"}},
+		{"invalid address", []string{"proxy.internal"}, []string{"X-Forwarded-For"}},
+		{"unsupported header", []string{"10.0.0.0/8"}, []string{"True-Client-IP"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := middleware.NewClientIPResolver(tt.trusted, tt.headers); err == nil {
+				t.Fatal("NewClientIPResolver() succeeded")
+			}
+		})
+	}
+}
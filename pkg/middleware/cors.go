@@ -29,7 +29,7 @@ func CORSMiddleware(config *config.EnvVars) gin.HandlerFunc {
             if allowed {
                 c.Writer.Header().Set("Access-Control-Allow-Origin", origin)
                 c.Writer.Header().Set("Access-Control-Allow-Methods", "GET, POST, PATCH, DELETE, OPTIONS")
-                c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+                c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-API-Key, X-Request-ID")
                 c.Writer.Header().Set("Access-Control-Max-Age", "86400") // 24 hours
                 c.Writer.Header().Set("Access-Control-Allow-Credentials", "true")
                 c.AbortWithStatus(204)
@@ -48,9 +48,10 @@ func CORSMiddleware(config *config.EnvVars) gin.HandlerFunc {
         // Set CORS headers for allowed requests
         c.Writer.Header().Set("Access-Control-Allow-Origin", origin)
         c.Writer.Header().Set("Access-Control-Allow-Methods", "GET, POST, PATCH, DELETE, OPTIONS")
-        c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+        c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-API-Key, X-Request-ID")
         c.Writer.Header().Set("Access-Control-Max-Age", "86400") // 24 hours
         c.Writer.Header().Set("Access-Control-Allow-Credentials", "true")
+        c.Writer.Header().Set("Access-Control-Expose-Headers", RequestIDHeader)
         
         c.Next()
     }
@@ -0,0 +1,165 @@
+package middleware
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hftamayo/gotodo/pkg/utils"
+)
+
+const (
+	// APIKeyHeader carries the API key of a client
+	APIKeyHeader = "X-API-Key"
+
+	// IdentityKey is the context key of the Identity of a request
+	IdentityKey = "identity"
+
+	// Kinds of identity
+	IdentityAPIKey = "api_key"
+	IdentityIP     = "ip"
+)
+
+// ErrUnknownAPIKey is returned by resolvers for keys that don't exist or were revoked
+var ErrUnknownAPIKey = errors.New("unknown API key")
+
+// Identity is who a request is counted against by the rate limiter
+type Identity struct {
+	Kind  string `json:"kind"`
+	ID    string `json:"id"` // user:<owner>, key:<id> or ip:<address>
+	Owner uint   `json:"owner,omitempty"`
+	KeyID uint   `json:"keyId,omitempty"`
+	Tier  string `json:"tier,omitempty"`
+}
+
+// IdentityResolver looks up the identity behind an API key
+type IdentityResolver interface {
+	ResolveAPIKey(key string) (*Identity, error)
+}
+
+// IdentityMiddleware identifies the client of a request by its API key, or by its IP
+// address when it sends none. Unknown keys are rejected; when the keys can't
+// be looked up the client is identified by its IP address.
+//
+// A rejected key is counted as a request of the client IP by limiter, so key
+// guesses are bound by the anonymous limits. Once the IP is over its limit,
+// its keys are refused with a 429 without being looked up until it may retry.
+func IdentityMiddleware(resolver IdentityResolver, limiter *utils.RateLimiter) gin.HandlerFunc {
+	guard := &keyGuessGuard{blocked: make(map[string]utils.RateLimitResult)}
+
+	return func(c *gin.Context) {
+		identity := ipIdentity(c)
+
+		if key := c.GetHeader(APIKeyHeader); key != "" {
+			if result, blocked := guard.check(identity.ID, time.Now()); blocked {
+				abortRateLimited(c, result)
+				return
+			}
+
+			resolved, err := resolver.ResolveAPIKey(key)
+			switch {
+			case errors.Is(err, ErrUnknownAPIKey):
+				result, limitErr := limiter.TakeRequest(utils.RateLimitRequest{
+					ClientID:  identity.ID,
+					Operation: operationOf(c),
+					Path:      routePath(c),
+				})
+				if limitErr == nil && !result.Allowed {
+					guard.block(identity.ID, result, time.Now())
+					abortRateLimited(c, result)
+					return
+				}
+				c.JSON(http.StatusUnauthorized, gin.H{
+					"error": "Invalid API key",
+				})
+				c.Abort()
+				return
+			case err != nil:
+				slog.WarnContext(c.Request.Context(), "Failed to resolve API key, identifying the client by IP", "error", err)
+			default:
+				identity = resolved
+			}
+		}
+
+		c.Set(IdentityKey, identity)
+		client := utils.RequestClient{IP: ClientIP(c), Identity: identity.ID}
+		if identity.Owner != 0 {
+			client.User = strconv.FormatUint(uint64(identity.Owner), 10)
+		}
+		c.Request = c.Request.WithContext(utils.WithClient(c.Request.Context(), client))
+		if identity.Tier != "" {
+			c.Set(RateLimitRoleKey, identity.Tier)
+		}
+		c.Next()
+	}
+}
+
+// GetIdentity returns the identity of the request, by IP address when the
+// identity middleware didn't run
+func GetIdentity(c *gin.Context) *Identity {
+	if value, ok := c.Get(IdentityKey); ok {
+		if identity, ok := value.(*Identity); ok {
+			return identity
+		}
+	}
+	return ipIdentity(c)
+}
+
+func ipIdentity(c *gin.Context) *Identity {
+	clientIP := ClientIP(c)
+	if clientIP == "" {
+		clientIP = "unknown"
+	}
+	return &Identity{Kind: IdentityIP, ID: "ip:" + clientIP}
+}
+
+// maxBlockedClients bounds the clients keyGuessGuard remembers, expired
+// entries are swept when it is reached
+const maxBlockedClients = 10000
+
+// keyGuessGuard remembers the clients whose rejected keys exceeded their
+// limit, each instance learns it on the first rejection it counts
+type keyGuessGuard struct {
+	mu      sync.Mutex
+	blocked map[string]utils.RateLimitResult // ResetAt is when the client may retry
+}
+
+// check returns a rejection when the client is blocked at now
+func (g *keyGuessGuard) check(clientID string, now time.Time) (utils.RateLimitResult, bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	result, ok := g.blocked[clientID]
+	if !ok {
+		return utils.RateLimitResult{}, false
+	}
+	if !now.Before(result.ResetAt) {
+		delete(g.blocked, clientID)
+		return utils.RateLimitResult{}, false
+	}
+	result.RetryAfter = result.ResetAt.Sub(now)
+	return result, true
+}
+
+func (g *keyGuessGuard) block(clientID string, result utils.RateLimitResult, now time.Time) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if len(g.blocked) >= maxBlockedClients {
+		for id, blocked := range g.blocked {
+			if !now.Before(blocked.ResetAt) {
+				delete(g.blocked, id)
+			}
+		}
+		if len(g.blocked) >= maxBlockedClients {
+			return
+		}
+	}
+	// ResetAt is when the client may retry from now on
+	result.ResetAt = now.Add(result.RetryAfter)
+	g.blocked[clientID] = result
+}
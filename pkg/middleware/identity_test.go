@@ -0,0 +1,115 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hftamayo/gotodo/pkg/middleware"
+	"github.com/hftamayo/gotodo/pkg/utils"
+)
+
+const validKey = "gtd_valid"
+
+type fakeResolver struct {
+	calls int
+}
+
+func (r *fakeResolver) ResolveAPIKey(key string) (*middleware.Identity, error) {
+	r.calls++
+	if key != validKey {
+		return nil, middleware.ErrUnknownAPIKey
+	}
+	return &middleware.Identity{Kind: middleware.IdentityAPIKey, ID: "user:1", Owner: 1, Tier: utils.TierPro}, nil
+}
+
+func newIdentityRouter(t *testing.T, resolver middleware.IdentityResolver, readLimit int64) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	limiter := utils.NewRateLimiter(nil)
+	policy := utils.DefaultRateLimitPolicy()
+	policy.Operations[utils.OperationRead] = readLimit
+	if err := limiter.SetPolicy(policy); err != nil {
+		t.Fatalf("SetPolicy() error = %v", err)
+	}
+
+	r := gin.New()
+	r.Use(middleware.IdentityMiddleware(resolver, limiter))
+	r.GET("/tasks", func(c *gin.Context) {
+		c.String(http.StatusOK, middleware.GetIdentity(c).ID)
+	})
+	return r
+}
+
+func get(r *gin.Engine, remoteAddr, key string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, "/tasks", nil)
+	req.RemoteAddr = remoteAddr
+	if key != "" {
+		req.Header.Set(middleware.APIKeyHeader, key)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestIdentityMiddlewareIdentifiesClients(t *testing.T) {
+	r := newIdentityRouter(t, &fakeResolver{}, 100)
+
+	if w := get(r, "203.0.113.7:1234", ""); w.Code != http.StatusOK || w.Body.String() != "ip:203.0.113.7" {
+		t.Fatalf("anonymous request = %d %q, want 200 ip:203.0.113.7", w.Code, w.Body.String())
+	}
+	if w := get(r, "203.0.113.7:1234", validKey); w.Code != http.StatusOK || w.Body.String() != "user:1" {
+		t.Fatalf("request with a key = %d %q, want 200 user:1", w.Code, w.Body.String())
+	}
+	if w := get(r, "203.0.113.7:1234", "gtd_unknown"); w.Code != http.StatusUnauthorized {
+		t.Fatalf("request with an unknown key = %d, want 401", w.Code)
+	}
+}
+
+func TestIdentityMiddlewareLimitsKeyGuesses(t *testing.T) {
+	resolver := &fakeResolver{}
+	r := newIdentityRouter(t, resolver, 3)
+
+	for i := 0; i < 3; i++ {
+		if w := get(r, "203.0.113.7:1234", "gtd_guess"); w.Code != http.StatusUnauthorized {
+			t.Fatalf("guess %d = %d, want 401", i+1, w.Code)
+		}
+	}
+
+	w := get(r, "203.0.113.7:1234", "gtd_guess")
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("guess over the limit = %d, want 429", w.Code)
+	}
+	if w.Header().Get("Retry-After") == "" {
+		t.Fatal("429 without Retry-After")
+	}
+
+	// Once blocked, keys of the IP are refused without being looked up, valid ones included
+	calls := resolver.calls
+	if w := get(r, "203.0.113.7:1234", validKey); w.Code != http.StatusTooManyRequests {
+		t.Fatalf("valid key from a blocked IP = %d, want 429", w.Code)
+	}
+	if resolver.calls != calls {
+		t.Fatalf("resolver called %d times while the IP was blocked", resolver.calls-calls)
+	}
+
+	// Other clients are not affected
+	if w := get(r, "198.51.100.9:1234", validKey); w.Code != http.StatusOK {
+		t.Fatalf("valid key from another IP = %d, want 200", w.Code)
+	}
+}
+
+func TestIdentityMiddlewareDoesNotChargeValidKeysToTheIP(t *testing.T) {
+	r := newIdentityRouter(t, &fakeResolver{}, 1)
+
+	for i := 0; i < 5; i++ {
+		if w := get(r, "203.0.113.7:1234", validKey); w.Code != http.StatusOK {
+			t.Fatalf("request %d with a valid key = %d, want 200", i+1, w.Code)
+		}
+	}
+	if w := get(r, "203.0.113.7:1234", "gtd_guess"); w.Code != http.StatusUnauthorized {
+		t.Fatalf("first guess = %d, want 401", w.Code)
+	}
+}
@@ -1,33 +1,31 @@
 package middleware
 
 import (
+	"math"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/hftamayo/gotodo/pkg/utils"
 )
 
+// RateLimitRoleKey is the context key under which authentication middleware
+// stores the role of the client, picking the role limits of the policy
+const RateLimitRoleKey = "rateLimitRole"
+
 // RateLimiter is a middleware that limits the number of requests
 func RateLimiter(limiter *utils.RateLimiter) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		clientID := c.ClientIP()
-		if clientID == "" {
-			clientID = "unknown"
-		}
+		// Clients with an API key are counted per user, the others per IP
+		clientID := GetIdentity(c).ID
 
-		// Determine operation type based on HTTP method
-		var op utils.OperationType
-		switch c.Request.Method {
-		case "GET":
-			op = utils.OperationRead
-		case "POST", "PUT", "PATCH", "DELETE":
-			op = utils.OperationWrite
-		default:
-			op = utils.OperationRead
-		}
-
-		allowed, limit, retryTime, err := limiter.Allow(clientID, op)
+		result, err := limiter.TakeRequest(utils.RateLimitRequest{
+			ClientID:  clientID,
+			Operation: operationOf(c),
+			Path:      routePath(c),
+			Role:      c.GetString(RateLimitRoleKey),
+		})
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{
 				"error": "Rate limit error",
@@ -36,22 +34,60 @@ func RateLimiter(limiter *utils.RateLimiter) gin.HandlerFunc {
 			return
 		}
 
-		// Set rate limit headers
-		c.Header("X-RateLimit-Limit", strconv.FormatInt(limit, 10))
-		c.Header("X-RateLimit-Remaining", strconv.FormatInt(limit-1, 10))
-		if !retryTime.IsZero() {
-			c.Header("X-RateLimit-Reset", strconv.FormatInt(retryTime.Unix(), 10))
-		}
-
-		if !allowed {
-			c.JSON(http.StatusTooManyRequests, gin.H{
-				"error":       "Rate limit exceeded",
-				"retry_after": retryTime.Unix(),
-			})
-			c.Abort()
+		if !result.Allowed {
+			abortRateLimited(c, result)
 			return
 		}
+		setRateLimitHeaders(c, result)
 
 		c.Next()
 	}
-}
\ No newline at end of file
+}
+
+// operationOf returns the operation type of a request from its HTTP method
+func operationOf(c *gin.Context) utils.OperationType {
+	switch c.Request.Method {
+	case "POST", "PUT", "PATCH", "DELETE":
+		return utils.OperationWrite
+	default:
+		return utils.OperationRead
+	}
+}
+
+// routePath is the path route groups are matched on, the route pattern so
+// ids don't matter
+func routePath(c *gin.Context) string {
+	if path := c.FullPath(); path != "" {
+		return path
+	}
+	return c.Request.URL.Path
+}
+
+func setRateLimitHeaders(c *gin.Context, result utils.RateLimitResult) {
+	c.Header("X-RateLimit-Limit", strconv.FormatInt(result.Limit, 10))
+	c.Header("X-RateLimit-Remaining", strconv.FormatInt(result.Remaining, 10))
+	c.Header("X-RateLimit-Reset", strconv.FormatInt(result.ResetAt.Unix(), 10))
+	if quota := result.Quota; quota != nil {
+		c.Header("X-Quota-Limit", strconv.FormatInt(quota.Limit, 10))
+		c.Header("X-Quota-Remaining", strconv.FormatInt(quota.Remaining, 10))
+		c.Header("X-Quota-Reset", strconv.FormatInt(quota.ResetAt.Unix(), 10))
+	}
+}
+
+// abortRateLimited answers 429 to a request the limiter rejected
+func abortRateLimited(c *gin.Context, result utils.RateLimitResult) {
+	setRateLimitHeaders(c, result)
+	message := "Rate limit exceeded"
+	if result.Quota != nil {
+		// The quota is only counted once the rate limit let the request through
+		message = "Daily quota exceeded"
+	}
+
+	retryAfter := int64(math.Ceil(result.RetryAfter.Seconds()))
+	c.Header("Retry-After", strconv.FormatInt(retryAfter, 10))
+	c.JSON(http.StatusTooManyRequests, gin.H{
+		"error":       message,
+		"retry_after": time.Now().Add(result.RetryAfter).Unix(),
+	})
+	c.Abort()
+}
@@ -0,0 +1,68 @@
+package middleware
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hftamayo/gotodo/pkg/utils"
+)
+
+const (
+	// RequestIDHeader carries the ID of a request, from the client or a proxy
+	// and back in the response
+	RequestIDHeader = "X-Request-ID"
+
+	// RequestIDKey is the context key of the request ID
+	RequestIDKey = "requestID"
+
+	maxRequestIDLength = 128
+)
+
+// RequestID keeps the X-Request-ID of the request when it is well formed and
+// generates one otherwise. The ID is sent back in the response and stored in
+// the request context, where the loggers and the error log read it.
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestID := c.GetHeader(RequestIDHeader)
+		if !validRequestID(requestID) {
+			requestID = newRequestID()
+		}
+
+		c.Set(RequestIDKey, requestID)
+		c.Header(RequestIDHeader, requestID)
+		c.Request = c.Request.WithContext(utils.WithRequestID(c.Request.Context(), requestID))
+		c.Next()
+	}
+}
+
+// GetRequestID returns the ID of the request, "" when RequestID didn't run
+func GetRequestID(c *gin.Context) string {
+	return c.GetString(RequestIDKey)
+}
+
+// validRequestID accepts the characters of UUIDs and of the usual tracing
+// IDs, anything else could forge log lines or headers
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for _, r := range id {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		case r == '-', r == '_', r == '.', r == ':', r == '/', r == '+', r == '=':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+func newRequestID() string {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		// crypto/rand doesn't fail on supported platforms
+		panic(err)
+	}
+	return hex.EncodeToString(buf)
+}
@@ -0,0 +1,249 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/hftamayo/gotodo/api/v1/models"
+	"github.com/hftamayo/gotodo/pkg/config"
+	"github.com/hftamayo/gotodo/pkg/events"
+	"gorm.io/gorm"
+)
+
+// relayLockKey is the Postgres advisory lock that keeps a single relay
+// claiming events at a time, the claims then preserve the per-task event order
+const relayLockKey = 72210029
+
+// Relay publishes the events recorded in the outbox table. Delivery is
+// at-least-once: an event is marked published only after every subscriber
+// accepted it, so subscribers must tolerate duplicates. Events that keep
+// failing are dead-lettered after OutboxConfig.MaxAttempts.
+type Relay struct {
+	db        *gorm.DB
+	publisher events.Publisher
+	config    *config.OutboxConfig
+	errorLog  config.ErrorLogger
+}
+
+// NewRelay creates a new outbox relay
+func NewRelay(db *gorm.DB, publisher events.Publisher, outboxConfig *config.OutboxConfig, errorLog config.ErrorLogger) *Relay {
+	if outboxConfig == nil {
+		outboxConfig = config.DefaultOutboxConfig()
+	}
+
+	if errorLog == nil {
+		errorLog = config.NewErrorLoggerWithDefaults()
+	}
+
+	return &Relay{
+		db:        db,
+		publisher: publisher,
+		config:    outboxConfig,
+		errorLog:  errorLog,
+	}
+}
+
+// Start polls the outbox and purges published events until ctx is cancelled
+func (r *Relay) Start(ctx context.Context) {
+	ticker := time.NewTicker(r.config.PollInterval)
+	defer ticker.Stop()
+
+	purgeInterval := time.Hour
+	if r.config.Retention < purgeInterval {
+		purgeInterval = r.config.Retention
+	}
+	purge := time.NewTicker(purgeInterval)
+	defer purge.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			// Drain the backlog before waiting for the next tick
+			for {
+				published, err := r.RelayBatch(ctx)
+				if err != nil {
+					r.logError("relay", err.Error(), map[string]interface{}{"error": err.Error()})
+				}
+				if err != nil || published < r.config.BatchSize || ctx.Err() != nil {
+					break
+				}
+			}
+		case <-purge.C:
+			if err := r.Purge(time.Now().Add(-r.config.Retention)); err != nil {
+				r.logError("purge", err.Error(), map[string]interface{}{"error": err.Error()})
+			}
+		}
+	}
+}
+
+// RelayBatch publishes the oldest pending events in ID order and returns how
+// many were published. Once an event of a task fails, the later events of
+// that task are held back until it succeeds or is dead-lettered so their
+// order is kept.
+func (r *Relay) RelayBatch(ctx context.Context) (int, error) {
+	rows, err := r.claim(ctx)
+	if err != nil || len(rows) == 0 {
+		return 0, err
+	}
+
+	// Subscribers are called outside of any transaction, the claim keeps the
+	// batch away from the other relays until ClaimTimeout
+	publishCtx, cancel := context.WithTimeout(ctx, r.config.ClaimTimeout)
+	defer cancel()
+
+	published := 0
+	var released []uint
+	blocked := make(map[uint]bool)
+	for _, row := range rows {
+		if blocked[row.AggregateID] || publishCtx.Err() != nil {
+			released = append(released, row.ID)
+			continue
+		}
+
+		if err := r.publisher.Publish(publishCtx, toEvent(row)); err != nil {
+			blocked[row.AggregateID] = true
+			if err := r.recordFailure(ctx, row, err); err != nil {
+				return published, err
+			}
+			continue
+		}
+
+		if err := r.db.WithContext(context.WithoutCancel(ctx)).Model(row).Updates(map[string]interface{}{
+			"published_at":    time.Now(),
+			"next_attempt_at": nil,
+			"last_error":      "",
+		}).Error; err != nil {
+			return published, fmt.Errorf("failed to mark outbox event %s published: %w", row.EventID, err)
+		}
+		published++
+	}
+
+	// The events left unpublished stay behind the failed ones of their task
+	if len(released) > 0 {
+		if err := r.db.WithContext(context.WithoutCancel(ctx)).Model(&models.OutboxEvent{}).
+			Where("id IN ?", released).
+			Update("next_attempt_at", nil).Error; err != nil {
+			return published, fmt.Errorf("failed to release outbox events: %w", err)
+		}
+	}
+	return published, nil
+}
+
+// claim loads the next batch and claims it until ClaimTimeout, under the
+// advisory lock so a single relay picks events at a time. An event is due
+// when no earlier event of its task is waiting for a retry or claimed.
+func (r *Relay) claim(ctx context.Context) ([]*models.OutboxEvent, error) {
+	var rows []*models.OutboxEvent
+
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var locked bool
+		if err := tx.Raw("SELECT pg_try_advisory_xact_lock(?)", relayLockKey).Scan(&locked).Error; err != nil {
+			return fmt.Errorf("failed to acquire outbox relay lock: %w", err)
+		}
+		if !locked {
+			// Another instance is claiming
+			return nil
+		}
+
+		now := time.Now()
+		if err := tx.Where("published_at IS NULL AND dead_lettered_at IS NULL").
+			Where("next_attempt_at IS NULL OR next_attempt_at <= ?", now).
+			Where(`NOT EXISTS (SELECT 1 FROM outbox_events earlier
+				WHERE earlier.aggregate_id = outbox_events.aggregate_id AND earlier.id < outbox_events.id
+				AND earlier.published_at IS NULL AND earlier.dead_lettered_at IS NULL
+				AND earlier.next_attempt_at > ?)`, now).
+			Order("id asc").
+			Limit(r.config.BatchSize).
+			Find(&rows).Error; err != nil {
+			return fmt.Errorf("failed to load outbox events: %w", err)
+		}
+		if len(rows) == 0 {
+			return nil
+		}
+
+		ids := make([]uint, len(rows))
+		for i, row := range rows {
+			ids[i] = row.ID
+		}
+		if err := tx.Model(&models.OutboxEvent{}).
+			Where("id IN ?", ids).
+			Update("next_attempt_at", now.Add(r.config.ClaimTimeout)).Error; err != nil {
+			return fmt.Errorf("failed to claim outbox events: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+// recordFailure schedules the retry of an event with exponential backoff, or
+// dead-letters it once it used MaxAttempts
+func (r *Relay) recordFailure(ctx context.Context, row *models.OutboxEvent, publishErr error) error {
+	attempts := row.Attempts + 1
+	now := time.Now()
+	updates := map[string]interface{}{
+		"attempts":        attempts,
+		"last_error":      publishErr.Error(),
+		"next_attempt_at": now.Add(r.backoff(attempts)),
+	}
+
+	metadata := map[string]interface{}{"event_id": row.EventID, "task_id": row.AggregateID, "attempts": attempts, "error": publishErr.Error()}
+	if attempts >= r.config.MaxAttempts {
+		updates["dead_lettered_at"] = now
+		r.logError("dead-letter", fmt.Sprintf("Outbox event %s moved to dead letters after %d attempts: %v", row.EventID, attempts, publishErr), metadata)
+	} else {
+		r.logError("publish", fmt.Sprintf("Failed to publish outbox event %s: %v", row.EventID, publishErr), metadata)
+	}
+
+	if err := r.db.WithContext(context.WithoutCancel(ctx)).Model(row).Updates(updates).Error; err != nil {
+		return fmt.Errorf("failed to record outbox failure for event %s: %w", row.EventID, err)
+	}
+	return nil
+}
+
+// backoff returns InitialBackoff * 2^(attempts-1) capped at MaxBackoff
+func (r *Relay) backoff(attempts int) time.Duration {
+	delay := r.config.InitialBackoff
+	for i := 1; i < attempts && delay < r.config.MaxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > r.config.MaxBackoff {
+		delay = r.config.MaxBackoff
+	}
+	return delay
+}
+
+// Purge deletes the events published before the given time
+func (r *Relay) Purge(before time.Time) error {
+	if err := r.db.Where("published_at IS NOT NULL AND published_at < ?", before).
+		Delete(&models.OutboxEvent{}).Error; err != nil {
+		return fmt.Errorf("failed to purge outbox events: %w", err)
+	}
+	return nil
+}
+
+func (r *Relay) logError(operation, errorMsg string, metadata map[string]interface{}) {
+	r.errorLog.LogError(context.Background(), "outbox-relay", operation, errorMsg, metadata)
+}
+
+func toEvent(row *models.OutboxEvent) events.Event {
+	var data json.RawMessage
+	if row.Payload != "" {
+		data = json.RawMessage(row.Payload)
+	}
+
+	return events.Event{
+		ID:         row.EventID,
+		Type:       events.EventType(row.EventType),
+		TaskID:     row.AggregateID,
+		Owner:      row.Owner,
+		Data:       data,
+		OccurredAt: row.OccurredAt,
+	}
+}
@@ -0,0 +1,93 @@
+// Package safehttp builds the HTTP clients used to call URLs supplied by
+// users, such as webhook subscriptions and reminder webhooks, so they can't
+// be pointed at the API's own network.
+package safehttp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"syscall"
+	"time"
+)
+
+// resolveTimeout bounds the lookup ValidateURL makes of the URL's host
+const resolveTimeout = 5 * time.Second
+
+var (
+	ErrInvalidURL     = errors.New("URL must be an absolute http or https URL")
+	ErrBlockedAddress = errors.New("address is not allowed")
+)
+
+// NewClient returns a client that refuses to connect to internal addresses.
+// The check runs on the resolved address right before connecting, so a
+// public name that resolves to an internal address is refused as well. The
+// environment proxy is ignored, it would connect on the client's behalf,
+// and redirects are not followed, the 3xx response is returned instead.
+func NewClient(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{Timeout: timeout, Control: denyInternalAddress}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: transport,
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// ValidateURL checks that rawURL is an absolute http or https URL whose
+// host resolves to public addresses only. The client still checks every
+// connection, DNS answers can change after validation.
+func ValidateURL(ctx context.Context, rawURL string) error {
+	parsed, err := url.Parse(rawURL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Hostname() == "" {
+		return ErrInvalidURL
+	}
+
+	host := parsed.Hostname()
+	if ip := net.ParseIP(host); ip != nil {
+		if IsBlocked(ip) {
+			return fmt.Errorf("%w: %s", ErrBlockedAddress, ip)
+		}
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, resolveTimeout)
+	defer cancel()
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return fmt.Errorf("%w: failed to resolve %s", ErrInvalidURL, host)
+	}
+	for _, addr := range addrs {
+		if IsBlocked(addr.IP) {
+			return fmt.Errorf("%w: %s resolves to %s", ErrBlockedAddress, host, addr.IP)
+		}
+	}
+	return nil
+}
+
+// IsBlocked reports whether ip is a loopback, private, link-local,
+// unspecified or multicast address
+func IsBlocked(ip net.IP) bool {
+	return ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() || ip.IsLinkLocalUnicast() ||
+		ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() || ip.IsMulticast()
+}
+
+func denyInternalAddress(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || IsBlocked(ip) {
+		return fmt.Errorf("%w: %s", ErrBlockedAddress, host)
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+package safehttp_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/hftamayo/gotodo/pkg/safehttp"
+)
+
+func TestValidateURL(t *testing.T) {
+	tests := []struct {
+		name string
+		url  string
+		want error
+	}{
+		{"public address", "https://93.184.216.34/hook", nil},
+		{"public ipv6 address", "http://[2606:2800:220:1:248:1893:25c8:1946]/hook", nil},
+		{"ftp scheme", "ftp://93.184.216.34/hook", safehttp.ErrInvalidURL},
+		{"relative url", "/hook", safehttp.ErrInvalidURL},
+		{"no host", "http:///hook", safehttp.ErrInvalidURL},
+		{"loopback", "http://127.0.0.1:8080/hook", safehttp.ErrBlockedAddress},
+		{"loopback name", "http://localhost/hook", safehttp.ErrBlockedAddress},
+		{"ipv6 loopback", "http://[::1]/hook", safehttp.ErrBlockedAddress},
+		{"metadata service", "http://169.254.169.254/latest/meta-data", safehttp.ErrBlockedAddress},
+		{"private 10/8", "http://10.0.0.1/hook", safehttp.ErrBlockedAddress},
+		{"private 192.168/16", "https://192.168.1.10/hook", safehttp.ErrBlockedAddress},
+		{"ipv6 unique local", "http://[fd00::1]/hook", safehttp.ErrBlockedAddress},
+		{"unspecified", "http://0.0.0.0/hook", safehttp.ErrBlockedAddress},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := safehttp.ValidateURL(context.Background(), tt.url)
+			if tt.want == nil && err != nil {
+				t.Fatalf("ValidateURL(%q) = %v, want nil", tt.url, err)
+			}
+			if tt.want != nil && !errors.Is(err, tt.want) {
+				t.Fatalf("ValidateURL(%q) = %v, want %v", tt.url, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestClientRefusesInternalAddresses(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	_, err := safehttp.NewClient(time.Second).Get(server.URL)
+	if !errors.Is(err, safehttp.ErrBlockedAddress) {
+		t.Fatalf("Get(%s) error = %v, want %v", server.URL, err, safehttp.ErrBlockedAddress)
+	}
+}
+
+func TestClientDoesNotFollowRedirects(t *testing.T) {
+	client := safehttp.NewClient(time.Second)
+	// The transport is swapped so the redirect can be served from loopback
+	client.Transport = http.DefaultTransport
+
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("redirect was followed")
+	}))
+	defer target.Close()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, target.URL, http.StatusFound)
+	}))
+	defer server.Close()
+
+	resp, err := client.Get(server.URL)
+	if err != nil {
+		t.Fatalf("Get(%s) error = %v", server.URL, err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusFound {
+		t.Fatalf("status = %d, want %d", resp.StatusCode, http.StatusFound)
+	}
+}
@@ -1,7 +1,7 @@
 package seeder
 
 import (
-	"log"
+	"log/slog"
 	"os"
 
 	"github.com/hftamayo/gotodo/api/v1/models"
@@ -24,25 +24,25 @@ func SeedData(db *gorm.DB) error {
 		{FullName: "user02", Email: "mary@tamayo.com", Password: os.Getenv("USER02_PASSWORD")},
 	}
 
-	log.Println("Starting to seed users...")
+	slog.Info("Starting to seed users")
 	for i := range users {
 		hashedPassword, err := utils.HashPassword(users[i].Password)
 		if err != nil {
-			log.Printf("Error hashing password for user %s: %v\n", users[i].FullName, err)
+			slog.Error("Error hashing password", "user", users[i].FullName, "error", err)
 			tx.Rollback()
 			return err
 		}
 		users[i].Password = hashedPassword
 
-		log.Printf("Seeding user: %s\n", users[i].FullName)
+		slog.Info("Seeding user", "user", users[i].FullName)
 		if err := tx.Create(&users[i]).Error; err != nil {
-			log.Printf("Error seeding user %s: %v\n", users[i].FullName, err)
+			slog.Error("Error seeding user", "user", users[i].FullName, "error", err)
 			tx.Rollback()
 			return err
 		}
 	}
 
-	log.Println("Finished seeding users.")
+	slog.Info("Finished seeding users")
 
 	tasks := []models.Task{
 		{Title: "backup the database", Description: "create the entire backup using incremental", Owner: users[0].ID},
@@ -51,15 +51,15 @@ func SeedData(db *gorm.DB) error {
 	}
 
 	// Insert the data into the database
-	log.Println("Starting to seed tasks...")
+	slog.Info("Starting to seed tasks")
 	for _, task := range tasks {
-		log.Printf("Seeding task: %s\n", task.Title)
+		slog.Info("Seeding task", "title", task.Title)
 		if err := tx.Create(&task).Error; err != nil {
-			log.Printf("Error seeding todo %s: %v\n", task.Title, err)
+			slog.Error("Error seeding task", "title", task.Title, "error", err)
 			tx.Rollback()
 		}
 	}
-	log.Println("Finished seeding tasks.")
+	slog.Info("Finished seeding tasks")
 
 	if err := tx.Commit().Error; err != nil {
 		tx.Rollback()
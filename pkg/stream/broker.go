@@ -0,0 +1,107 @@
+package stream
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"time"
+
+	"github.com/hftamayo/gotodo/pkg/events"
+	"github.com/hftamayo/gotodo/pkg/utils"
+)
+
+// DefaultChannel is the Redis pub/sub channel shared by every API instance
+const DefaultChannel = "tasks:events"
+
+// Broker fans task events out to the hubs of every API instance
+type Broker interface {
+	events.Publisher
+	Start(ctx context.Context)
+}
+
+// LocalBroker delivers events only to clients connected to this instance
+type LocalBroker struct {
+	hub *Hub
+}
+
+// NewLocalBroker creates an in-process broker
+func NewLocalBroker(hub *Hub) *LocalBroker {
+	return &LocalBroker{hub: hub}
+}
+
+func (b *LocalBroker) Publish(ctx context.Context, event events.Event) error {
+	b.hub.Broadcast(event)
+	return nil
+}
+
+func (b *LocalBroker) Start(ctx context.Context) {}
+
+// RedisBroker relays events through Redis pub/sub so clients connected to any
+// instance receive them. Events published while Redis is unreachable are
+// still delivered to the clients of the publishing instance.
+type RedisBroker struct {
+	client  utils.RedisClientInterface
+	channel string
+	hub     *Hub
+}
+
+// NewRedisBroker creates a broker backed by Redis pub/sub
+func NewRedisBroker(client utils.RedisClientInterface, hub *Hub) *RedisBroker {
+	return &RedisBroker{
+		client:  client,
+		channel: DefaultChannel,
+		hub:     hub,
+	}
+}
+
+// NewBroker returns a Redis broker when a client is available and falls back
+// to the in-process broker otherwise
+func NewBroker(client utils.RedisClientInterface, hub *Hub) Broker {
+	if client == nil {
+		return NewLocalBroker(hub)
+	}
+	return NewRedisBroker(client, hub)
+}
+
+func (b *RedisBroker) Publish(ctx context.Context, event events.Event) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	if err := b.client.Publish(ctx, b.channel, payload).Err(); err != nil {
+		slog.Warn("Failed to publish task event to Redis, delivering locally", "error", err)
+		b.hub.Broadcast(event)
+		return err
+	}
+	return nil
+}
+
+// Start relays the events received from Redis to the local hub until ctx is
+// cancelled, go-redis re-subscribes on its own after a connection loss
+func (b *RedisBroker) Start(ctx context.Context) {
+	pubsub := b.client.Subscribe(ctx, b.channel)
+	defer pubsub.Close()
+
+	messages := pubsub.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case msg, ok := <-messages:
+			if !ok {
+				return
+			}
+
+			var event events.Event
+			if err := json.Unmarshal([]byte(msg.Payload), &event); err != nil {
+				slog.Warn("Discarding malformed task event from Redis", "error", err)
+				continue
+			}
+			b.hub.Broadcast(event)
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package stream
+
+import (
+	"sync"
+
+	"github.com/hftamayo/gotodo/pkg/events"
+)
+
+// Hub keeps the event subscriptions of the clients connected to this instance
+type Hub struct {
+	subscribers map[uint]map[*Subscription]struct{}
+	mu          sync.RWMutex
+	closed      bool
+}
+
+// Subscription receives the events of one owner on C until it is closed
+type Subscription struct {
+	C      <-chan events.Event
+	ch     chan events.Event
+	owner  uint
+	hub    *Hub
+	closed bool
+}
+
+// NewHub creates a new subscription hub
+func NewHub() *Hub {
+	return &Hub{
+		subscribers: make(map[uint]map[*Subscription]struct{}),
+	}
+}
+
+// Subscribe registers a subscription for the events of the given owner,
+// buffer is the number of events kept for a client that falls behind
+func (h *Hub) Subscribe(owner uint, buffer int) *Subscription {
+	ch := make(chan events.Event, buffer)
+	sub := &Subscription{C: ch, ch: ch, owner: owner, hub: h}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.closed {
+		sub.closed = true
+		close(ch)
+		return sub
+	}
+	if h.subscribers[owner] == nil {
+		h.subscribers[owner] = make(map[*Subscription]struct{})
+	}
+	h.subscribers[owner][sub] = struct{}{}
+	return sub
+}
+
+// Broadcast delivers the event to the subscriptions of its owner, a client
+// whose buffer is full misses the event rather than blocking the others
+func (h *Hub) Broadcast(event events.Event) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	for sub := range h.subscribers[event.Owner] {
+		select {
+		case sub.ch <- event:
+		default:
+		}
+	}
+}
+
+// Count returns the number of open subscriptions
+func (h *Hub) Count() int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	count := 0
+	for _, subs := range h.subscribers {
+		count += len(subs)
+	}
+	return count
+}
+
+// Close closes every subscription so the streams end and the server can
+// shut down, later subscriptions are closed right away
+func (h *Hub) Close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.closed = true
+	for _, subs := range h.subscribers {
+		for sub := range subs {
+			sub.closed = true
+			close(sub.ch)
+		}
+	}
+	h.subscribers = make(map[uint]map[*Subscription]struct{})
+}
+
+// Close unregisters the subscription and closes its channel
+func (s *Subscription) Close() {
+	s.hub.mu.Lock()
+	defer s.hub.mu.Unlock()
+
+	if s.closed {
+		return
+	}
+	s.closed = true
+
+	delete(s.hub.subscribers[s.owner], s)
+	if len(s.hub.subscribers[s.owner]) == 0 {
+		delete(s.hub.subscribers, s.owner)
+	}
+	close(s.ch)
+}
@@ -0,0 +1,53 @@
+package stream
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/hftamayo/gotodo/pkg/events"
+	"github.com/hftamayo/gotodo/pkg/utils"
+	"github.com/redis/go-redis/v9"
+)
+
+// StreamWriter appends task events to a Redis stream, so external consumers
+// can read them with consumer groups and replay what they missed
+type StreamWriter struct {
+	client utils.RedisClientInterface
+	stream string
+	maxLen int64
+}
+
+// NewStreamWriter creates a writer for the given stream, maxLen caps the
+// stream length approximately, zero keeps every entry
+func NewStreamWriter(client utils.RedisClientInterface, stream string, maxLen int64) *StreamWriter {
+	return &StreamWriter{
+		client: client,
+		stream: stream,
+		maxLen: maxLen,
+	}
+}
+
+// Publish adds the event to the stream, the entry carries the event ID so
+// consumers can drop the duplicates of at-least-once delivery
+func (w *StreamWriter) Publish(ctx context.Context, event events.Event) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	return w.client.XAdd(ctx, &redis.XAddArgs{
+		Stream: w.stream,
+		MaxLen: w.maxLen,
+		Approx: w.maxLen > 0,
+		Values: map[string]interface{}{
+			"id":      event.ID,
+			"type":    string(event.Type),
+			"taskId":  event.TaskID,
+			"payload": payload,
+		},
+	}).Err()
+}
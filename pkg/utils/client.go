@@ -0,0 +1,43 @@
+package utils
+
+import "context"
+
+type (
+	clientContextKey    struct{}
+	requestIDContextKey struct{}
+)
+
+// RequestClient is who sent the request a context belongs to
+type RequestClient struct {
+	IP       string // resolved through the trusted proxies
+	Identity string // what the rate limiter counts against, see middleware.Identity
+	User     string // owner of the API key, empty for anonymous clients
+}
+
+// WithClient returns a copy of ctx carrying client
+func WithClient(ctx context.Context, client RequestClient) context.Context {
+	return context.WithValue(ctx, clientContextKey{}, client)
+}
+
+// ClientFromContext returns the client of the request ctx belongs to
+func ClientFromContext(ctx context.Context) (RequestClient, bool) {
+	if ctx == nil {
+		return RequestClient{}, false
+	}
+	client, ok := ctx.Value(clientContextKey{}).(RequestClient)
+	return client, ok
+}
+
+// WithRequestID returns a copy of ctx carrying the ID of its request
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDContextKey{}, requestID)
+}
+
+// RequestIDFromContext returns the ID of the request ctx belongs to, "" when unknown
+func RequestIDFromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	requestID, _ := ctx.Value(requestIDContextKey{}).(string)
+	return requestID
+}
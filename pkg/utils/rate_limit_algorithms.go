@@ -0,0 +1,134 @@
+package utils
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// RateLimitAlgorithm selects how a RateLimiter counts requests
+type RateLimitAlgorithm string
+
+const (
+	// AlgorithmFixedWindow counts requests per window, a client may send up to
+	// twice the limit around a window boundary
+	AlgorithmFixedWindow RateLimitAlgorithm = "fixed_window"
+	// AlgorithmSlidingWindow weights the previous window by how much of it
+	// still overlaps the sliding window, smoothing the boundary bursts
+	AlgorithmSlidingWindow RateLimitAlgorithm = "sliding_window"
+	// AlgorithmTokenBucket refills limit tokens per window, bursts are bounded
+	// by limit and the sustained rate by limit/window
+	AlgorithmTokenBucket RateLimitAlgorithm = "token_bucket"
+)
+
+// ParseRateLimitAlgorithm validates the name of an algorithm
+func ParseRateLimitAlgorithm(name string) (RateLimitAlgorithm, error) {
+	switch algorithm := RateLimitAlgorithm(name); algorithm {
+	case AlgorithmFixedWindow, AlgorithmSlidingWindow, AlgorithmTokenBucket:
+		return algorithm, nil
+	default:
+		return "", fmt.Errorf("unknown rate limit algorithm: %q", name)
+	}
+}
+
+// RateLimitResult is the outcome of counting one request
+type RateLimitResult struct {
+	Allowed   bool
+	Limit     int64
+	Remaining int64     // requests left before the next rejection
+	ResetAt   time.Time // when the client is back to its full quota
+	// RetryAfter is how long a rejected client should wait, zero when allowed
+	RetryAfter time.Duration
+	// Quota is the daily quota of the client, nil when it has none
+	Quota *QuotaResult
+}
+
+// fixedWindowResult builds the result of a fixed window holding count
+// requests, including this one, that expires in ttl
+func fixedWindowResult(count, limit int64, ttl time.Duration, now time.Time) RateLimitResult {
+	result := RateLimitResult{
+		Allowed:   count <= limit,
+		Limit:     limit,
+		Remaining: max(limit-count, 0),
+		ResetAt:   now.Add(ttl),
+	}
+	if !result.Allowed {
+		result.RetryAfter = ttl
+	}
+	return result
+}
+
+// slidingWindow is the position of now in the windows of a sliding window counter
+type slidingWindow struct {
+	index   int64         // number of the current fixed window
+	elapsed time.Duration // time spent in the current fixed window
+}
+
+func newSlidingWindow(now time.Time, window time.Duration) slidingWindow {
+	nanos := now.UnixNano()
+	return slidingWindow{
+		index:   nanos / int64(window),
+		elapsed: time.Duration(nanos % int64(window)),
+	}
+}
+
+// estimate weights previous by the share of the previous window still covered
+func (w slidingWindow) estimate(previous, current int64, window time.Duration) float64 {
+	return float64(previous)*float64(window-w.elapsed)/float64(window) + float64(current)
+}
+
+// result builds the result of a sliding window counter, current
+// includes this request when it was allowed
+func (w slidingWindow) result(allowed bool, previous, current, limit int64, window time.Duration, now time.Time) RateLimitResult {
+	untilNext := window - w.elapsed
+	result := RateLimitResult{
+		Allowed: allowed,
+		Limit:   limit,
+		ResetAt: now.Add(untilNext),
+	}
+	if current > 0 {
+		// The current window keeps counting until it has fully slid out
+		result.ResetAt = result.ResetAt.Add(window)
+	}
+	if allowed {
+		result.Remaining = max(int64(math.Floor(float64(limit)-w.estimate(previous, current, window))), 0)
+		return result
+	}
+
+	// Wait until the estimate leaves room for one more request
+	room := float64(limit - 1)
+	if current > int64(room) {
+		// Only once the current window is the previous one and has slid enough
+		share := 1 - room/float64(current)
+		result.RetryAfter = untilNext + time.Duration(share*float64(window))
+	} else {
+		share := 1 - (room-float64(current))/float64(previous)
+		result.RetryAfter = time.Duration(share*float64(window)) - w.elapsed
+	}
+	result.RetryAfter = ceilMillisecond(result.RetryAfter)
+	return result
+}
+
+// tokenBucketResult builds the result of a bucket left with tokens after this request
+func tokenBucketResult(allowed bool, tokens float64, limit int64, window time.Duration, now time.Time) RateLimitResult {
+	perToken := float64(window) / float64(limit)
+	result := RateLimitResult{
+		Allowed:   allowed,
+		Limit:     limit,
+		Remaining: int64(math.Floor(tokens)),
+		ResetAt:   now.Add(time.Duration((float64(limit) - tokens) * perToken)),
+	}
+	if !allowed {
+		result.RetryAfter = ceilMillisecond(time.Duration((1 - tokens) * perToken))
+	}
+	return result
+}
+
+// ceilMillisecond rounds a wait up to the millisecond precision of the
+// scripts, and to at least one millisecond, so retrying after it succeeds
+func ceilMillisecond(d time.Duration) time.Duration {
+	if rest := d % time.Millisecond; rest > 0 {
+		d += time.Millisecond - rest
+	}
+	return max(d, time.Millisecond)
+}
@@ -0,0 +1,241 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Duration is a time.Duration written as "30s" or "1m" in JSON
+type Duration time.Duration
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var value string
+	if err := json.Unmarshal(data, &value); err != nil {
+		return fmt.Errorf("duration must be a string like \"1m\": %w", err)
+	}
+	parsed, err := time.ParseDuration(value)
+	if err != nil {
+		return err
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+// RateLimitScope holds limits per operation type, a zero Window keeps the
+// window of the enclosing policy
+type RateLimitScope struct {
+	Window     Duration                `json:"window,omitempty"`
+	Operations map[OperationType]int64 `json:"operations,omitempty"`
+	// DailyQuota caps the requests of a role per UTC day, 0 is unlimited.
+	// Only role quotas apply, anonymous clients are bound by their limits.
+	DailyQuota int64 `json:"dailyQuota,omitempty"`
+}
+
+// RouteRateLimit overrides the limits of the requests whose path starts with Prefix
+type RouteRateLimit struct {
+	Name   string `json:"name"`
+	Prefix string `json:"prefix"`
+	RateLimitScope
+}
+
+// RateLimitPolicy decides the limit of a request from its operation type,
+// its route group and the role of the client. A route limit replaces the
+// default one, and so does a role limit, but a role never gets more than the
+// route allows: when both are set the stricter one applies. Roles are the
+// plan tiers of the API keys.
+type RateLimitPolicy struct {
+	Algorithm RateLimitAlgorithm `json:"algorithm"`
+	RateLimitScope
+	Routes []RouteRateLimit          `json:"routes,omitempty"`
+	Roles  map[string]RateLimitScope `json:"roles,omitempty"`
+}
+
+// Plan tiers of the default policy
+const (
+	TierFree     = "free"
+	TierPro      = "pro"
+	TierInternal = "internal"
+)
+
+// DefaultRateLimitPolicy returns the limits used without configuration
+func DefaultRateLimitPolicy() *RateLimitPolicy {
+	return &RateLimitPolicy{
+		Algorithm: AlgorithmSlidingWindow,
+		RateLimitScope: RateLimitScope{
+			Window: Duration(time.Minute),
+			Operations: map[OperationType]int64{
+				OperationRead:     100,
+				OperationWrite:    30,
+				OperationPrefetch: 200,
+			},
+		},
+		Roles: map[string]RateLimitScope{
+			TierFree: {
+				Operations: map[OperationType]int64{OperationRead: 100, OperationWrite: 30, OperationPrefetch: 200},
+				DailyQuota: 10000,
+			},
+			TierPro: {
+				Operations: map[OperationType]int64{OperationRead: 1000, OperationWrite: 300, OperationPrefetch: 2000},
+				DailyQuota: 250000,
+			},
+			TierInternal: {
+				Operations: map[OperationType]int64{OperationRead: 10000, OperationWrite: 3000, OperationPrefetch: 20000},
+			},
+		},
+	}
+}
+
+// Validate checks the policy can be applied
+func (p *RateLimitPolicy) Validate() error {
+	if _, err := ParseRateLimitAlgorithm(string(p.Algorithm)); err != nil {
+		return err
+	}
+	if time.Duration(p.Window) < time.Second {
+		return fmt.Errorf("window must be at least 1s, got %s", time.Duration(p.Window))
+	}
+	if err := p.RateLimitScope.validate("default"); err != nil {
+		return err
+	}
+	for _, op := range operationTypes {
+		if _, ok := p.Operations[op]; !ok {
+			return fmt.Errorf("default: missing the %s limit", op)
+		}
+	}
+
+	names := make(map[string]bool, len(p.Routes))
+	for _, route := range p.Routes {
+		if route.Name == "" || names[route.Name] {
+			return fmt.Errorf("route %q: names must be set and unique", route.Name)
+		}
+		names[route.Name] = true
+		if !strings.HasPrefix(route.Prefix, "/") {
+			return fmt.Errorf("route %q: prefix must start with /", route.Name)
+		}
+		if err := route.RateLimitScope.validate("route " + route.Name); err != nil {
+			return err
+		}
+	}
+	for role, scope := range p.Roles {
+		if role == "" {
+			return fmt.Errorf("roles must have a name")
+		}
+		if err := scope.validate("role " + role); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s RateLimitScope) validate(name string) error {
+	if s.Window != 0 && time.Duration(s.Window) < time.Second {
+		return fmt.Errorf("%s: window must be at least 1s, got %s", name, time.Duration(s.Window))
+	}
+	if s.DailyQuota < 0 {
+		return fmt.Errorf("%s: the daily quota can't be negative", name)
+	}
+	for op, limit := range s.Operations {
+		if !op.valid() {
+			return fmt.Errorf("%s: unknown operation type %q", name, op)
+		}
+		if limit <= 0 {
+			return fmt.Errorf("%s: the %s limit must be greater than 0", name, op)
+		}
+	}
+	return nil
+}
+
+// Resolve returns the limit of an operation on path for a client with role,
+// and the name of the route group counted separately ("" for the default one)
+func (p *RateLimitPolicy) Resolve(op OperationType, path, role string) (*RateLimitConfig, string, error) {
+	if !op.valid() {
+		return nil, "", fmt.Errorf("unknown operation type: %s", op)
+	}
+
+	limit, window := p.Operations[op], p.Window
+
+	var group *RouteRateLimit
+	for i := range p.Routes {
+		route := &p.Routes[i]
+		if strings.HasPrefix(path, route.Prefix) && (group == nil || len(route.Prefix) > len(group.Prefix)) {
+			group = route
+		}
+	}
+	var groupName string
+	routeLimited := false
+	if group != nil {
+		groupName = group.Name
+		if routeLimit, ok := group.Operations[op]; ok {
+			limit, routeLimited = routeLimit, true
+			if group.Window != 0 {
+				window = group.Window
+			}
+		}
+	}
+
+	if scope, ok := p.Roles[role]; ok && role != "" {
+		if roleLimit, ok := scope.Operations[op]; ok {
+			roleWindow := window
+			if scope.Window != 0 {
+				roleWindow = scope.Window
+			}
+			if !routeLimited || stricter(roleLimit, roleWindow, limit, window) {
+				limit, window = roleLimit, roleWindow
+			}
+		}
+	}
+
+	return &RateLimitConfig{
+		MaxRequests: limit,
+		Window:      int64(time.Duration(window) / time.Second),
+	}, groupName, nil
+}
+
+// stricter reports whether limitA per windowA allows fewer requests over
+// time than limitB per windowB
+func stricter(limitA int64, windowA Duration, limitB int64, windowB Duration) bool {
+	return limitA*int64(time.Duration(windowB)/time.Second) < limitB*int64(time.Duration(windowA)/time.Second)
+}
+
+// DailyQuota returns the daily quota of role, 0 when it has none
+func (p *RateLimitPolicy) DailyQuota(role string) int64 {
+	if role == "" {
+		return 0
+	}
+	return p.Roles[role].DailyQuota
+}
+
+// Clone copies the policy, the copy can be changed while the original is in use
+func (p *RateLimitPolicy) Clone() *RateLimitPolicy {
+	c := *p
+	c.Operations = cloneLimits(p.Operations)
+	c.Routes = make([]RouteRateLimit, len(p.Routes))
+	for i, route := range p.Routes {
+		route.Operations = cloneLimits(route.Operations)
+		c.Routes[i] = route
+	}
+	if p.Roles != nil {
+		c.Roles = make(map[string]RateLimitScope, len(p.Roles))
+		for role, scope := range p.Roles {
+			scope.Operations = cloneLimits(scope.Operations)
+			c.Roles[role] = scope
+		}
+	}
+	return &c
+}
+
+func cloneLimits(limits map[OperationType]int64) map[OperationType]int64 {
+	if limits == nil {
+		return nil
+	}
+	c := make(map[OperationType]int64, len(limits))
+	for op, limit := range limits {
+		c[op] = limit
+	}
+	return c
+}
@@ -0,0 +1,160 @@
+package utils_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hftamayo/gotodo/pkg/utils"
+)
+
+func resolvePolicy() *utils.RateLimitPolicy {
+	return &utils.RateLimitPolicy{
+		Algorithm: utils.AlgorithmSlidingWindow,
+		RateLimitScope: utils.RateLimitScope{
+			Window:     utils.Duration(time.Minute),
+			Operations: map[utils.OperationType]int64{utils.OperationRead: 100, utils.OperationWrite: 30, utils.OperationPrefetch: 200},
+		},
+		Routes: []utils.RouteRateLimit{
+			{Name: "tasks", Prefix: "/tasks", RateLimitScope: utils.RateLimitScope{
+				Operations: map[utils.OperationType]int64{utils.OperationRead: 500},
+			}},
+			{Name: "import", Prefix: "/tasks/task/import", RateLimitScope: utils.RateLimitScope{
+				Window:     utils.Duration(10 * time.Second),
+				Operations: map[utils.OperationType]int64{utils.OperationWrite: 2},
+			}},
+		},
+		Roles: map[string]utils.RateLimitScope{
+			utils.TierPro: {Operations: map[utils.OperationType]int64{utils.OperationRead: 1000, utils.OperationWrite: 300}},
+			"slow": {
+				Window:     utils.Duration(time.Hour),
+				Operations: map[utils.OperationType]int64{utils.OperationWrite: 60},
+			},
+		},
+	}
+}
+
+func TestRateLimitPolicyResolve(t *testing.T) {
+	tests := []struct {
+		name       string
+		op         utils.OperationType
+		path       string
+		role       string
+		wantLimit  int64
+		wantWindow int64
+		wantGroup  string
+	}{
+		{"default", utils.OperationWrite, "/health", "", 30, 60, ""},
+		{"route replaces default", utils.OperationRead, "/tasks/task/1", "", 500, 60, "tasks"},
+		{"route without the operation keeps default", utils.OperationWrite, "/tasks/task/1", "", 30, 60, "tasks"},
+		{"longest prefix wins", utils.OperationWrite, "/tasks/task/import", "", 2, 10, "import"},
+		{"role replaces default", utils.OperationWrite, "/health", utils.TierPro, 300, 60, ""},
+		{"role without the operation keeps default", utils.OperationPrefetch, "/health", utils.TierPro, 200, 60, ""},
+		{"unknown role keeps default", utils.OperationWrite, "/health", "gold", 30, 60, ""},
+		{"stricter route caps role", utils.OperationWrite, "/tasks/task/import", utils.TierPro, 2, 10, "import"},
+		{"role without the operation keeps route", utils.OperationRead, "/tasks/task/1", "slow", 500, 60, "tasks"},
+		{"role stricter than route", utils.OperationWrite, "/tasks/task/import", "slow", 60, 3600, "import"},
+		{"looser role than route", utils.OperationRead, "/tasks/task/1", utils.TierPro, 500, 60, "tasks"},
+	}
+
+	policy := resolvePolicy()
+	if err := policy.Validate(); err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config, group, err := policy.Resolve(tt.op, tt.path, tt.role)
+			if err != nil {
+				t.Fatalf("Resolve() error = %v", err)
+			}
+			if config.MaxRequests != tt.wantLimit || config.Window != tt.wantWindow || group != tt.wantGroup {
+				t.Fatalf("Resolve() = %d per %ds in %q, want %d per %ds in %q",
+					config.MaxRequests, config.Window, group, tt.wantLimit, tt.wantWindow, tt.wantGroup)
+			}
+		})
+	}
+}
+
+func TestRateLimitPolicyValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		change  func(p *utils.RateLimitPolicy)
+		wantErr bool
+	}{
+		{"default policy", func(p *utils.RateLimitPolicy) {}, false},
+		{"unknown algorithm", func(p *utils.RateLimitPolicy) { p.Algorithm = "leaky_bucket" }, true},
+		{"window under a second", func(p *utils.RateLimitPolicy) { p.Window = utils.Duration(500 * time.Millisecond) }, true},
+		{"missing default operation", func(p *utils.RateLimitPolicy) { delete(p.Operations, utils.OperationPrefetch) }, true},
+		{"zero limit", func(p *utils.RateLimitPolicy) { p.Operations[utils.OperationRead] = 0 }, true},
+		{"unknown operation", func(p *utils.RateLimitPolicy) { p.Operations["delete"] = 5 }, true},
+		{"route", func(p *utils.RateLimitPolicy) {
+			p.Routes = []utils.RouteRateLimit{{Name: "import", Prefix: "/tasks/task/import"}}
+		}, false},
+		{"route without name", func(p *utils.RateLimitPolicy) {
+			p.Routes = []utils.RouteRateLimit{{Prefix: "/tasks"}}
+		}, true},
+		{"duplicate route names", func(p *utils.RateLimitPolicy) {
+			p.Routes = []utils.RouteRateLimit{{Name: "tasks", Prefix: "/tasks"}, {Name: "tasks", Prefix: "/tasks/task"}}
+		}, true},
+		{"route prefix without slash", func(p *utils.RateLimitPolicy) {
+			p.Routes = []utils.RouteRateLimit{{Name: "tasks", Prefix: "tasks"}}
+		}, true},
+		{"route window under a second", func(p *utils.RateLimitPolicy) {
+			p.Routes = []utils.RouteRateLimit{{Name: "tasks", Prefix: "/tasks",
+				RateLimitScope: utils.RateLimitScope{Window: utils.Duration(time.Millisecond)}}}
+		}, true},
+		{"role with negative quota", func(p *utils.RateLimitPolicy) {
+			p.Roles[utils.TierFree] = utils.RateLimitScope{DailyQuota: -1}
+		}, true},
+		{"role without name", func(p *utils.RateLimitPolicy) { p.Roles[""] = utils.RateLimitScope{} }, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policy := utils.DefaultRateLimitPolicy()
+			tt.change(policy)
+			if err := policy.Validate(); (err != nil) != tt.wantErr {
+				t.Fatalf("Validate() error = %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRateLimitPolicyDailyQuota(t *testing.T) {
+	policy := utils.DefaultRateLimitPolicy()
+	for role, want := range map[string]int64{"": 0, utils.TierFree: 10000, utils.TierPro: 250000, utils.TierInternal: 0, "gold": 0} {
+		if got := policy.DailyQuota(role); got != want {
+			t.Errorf("DailyQuota(%q) = %d, want %d", role, got, want)
+		}
+	}
+}
+
+func TestRateLimiterSetPolicy(t *testing.T) {
+	limiter := utils.NewRateLimiter(nil)
+
+	policy := utils.DefaultRateLimitPolicy()
+	policy.Operations[utils.OperationRead] = 1
+	if err := limiter.SetPolicy(policy); err != nil {
+		t.Fatalf("SetPolicy() error = %v", err)
+	}
+	// The limiter keeps its own copy
+	policy.Operations[utils.OperationRead] = 1000
+	if got := limiter.GetLimitForOperation(utils.OperationRead); got != 1 {
+		t.Fatalf("read limit = %d after changing the applied policy, want 1", got)
+	}
+
+	if result, _ := limiter.Take("ip:203.0.113.7", utils.OperationRead); !result.Allowed {
+		t.Fatal("first request rejected")
+	}
+	if result, _ := limiter.Take("ip:203.0.113.7", utils.OperationRead); result.Allowed {
+		t.Fatal("second request allowed over a limit of 1")
+	}
+
+	invalid := utils.DefaultRateLimitPolicy()
+	invalid.Algorithm = "leaky_bucket"
+	if err := limiter.SetPolicy(invalid); err == nil {
+		t.Fatal("SetPolicy() accepted an invalid policy")
+	}
+	if got := limiter.Policy().Algorithm; got != utils.AlgorithmSlidingWindow {
+		t.Fatalf("algorithm = %s after an invalid policy, want the current one kept", got)
+	}
+}
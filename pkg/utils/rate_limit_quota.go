@@ -0,0 +1,64 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// QuotaResult is the daily quota usage of a client
+type QuotaResult struct {
+	Limit     int64     `json:"limit"`
+	Used      int64     `json:"used"`
+	Remaining int64     `json:"remaining"` // negative on the request exceeding the quota
+	ResetAt   time.Time `json:"resetAt"`
+}
+
+// QuotaUsage returns the daily quota usage of a client with role without
+// counting a request, nil when the role has no quota
+func (r *RateLimiter) QuotaUsage(clientID, role string) (*QuotaResult, error) {
+	limit := r.policy.Load().DailyQuota(role)
+	if limit <= 0 {
+		return nil, nil
+	}
+
+	now := time.Now()
+	key, resetAt := getQuotaKey(clientID, now)
+	var used int64
+	if !r.viaRedis(now, func(ctx context.Context) (err error) {
+		used, err = r.quotaUsedRedis(ctx, key)
+		return err
+	}) {
+		used = r.Fallback.QuotaUsed(key, now)
+	}
+	return newQuotaResult(used, limit, resetAt), nil
+}
+
+// takeQuota counts a request on the daily quota of a client
+func (r *RateLimiter) takeQuota(clientID string, limit int64, now time.Time) *QuotaResult {
+	key, resetAt := getQuotaKey(clientID, now)
+	var used int64
+	if !r.viaRedis(now, func(ctx context.Context) (err error) {
+		used, err = r.takeQuotaRedis(ctx, key, resetAt)
+		return err
+	}) {
+		used = r.Fallback.TakeQuota(key, resetAt, now)
+	}
+	return newQuotaResult(used, limit, resetAt)
+}
+
+func newQuotaResult(used, limit int64, resetAt time.Time) *QuotaResult {
+	return &QuotaResult{
+		Limit:     limit,
+		Used:      min(used, limit),
+		Remaining: limit - used,
+		ResetAt:   resetAt,
+	}
+}
+
+// getQuotaKey returns the key of the quota of a client for the UTC day of
+// now, and the end of that day
+func getQuotaKey(clientID string, now time.Time) (string, time.Time) {
+	day := now.UTC().Truncate(24 * time.Hour)
+	return fmt.Sprintf("quota:{%s}:%s", clientID, day.Format("20060102")), day.Add(24 * time.Hour)
+}
@@ -3,7 +3,9 @@ package utils
 import (
 	"context"
 	"fmt"
+	"log/slog"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -22,71 +24,172 @@ type RateLimitConfig struct {
 	Window      int64 // Time window in seconds
 }
 
+var operationTypes = []OperationType{OperationRead, OperationWrite, OperationPrefetch}
+
+func (op OperationType) valid() bool {
+	return op == OperationRead || op == OperationWrite || op == OperationPrefetch
+}
+
+// RateLimitRequest describes the request being counted, Path and Role pick
+// the route group and role limits of the policy
+type RateLimitRequest struct {
+	ClientID  string
+	Operation OperationType
+	Path      string
+	Role      string
+}
+
 type RateLimiter struct {
 	RedisClient RedisClientInterface
-	operationLimits map[OperationType]int
-	Window          time.Duration
-	mu              sync.RWMutex
+
+	// policy is replaced as a whole, mu serializes the replacements
+	policy atomic.Pointer[RateLimitPolicy]
+	mu     sync.Mutex
+
+	// Fallback counts the requests while Redis is missing or failing, after a
+	// failure Redis is tried again once RetryInterval has passed. A Redis call
+	// fails after RedisTimeout, so a hanging Redis only delays the requests
+	// that find out.
+	Fallback      *MemoryRateStore
+	RetryInterval time.Duration
+	RedisTimeout  time.Duration
+	redisDownUntil atomic.Int64 // unix nanoseconds
+
+	// rejected counts the requests turned down per operation
+	rejected map[OperationType]*atomic.Uint64
 }
 
+// NewRateLimiter creates a rate limiter backed by Redis, with a nil client
+// every request is counted in memory. It starts with DefaultRateLimitPolicy.
 func NewRateLimiter(redisClient RedisClientInterface) *RateLimiter {
-	return &RateLimiter{
-		RedisClient: redisClient,
-		operationLimits: map[OperationType]int{
-			OperationRead:     100, 
-			OperationWrite:    30,  
-			OperationPrefetch: 200, 
-		},
-		Window: time.Minute, 
-		mu:     sync.RWMutex{},
+	r := &RateLimiter{
+		RedisClient:   redisClient,
+		Fallback:      NewMemoryRateStore(0),
+		RetryInterval: 10 * time.Second,
+		RedisTimeout:  100 * time.Millisecond,
+		rejected:      make(map[OperationType]*atomic.Uint64, len(operationTypes)),
+	}
+	for _, op := range operationTypes {
+		r.rejected[op] = &atomic.Uint64{}
+	}
+	r.policy.Store(DefaultRateLimitPolicy())
+	return r
+}
+
+// Start sweeps the expired windows of the memory fallback until ctx is cancelled
+func (r *RateLimiter) Start(ctx context.Context) {
+	r.Fallback.StartJanitor(ctx, time.Minute)
+}
+
+// UsingFallback reports whether requests are currently counted in memory
+func (r *RateLimiter) UsingFallback() bool {
+	return r.RedisClient == nil || time.Now().UnixNano() < r.redisDownUntil.Load()
+}
+
+// Policy returns a copy of the policy in use
+func (r *RateLimiter) Policy() *RateLimitPolicy {
+	return r.policy.Load().Clone()
+}
+
+// SetPolicy validates policy and applies it to the next requests, the counts
+// of the current windows are kept
+func (r *RateLimiter) SetPolicy(policy *RateLimitPolicy) error {
+	if policy == nil {
+		return fmt.Errorf("rate limit policy is nil")
+	}
+	if err := policy.Validate(); err != nil {
+		return fmt.Errorf("invalid rate limit policy: %w", err)
 	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.policy.Store(policy.Clone())
+	return nil
 }
 
-// SetLimitForOperation sets the rate limit for a specific operation type
+// SetLimitForOperation sets the default rate limit for a specific operation type
 func (r *RateLimiter) SetLimitForOperation(op OperationType, limit int) {
 	r.mu.Lock()
 	defer r.mu.Unlock()
-	r.operationLimits[op] = limit
+	policy := r.policy.Load().Clone()
+	policy.Operations[op] = int64(limit)
+	r.policy.Store(policy)
 }
 
-// GetLimitForOperation gets the rate limit for a specific operation type
+// GetLimitForOperation gets the default rate limit for a specific operation type
 func (r *RateLimiter) GetLimitForOperation(op OperationType) int {
-	r.mu.RLock()
-	defer r.mu.RUnlock()
-	if limit, exists := r.operationLimits[op]; exists {
-		return limit
+	policy := r.policy.Load()
+	if limit, exists := policy.Operations[op]; exists {
+		return int(limit)
 	}
-	return r.operationLimits[OperationRead] // Default to read limit
+	return int(policy.Operations[OperationRead]) // Default to read limit
 }
 
-// Allow checks if a request is allowed for a specific operation type
+// Allow checks if a request is allowed for a specific operation type, the
+// returned time is when a rejected client may retry
 func (r *RateLimiter) Allow(clientID string, op OperationType) (bool, int64, time.Time, error) {
-	r.mu.RLock()
-	defer r.mu.RUnlock()
-
-	// Get the appropriate rate limit configuration based on operation type
-	var config *RateLimitConfig
-	switch op {
-	case OperationRead:
-		config = &RateLimitConfig{
-			MaxRequests: 100, // Default read limit
-			Window:      60,  // 1 minute window
-		}
-	case OperationWrite:
-		config = &RateLimitConfig{
-			MaxRequests: 50, // Default write limit
-			Window:      60, // 1 minute window
-		}
-	case OperationPrefetch:
-		config = &RateLimitConfig{
-			MaxRequests: 200, // Default prefetch limit
-			Window:      60,  // 1 minute window
+	result, err := r.Take(clientID, op)
+	if err != nil {
+		return false, 0, time.Time{}, err
+	}
+	return result.Allowed, result.Limit, result.retryTime(), nil
+}
+
+// Take counts a request for a specific operation type with the default
+// limits of the policy and returns the remaining quota of the client
+func (r *RateLimiter) Take(clientID string, op OperationType) (RateLimitResult, error) {
+	return r.TakeRequest(RateLimitRequest{ClientID: clientID, Operation: op})
+}
+
+// TakeRequest counts a request with the limits the policy gives to its
+// operation, route group and role, then against the daily quota of the role.
+// Each operation and route group is counted separately.
+func (r *RateLimiter) TakeRequest(request RateLimitRequest) (RateLimitResult, error) {
+	policy := r.policy.Load()
+	config, group, err := policy.Resolve(request.Operation, request.Path, request.Role)
+	if err != nil {
+		return RateLimitResult{}, err
+	}
+
+	identifier := request.ClientID + ":" + string(request.Operation)
+	if group != "" {
+		identifier += ":" + group
+	}
+	result, err := r.takeOperation(identifier, config, policy.Algorithm)
+	if err != nil {
+		return result, err
+	}
+	if !result.Allowed {
+		r.reject(request.Operation)
+		return result, nil
+	}
+
+	if limit := policy.DailyQuota(request.Role); limit > 0 {
+		result.Quota = r.takeQuota(request.ClientID, limit, time.Now())
+		if result.Quota.Remaining < 0 {
+			result.Allowed = false
+			result.RetryAfter = ceilMillisecond(time.Until(result.Quota.ResetAt))
+			result.Quota.Remaining = 0
+			r.reject(request.Operation)
 		}
-	default:
-		return false, 0, time.Time{}, fmt.Errorf("unknown operation type: %s", op)
 	}
+	return result, nil
+}
 
-	return r.AllowOperation(clientID, config)
+// Rejections returns the number of requests turned down per operation, by
+// the rate limits or the daily quota
+func (r *RateLimiter) Rejections() map[OperationType]uint64 {
+	rejections := make(map[OperationType]uint64, len(r.rejected))
+	for op, count := range r.rejected {
+		rejections[op] = count.Load()
+	}
+	return rejections
+}
+
+func (r *RateLimiter) reject(op OperationType) {
+	if count, ok := r.rejected[op]; ok {
+		count.Add(1)
+	}
 }
 
 // validateRateLimitConfig validates the rate limit configuration
@@ -105,7 +208,9 @@ func validateRateLimitConfig(config *RateLimitConfig) error {
 
 // getRateLimitKey generates the Redis key for rate limiting
 func getRateLimitKey(identifier string, config *RateLimitConfig) string {
-	return fmt.Sprintf("rate_limit:%s:%d", identifier, config.Window)
+	// The braces keep the keys of a client in one slot of a Redis cluster, the
+	// sliding window script reads two of them
+	return fmt.Sprintf("rate_limit:{%s}:%d", identifier, config.Window)
 }
 
 // checkRateLimit checks if the operation is allowed based on current count
@@ -115,33 +220,67 @@ func checkRateLimit(currentCount int64, config *RateLimitConfig) bool {
 
 // AllowOperation checks if an operation is allowed based on rate limiting rules
 func (rl *RateLimiter) AllowOperation(identifier string, config *RateLimitConfig) (bool, int64, time.Time, error) {
-	if err := validateRateLimitConfig(config); err != nil {
+	result, err := rl.TakeOperation(identifier, config)
+	if err != nil {
 		return false, 0, time.Time{}, err
 	}
+	return result.Allowed, result.Limit, result.retryTime(), nil
+}
+
+// TakeOperation counts an operation with the limiter algorithm, in Redis or
+// in the memory fallback while Redis is unavailable. Counts are not carried
+// over between the two, a client may get up to one extra window worth of
+// requests when the store switches.
+func (rl *RateLimiter) TakeOperation(identifier string, config *RateLimitConfig) (RateLimitResult, error) {
+	return rl.takeOperation(identifier, config, rl.policy.Load().Algorithm)
+}
+
+func (rl *RateLimiter) takeOperation(identifier string, config *RateLimitConfig, algorithm RateLimitAlgorithm) (RateLimitResult, error) {
+	if err := validateRateLimitConfig(config); err != nil {
+		return RateLimitResult{}, err
+	}
 
 	key := getRateLimitKey(identifier, config)
-	ctx := context.Background()
 	now := time.Now()
+	window := time.Duration(config.Window) * time.Second
 
-	// Get current count
-	currentCount, err := rl.RedisClient.Incr(ctx, key).Result()
-	if err != nil {
-		return false, 0, time.Time{}, fmt.Errorf("failed to increment rate limit counter: %w", err)
+	var result RateLimitResult
+	if rl.viaRedis(now, func(ctx context.Context) (err error) {
+		result, err = rl.takeRedis(ctx, key, algorithm, config.MaxRequests, window, now)
+		return err
+	}) {
+		return result, nil
+	}
+	return rl.Fallback.Take(key, algorithm, config.MaxRequests, window, now)
+}
+
+// viaRedis runs fn on Redis unless it is known to be down. It returns false
+// when fn wasn't run or failed, the memory fallback has to answer instead.
+func (rl *RateLimiter) viaRedis(now time.Time, fn func(ctx context.Context) error) bool {
+	if rl.UsingFallback() {
+		return false
 	}
 
-	// Set expiration if this is the first request
-	if currentCount == 1 {
-		if err := rl.RedisClient.Expire(ctx, key, time.Duration(config.Window)*time.Second).Err(); err != nil {
-			return false, 0, time.Time{}, fmt.Errorf("failed to set rate limit expiration: %w", err)
+	ctx, cancel := context.WithTimeout(context.Background(), rl.RedisTimeout)
+	err := fn(ctx)
+	cancel()
+	if err == nil {
+		if rl.redisDownUntil.Swap(0) != 0 {
+			slog.Info("Rate limiter Redis recovered, switching back from the memory fallback")
 		}
+		return true
 	}
 
-	// Check if we're over the limit
-	if currentCount > config.MaxRequests {
-		// Calculate retry time based on window expiration
-		retryTime := now.Add(time.Duration(config.Window) * time.Second)
-		return false, config.MaxRequests, retryTime, nil
+	if rl.redisDownUntil.Swap(now.Add(rl.RetryInterval).UnixNano()) == 0 {
+		slog.Warn("Rate limiter Redis failing, counting requests in memory", "error", err)
 	}
+	return false
+}
 
-	return true, config.MaxRequests, time.Time{}, nil
-}
\ No newline at end of file
+// retryTime is when a rejected request may be retried, zero when allowed
+func (r RateLimitResult) retryTime() time.Time {
+	if r.Allowed {
+		return time.Time{}
+	}
+	return time.Now().Add(r.RetryAfter)
+}
@@ -0,0 +1,175 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// defaultMemoryRateLimitKeys bounds the windows kept by a MemoryRateStore
+const defaultMemoryRateLimitKeys = 100000
+
+// MemoryRateStore runs the rate limit algorithms in process, it backs the
+// RateLimiter when Redis is not configured or not answering. Counters are per
+// instance, so with several replicas each one enforces the limit on its own.
+type MemoryRateStore struct {
+	mu      sync.Mutex
+	windows map[string]*memoryWindow
+	maxKeys int
+}
+
+// memoryWindow is a fixed window counter or a token bucket
+type memoryWindow struct {
+	count     int64
+	tokens    float64
+	updatedAt time.Time
+	expiresAt time.Time
+}
+
+// NewMemoryRateStore creates a store holding at most maxKeys windows, the
+// windows closest to expiring are dropped first when it is full
+func NewMemoryRateStore(maxKeys int) *MemoryRateStore {
+	if maxKeys <= 0 {
+		maxKeys = defaultMemoryRateLimitKeys
+	}
+	return &MemoryRateStore{
+		windows: make(map[string]*memoryWindow),
+		maxKeys: maxKeys,
+	}
+}
+
+// Take counts a request of the window under key with algorithm, the same way
+// the Redis scripts do
+func (s *MemoryRateStore) Take(key string, algorithm RateLimitAlgorithm, limit int64, window time.Duration, now time.Time) (RateLimitResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch algorithm {
+	case AlgorithmFixedWindow:
+		w := s.window(key+":fw", now, window)
+		w.count++
+		return fixedWindowResult(w.count, limit, w.expiresAt.Sub(now), now), nil
+
+	case AlgorithmSlidingWindow:
+		position := newSlidingWindow(now, window)
+		var previous int64
+		if w, ok := s.windows[key+":sw:"+strconv.FormatInt(position.index-1, 10)]; ok && now.Before(w.expiresAt) {
+			previous = w.count
+		}
+		current := s.window(key+":sw:"+strconv.FormatInt(position.index, 10), now, 2*window)
+		if position.estimate(previous, current.count, window)+1 > float64(limit) {
+			return position.result(false, previous, current.count, limit, window, now), nil
+		}
+		current.count++
+		current.expiresAt = now.Add(2 * window)
+		return position.result(true, previous, current.count, limit, window, now), nil
+
+	case AlgorithmTokenBucket:
+		w, ok := s.windows[key+":tb"]
+		if !ok || !now.Before(w.expiresAt) {
+			w = s.window(key+":tb", now, window)
+			w.tokens = float64(limit)
+			w.updatedAt = now
+		}
+		elapsed := max(now.Sub(w.updatedAt), 0)
+		w.tokens = min(float64(limit), w.tokens+float64(elapsed)*float64(limit)/float64(window))
+		allowed := w.tokens >= 1
+		if allowed {
+			w.tokens--
+		}
+		w.updatedAt = now
+		w.expiresAt = now.Add(window)
+		return tokenBucketResult(allowed, w.tokens, limit, window, now), nil
+
+	default:
+		return RateLimitResult{}, fmt.Errorf("unknown rate limit algorithm: %q", algorithm)
+	}
+}
+
+// TakeQuota counts a request on the quota under key, which resets at resetAt,
+// and returns the requests counted so far
+func (s *MemoryRateStore) TakeQuota(key string, resetAt, now time.Time) int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	w := s.window(key, now, resetAt.Sub(now))
+	w.count++
+	return w.count
+}
+
+// QuotaUsed returns the requests counted on the quota under key
+func (s *MemoryRateStore) QuotaUsed(key string, now time.Time) int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if w, ok := s.windows[key]; ok && now.Before(w.expiresAt) {
+		return w.count
+	}
+	return 0
+}
+
+// window returns the live window under key, starting one of length ttl when
+// it is missing or expired. The caller holds the mutex.
+func (s *MemoryRateStore) window(key string, now time.Time, ttl time.Duration) *memoryWindow {
+	w, ok := s.windows[key]
+	if ok && now.Before(w.expiresAt) {
+		return w
+	}
+	if !ok && len(s.windows) >= s.maxKeys {
+		s.evict(now)
+	}
+	w = &memoryWindow{expiresAt: now.Add(ttl)}
+	s.windows[key] = w
+	return w
+}
+
+// Len returns the number of windows held
+func (s *MemoryRateStore) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.windows)
+}
+
+// StartJanitor drops expired windows every interval until ctx is cancelled
+func (s *MemoryRateStore) StartJanitor(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			s.mu.Lock()
+			s.sweep(now)
+			s.mu.Unlock()
+		}
+	}
+}
+
+// sweep drops the expired windows, the caller holds the mutex
+func (s *MemoryRateStore) sweep(now time.Time) {
+	for key, w := range s.windows {
+		if !now.Before(w.expiresAt) {
+			delete(s.windows, key)
+		}
+	}
+}
+
+// evict makes room for a new window, the caller holds the mutex
+func (s *MemoryRateStore) evict(now time.Time) {
+	s.sweep(now)
+	if len(s.windows) < s.maxKeys {
+		return
+	}
+
+	var oldest string
+	for key, w := range s.windows {
+		if oldest == "" || w.expiresAt.Before(s.windows[oldest].expiresAt) {
+			oldest = key
+		}
+	}
+	delete(s.windows, oldest)
+}
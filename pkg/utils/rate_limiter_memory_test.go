@@ -0,0 +1,191 @@
+package utils_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hftamayo/gotodo/pkg/utils"
+)
+
+// epoch starts a 10s window, so offsets from it are positions in the window
+var epoch = time.Unix(1_000_000_000, 0)
+
+// step is a request at an offset from epoch and its expected outcome
+type step struct {
+	at            time.Duration
+	wantAllowed   bool
+	wantRemaining int64
+	wantRetry     time.Duration // only checked on rejections
+}
+
+func runSteps(t *testing.T, store *utils.MemoryRateStore, algorithm utils.RateLimitAlgorithm, limit int64, window time.Duration, steps []step) {
+	t.Helper()
+	for i, s := range steps {
+		result, err := store.Take("client", algorithm, limit, window, epoch.Add(s.at))
+		if err != nil {
+			t.Fatalf("step %d: Take() error = %v", i, err)
+		}
+		if result.Allowed != s.wantAllowed || result.Remaining != s.wantRemaining {
+			t.Fatalf("step %d at %s: allowed %v remaining %d, want %v remaining %d",
+				i, s.at, result.Allowed, result.Remaining, s.wantAllowed, s.wantRemaining)
+		}
+		if !s.wantAllowed && result.RetryAfter != s.wantRetry {
+			t.Fatalf("step %d at %s: retry after %s, want %s", i, s.at, result.RetryAfter, s.wantRetry)
+		}
+		if s.wantAllowed && result.RetryAfter != 0 {
+			t.Fatalf("step %d at %s: retry after %s on an allowed request", i, s.at, result.RetryAfter)
+		}
+	}
+}
+
+func TestMemoryRateStoreFixedWindow(t *testing.T) {
+	tests := []struct {
+		name  string
+		steps []step
+	}{
+		{"limit then reject until the window expires", []step{
+			{0, true, 2, 0},
+			{time.Second, true, 1, 0},
+			{2 * time.Second, true, 0, 0},
+			{3 * time.Second, false, 0, 7 * time.Second},
+			{9 * time.Second, false, 0, time.Second},
+			{10 * time.Second, true, 2, 0},
+		}},
+		{"window starts on the first request", []step{
+			{5 * time.Second, true, 2, 0},
+			{14 * time.Second, true, 1, 0},
+			{14 * time.Second, true, 0, 0},
+			{14 * time.Second, false, 0, time.Second},
+			{15 * time.Second, true, 2, 0},
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runSteps(t, utils.NewMemoryRateStore(0), utils.AlgorithmFixedWindow, 3, 10*time.Second, tt.steps)
+		})
+	}
+}
+
+func TestMemoryRateStoreSlidingWindow(t *testing.T) {
+	burst := func(at time.Duration, n int, limit int64) []step {
+		steps := make([]step, n)
+		for i := range steps {
+			steps[i] = step{at, true, limit - int64(i) - 1, 0}
+		}
+		return steps
+	}
+
+	tests := []struct {
+		name  string
+		steps []step
+	}{
+		{"no burst at the window boundary", append(burst(9*time.Second, 10, 10),
+			// The rejected request waits for the estimate to leave room for one
+			step{9 * time.Second, false, 0, 2 * time.Second},
+			// The previous window still counts in full right after the boundary
+			step{10 * time.Second, false, 0, time.Second},
+			step{10500 * time.Millisecond, false, 0, 500 * time.Millisecond},
+			// 10 * 0.9 = 9 requests are still counted, leaving one
+			step{11 * time.Second, true, 0, 0},
+			step{11 * time.Second, false, 0, time.Second},
+		)},
+		{"previous window weighs less as it slides out", append(burst(0, 10, 10),
+			// At 15s half of the previous window is left: 5 counted, 5 allowed
+			step{15 * time.Second, true, 4, 0},
+			step{15 * time.Second, true, 3, 0},
+			step{15 * time.Second, true, 2, 0},
+			step{15 * time.Second, true, 1, 0},
+			step{15 * time.Second, true, 0, 0},
+			step{15 * time.Second, false, 0, time.Second},
+		)},
+		{"windows older than the previous one are forgotten", append(burst(0, 10, 10),
+			step{20 * time.Second, true, 9, 0},
+		)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runSteps(t, utils.NewMemoryRateStore(0), utils.AlgorithmSlidingWindow, 10, 10*time.Second, tt.steps)
+		})
+	}
+}
+
+func TestMemoryRateStoreTokenBucket(t *testing.T) {
+	// 5 tokens per 10s, one every 2s
+	tests := []struct {
+		name  string
+		steps []step
+	}{
+		{"burst up to the limit", []step{
+			{0, true, 4, 0},
+			{0, true, 3, 0},
+			{0, true, 2, 0},
+			{0, true, 1, 0},
+			{0, true, 0, 0},
+			{0, false, 0, 2 * time.Second},
+		}},
+		{"refill is continuous", []step{
+			{0, true, 4, 0}, {0, true, 3, 0}, {0, true, 2, 0}, {0, true, 1, 0}, {0, true, 0, 0},
+			// Half a token after 1s, the rejection doesn't consume it
+			{time.Second, false, 0, time.Second},
+			{2 * time.Second, true, 0, 0},
+			{8 * time.Second, true, 2, 0},
+		}},
+		{"refill is capped at the limit", []step{
+			{0, true, 4, 0},
+			// 4 + 4.5 tokens are capped at 5
+			{9 * time.Second, true, 4, 0},
+			{9 * time.Second, true, 3, 0},
+			{9 * time.Second, true, 2, 0},
+			{9 * time.Second, true, 1, 0},
+			{9 * time.Second, true, 0, 0},
+			{9 * time.Second, false, 0, 2 * time.Second},
+		}},
+		{"an idle bucket is full again", []step{
+			{0, true, 4, 0}, {0, true, 3, 0}, {0, true, 2, 0}, {0, true, 1, 0}, {0, true, 0, 0},
+			{time.Minute, true, 4, 0},
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runSteps(t, utils.NewMemoryRateStore(0), utils.AlgorithmTokenBucket, 5, 10*time.Second, tt.steps)
+		})
+	}
+}
+
+func TestMemoryRateStoreCountsKeysSeparately(t *testing.T) {
+	store := utils.NewMemoryRateStore(0)
+	for _, key := range []string{"a", "b"} {
+		result, err := store.Take(key, utils.AlgorithmFixedWindow, 1, time.Minute, epoch)
+		if err != nil || !result.Allowed {
+			t.Fatalf("first request of %s = %+v, %v, want allowed", key, result, err)
+		}
+	}
+	if result, _ := store.Take("a", utils.AlgorithmFixedWindow, 1, time.Minute, epoch); result.Allowed {
+		t.Fatal("second request of a allowed over a limit of 1")
+	}
+}
+
+func TestMemoryRateStoreBoundsItsWindows(t *testing.T) {
+	store := utils.NewMemoryRateStore(3)
+	for i, key := range []string{"a", "b", "c", "d", "e"} {
+		if _, err := store.Take(key, utils.AlgorithmFixedWindow, 1, time.Minute, epoch.Add(time.Duration(i)*time.Second)); err != nil {
+			t.Fatalf("Take(%s) error = %v", key, err)
+		}
+	}
+	if n := store.Len(); n != 3 {
+		t.Fatalf("Len() = %d, want 3", n)
+	}
+	// The window closest to expiring was dropped, so a is counted afresh
+	if result, _ := store.Take("a", utils.AlgorithmFixedWindow, 1, time.Minute, epoch.Add(5*time.Second)); !result.Allowed {
+		t.Fatal("evicted window still counted")
+	}
+}
+
+func TestMemoryRateStoreUnknownAlgorithm(t *testing.T) {
+	if _, err := utils.NewMemoryRateStore(0).Take("client", "leaky_bucket", 1, time.Minute, epoch); err == nil {
+		t.Fatal("Take() with an unknown algorithm succeeded")
+	}
+}
@@ -0,0 +1,139 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Each script counts a request atomically, so concurrent requests of a client
+// on several instances never read the same count
+
+// fixedWindowScript returns {count, pttl}
+var fixedWindowScript = redis.NewScript(`
+local count = redis.call('INCR', KEYS[1])
+local ttl = redis.call('PTTL', KEYS[1])
+if ttl < 0 then
+    redis.call('PEXPIRE', KEYS[1], ARGV[1])
+    ttl = tonumber(ARGV[1])
+end
+return {count, ttl}
+`)
+
+// slidingWindowScript returns {allowed, previous, current}, a rejected request
+// is not counted so a client hammering the limit is not locked out for longer
+var slidingWindowScript = redis.NewScript(`
+local current = tonumber(redis.call('GET', KEYS[1]) or '0')
+local previous = tonumber(redis.call('GET', KEYS[2]) or '0')
+local limit = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+local elapsed = tonumber(ARGV[3])
+
+if previous * (window - elapsed) / window + current + 1 > limit then
+    return {0, previous, current}
+end
+current = redis.call('INCR', KEYS[1])
+redis.call('PEXPIRE', KEYS[1], window * 2)
+return {1, previous, current}
+`)
+
+// tokenBucketScript returns {allowed, tokens left}, tokens as a string since
+// Lua numbers are truncated to integers in replies
+var tokenBucketScript = redis.NewScript(`
+local limit = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+
+local state = redis.call('HMGET', KEYS[1], 'tokens', 'ts')
+local tokens = tonumber(state[1])
+local ts = tonumber(state[2])
+if tokens == nil or ts == nil then
+    tokens = limit
+    ts = now
+end
+
+tokens = math.min(limit, tokens + math.max(0, now - ts) * limit / window)
+local allowed = 0
+if tokens >= 1 then
+    tokens = tokens - 1
+    allowed = 1
+end
+redis.call('HSET', KEYS[1], 'tokens', tostring(tokens), 'ts', now)
+redis.call('PEXPIRE', KEYS[1], window)
+return {allowed, tostring(tokens)}
+`)
+
+// quotaScript returns the requests counted in the day of the quota key, the
+// key expires at the end of the day
+var quotaScript = redis.NewScript(`
+local count = redis.call('INCR', KEYS[1])
+if count == 1 then
+    redis.call('PEXPIREAT', KEYS[1], ARGV[1])
+end
+return count
+`)
+
+// takeRedis counts a request of the window under key in Redis
+func (rl *RateLimiter) takeRedis(ctx context.Context, key string, algorithm RateLimitAlgorithm, limit int64, window time.Duration, now time.Time) (RateLimitResult, error) {
+	windowMs := window.Milliseconds()
+
+	switch algorithm {
+	case AlgorithmFixedWindow:
+		reply, err := fixedWindowScript.Run(ctx, rl.RedisClient, []string{key + ":fw"}, windowMs).Int64Slice()
+		if err != nil {
+			return RateLimitResult{}, fmt.Errorf("failed to increment rate limit counter: %w", err)
+		}
+		return fixedWindowResult(reply[0], limit, time.Duration(reply[1])*time.Millisecond, now), nil
+
+	case AlgorithmSlidingWindow:
+		position := newSlidingWindow(now, window)
+		keys := []string{
+			key + ":sw:" + strconv.FormatInt(position.index, 10),
+			key + ":sw:" + strconv.FormatInt(position.index-1, 10),
+		}
+		reply, err := slidingWindowScript.Run(ctx, rl.RedisClient, keys, limit, windowMs, position.elapsed.Milliseconds()).Int64Slice()
+		if err != nil {
+			return RateLimitResult{}, fmt.Errorf("failed to update rate limit window: %w", err)
+		}
+		return position.result(reply[0] == 1, reply[1], reply[2], limit, window, now), nil
+
+	case AlgorithmTokenBucket:
+		reply, err := tokenBucketScript.Run(ctx, rl.RedisClient, []string{key + ":tb"}, limit, windowMs, now.UnixMilli()).Slice()
+		if err != nil {
+			return RateLimitResult{}, fmt.Errorf("failed to take rate limit token: %w", err)
+		}
+		allowed, _ := reply[0].(int64)
+		tokensReply, _ := reply[1].(string)
+		tokens, err := strconv.ParseFloat(tokensReply, 64)
+		if err != nil {
+			return RateLimitResult{}, fmt.Errorf("invalid rate limit token count %q: %w", tokensReply, err)
+		}
+		return tokenBucketResult(allowed == 1, tokens, limit, window, now), nil
+
+	default:
+		return RateLimitResult{}, fmt.Errorf("unknown rate limit algorithm: %q", algorithm)
+	}
+}
+
+func (rl *RateLimiter) takeQuotaRedis(ctx context.Context, key string, resetAt time.Time) (int64, error) {
+	used, err := quotaScript.Run(ctx, rl.RedisClient, []string{key}, resetAt.UnixMilli()).Int64()
+	if err != nil {
+		return 0, fmt.Errorf("failed to count daily quota: %w", err)
+	}
+	return used, nil
+}
+
+func (rl *RateLimiter) quotaUsedRedis(ctx context.Context, key string) (int64, error) {
+	used, err := rl.RedisClient.Get(ctx, key).Int64()
+	if errors.Is(err, redis.Nil) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to read daily quota: %w", err)
+	}
+	return used, nil
+}
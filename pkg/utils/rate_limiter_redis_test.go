@@ -0,0 +1,116 @@
+package utils
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+func newMiniredisLimiter(t *testing.T) (*RateLimiter, *miniredis.Miniredis) {
+	t.Helper()
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { client.Close() })
+	return NewRateLimiter(client), server
+}
+
+// TestRedisScriptsMatchMemoryStore replays the same requests on the Lua
+// scripts and on the memory store, which must decide them the same way
+func TestRedisScriptsMatchMemoryStore(t *testing.T) {
+	const window = 10 * time.Second
+	epoch := time.Unix(1_000_000_000, 0)
+	ms := time.Millisecond
+
+	tests := []struct {
+		name      string
+		algorithm RateLimitAlgorithm
+		limit     int64
+		offsets   []time.Duration
+	}{
+		{"fixed window across expiry", AlgorithmFixedWindow, 3,
+			[]time.Duration{0, 1000 * ms, 2000 * ms, 3000 * ms, 9999 * ms, 10000 * ms, 10001 * ms}},
+		{"sliding window boundary", AlgorithmSlidingWindow, 10,
+			[]time.Duration{9000 * ms, 9000 * ms, 9000 * ms, 9000 * ms, 9000 * ms, 9000 * ms, 9000 * ms, 9000 * ms, 9000 * ms, 9000 * ms,
+				9000 * ms, 10000 * ms, 10500 * ms, 11000 * ms, 11000 * ms, 15000 * ms, 15000 * ms, 31000 * ms}},
+		{"token bucket burst and refill", AlgorithmTokenBucket, 5,
+			[]time.Duration{0, 0, 0, 0, 0, 0, 1000 * ms, 2000 * ms, 2001 * ms, 3333 * ms, 8000 * ms, 8000 * ms, 30000 * ms}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			limiter, server := newMiniredisLimiter(t)
+			store := NewMemoryRateStore(0)
+
+			var elapsed time.Duration
+			for i, offset := range tt.offsets {
+				server.FastForward(offset - elapsed)
+				elapsed = offset
+				now := epoch.Add(offset)
+
+				want, err := store.Take("client", tt.algorithm, tt.limit, window, now)
+				if err != nil {
+					t.Fatalf("request %d: memory Take() error = %v", i, err)
+				}
+				got, err := limiter.takeRedis(context.Background(), "client", tt.algorithm, tt.limit, window, now)
+				if err != nil {
+					t.Fatalf("request %d: takeRedis() error = %v", i, err)
+				}
+
+				if got.Allowed != want.Allowed || got.Remaining != want.Remaining || got.RetryAfter != want.RetryAfter {
+					t.Fatalf("request %d at %s: redis allowed %v remaining %d retry %s, memory allowed %v remaining %d retry %s",
+						i, offset, got.Allowed, got.Remaining, got.RetryAfter, want.Allowed, want.Remaining, want.RetryAfter)
+				}
+				if diff := got.ResetAt.Sub(want.ResetAt).Abs(); diff > ms {
+					t.Fatalf("request %d at %s: redis resets at %s, memory at %s", i, offset, got.ResetAt, want.ResetAt)
+				}
+			}
+		})
+	}
+}
+
+func TestRedisQuotaScript(t *testing.T) {
+	limiter, server := newMiniredisLimiter(t)
+	now := time.Now()
+	key, resetAt := getQuotaKey("user:1", now)
+
+	for want := int64(1); want <= 3; want++ {
+		used, err := limiter.takeQuotaRedis(context.Background(), key, resetAt)
+		if err != nil || used != want {
+			t.Fatalf("takeQuotaRedis() = %d, %v, want %d", used, err, want)
+		}
+	}
+	if used, err := limiter.quotaUsedRedis(context.Background(), key); err != nil || used != 3 {
+		t.Fatalf("quotaUsedRedis() = %d, %v, want 3", used, err)
+	}
+	if ttl := server.TTL(key); ttl <= 0 || ttl > 24*time.Hour {
+		t.Fatalf("quota key TTL = %s, want until the end of the day", ttl)
+	}
+}
+
+func TestRateLimiterFallsBackWhenRedisFails(t *testing.T) {
+	limiter, server := newMiniredisLimiter(t)
+	limiter.SetLimitForOperation(OperationRead, 2)
+
+	if _, err := limiter.Take("ip:203.0.113.7", OperationRead); err != nil {
+		t.Fatalf("Take() error = %v", err)
+	}
+	if limiter.UsingFallback() {
+		t.Fatal("counting in memory while Redis answers")
+	}
+
+	server.Close()
+	for i := 0; i < 2; i++ {
+		if result, err := limiter.Take("ip:203.0.113.7", OperationRead); err != nil || !result.Allowed {
+			t.Fatalf("request %d with Redis down = %+v, %v, want allowed from memory", i, result, err)
+		}
+	}
+	if !limiter.UsingFallback() {
+		t.Fatal("not counting in memory after a Redis failure")
+	}
+	if result, _ := limiter.Take("ip:203.0.113.7", OperationRead); result.Allowed {
+		t.Fatal("memory fallback let a request over the limit through")
+	}
+}